@@ -0,0 +1,22 @@
+package approvals
+
+import (
+	listCmd "github.com/katiem0/gh-environments/cmd/approvals/list"
+	reviewCmd "github.com/katiem0/gh-environments/cmd/approvals/review"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdApprovals() *cobra.Command {
+
+	cmd := &cobra.Command{
+		Use:   "approvals <command>",
+		Short: "List and review deployments waiting for approval.",
+		Long:  "List workflow runs waiting for a required reviewer to approve deployment to an environment, and approve or reject them in bulk.",
+	}
+	cmd.Flags().Bool("help", false, "Show help for command")
+
+	cmd.AddCommand(listCmd.NewCmdList())
+	cmd.AddCommand(reviewCmd.NewCmdReview())
+
+	return cmd
+}
@@ -0,0 +1,23 @@
+package approvals
+
+import (
+	"testing"
+)
+
+func TestNewCmdApprovals(t *testing.T) {
+	cmd := NewCmdApprovals()
+
+	if cmd.Use != "approvals <command>" {
+		t.Errorf("Unexpected Use %q", cmd.Use)
+	}
+
+	found := make(map[string]bool)
+	for _, subcmd := range cmd.Commands() {
+		found[subcmd.Name()] = true
+	}
+	for _, name := range []string{"list", "review"} {
+		if !found[name] {
+			t.Errorf("%s subcommand not found", name)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package approvalslist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+type cmdFlags struct {
+	format     string
+	repoFilter utils.RepoFilterFlags
+	envFilter  utils.EnvFilterFlags
+}
+
+func NewCmdList() *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	listCmd := cobra.Command{
+		Use:   "list [flags] <organization> [repo ...]",
+		Short: "List deployments waiting for approval.",
+		Long:  "List the workflow runs in an organization that are waiting for a required reviewer to approve deployment to an environment.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(listCmd *cobra.Command, args []string) error {
+			ctx := listCmd.Context()
+			var err error
+
+			if cmdFlags.format != "table" && cmdFlags.format != "json" {
+				return exitcode.New(exitcode.ValidationFailure, fmt.Errorf("invalid format %q, expected table or json", cmdFlags.format))
+			}
+
+			repos, err := cmdFlags.repoFilter.Repos(args[1:])
+			if err != nil {
+				return err
+			}
+			filter, err := cmdFlags.repoFilter.Filter()
+			if err != nil {
+				return err
+			}
+			envs, err := cmdFlags.envFilter.Filter()
+			if err != nil {
+				return err
+			}
+
+			clientConfig := utils.ClientConfigFromFlags(listCmd.Flags())
+			clientConfig.Owner = args[0]
+			g, err := utils.NewGetter(clientConfig)
+			if err != nil {
+				return err
+			}
+
+			return runCmdList(ctx, args[0], repos, filter, envs, &cmdFlags, g, listCmd.OutOrStdout())
+		},
+	}
+
+	// Configure flags for command
+	listCmd.Flags().StringVarP(&cmdFlags.format, "format", "F", "table", "Output format: table or json")
+	cmdFlags.envFilter.AddFlags(listCmd.Flags())
+	cmdFlags.repoFilter.AddFlags(listCmd.Flags())
+
+	return &listCmd
+}
+
+func runCmdList(ctx context.Context, owner string, repos []string, filter utils.RepoFilter, envs utils.EnvFilter, cmdFlags *cmdFlags, g *utils.APIGetter, out io.Writer) error {
+	approvals, err := utils.GatherOrgPendingApprovals(ctx, g, owner, repos, filter, envs)
+	if err != nil {
+		return err
+	}
+
+	if cmdFlags.format == "json" {
+		if approvals == nil {
+			approvals = []utils.PendingApproval{}
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(approvals)
+	}
+
+	canApprove := 0
+	if len(approvals) > 0 {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Repository\tRun\tWorkflow\tBranch\tActor\tEnvironment\tCreated\tReviewers\tCan Approve")
+		for _, a := range approvals {
+			if a.CanApprove {
+				canApprove++
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%t\n", a.Repository, a.RunID, a.Workflow, a.Branch, a.Actor,
+				a.Environment, a.CreatedAt.UTC().Format(time.RFC3339), strings.Join(a.Reviewers, ","), a.CanApprove)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(out)
+	}
+	_, err = fmt.Fprintf(out, "%d deployment(s) waiting for approval, %d of which you can review\n", len(approvals), canApprove)
+	return err
+}
@@ -0,0 +1,81 @@
+package approvalslist
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/utils"
+)
+
+func TestNewCmdList(t *testing.T) {
+	cmd := NewCmdList()
+
+	if cmd.Use != "list [flags] <organization> [repo ...]" {
+		t.Errorf("Unexpected Use %q", cmd.Use)
+	}
+	for _, flag := range []string{"format", "env", "visibility"} {
+		if cmd.Flag(flag) == nil {
+			t.Errorf("%s flag not found", flag)
+		}
+	}
+
+	cmd.SetArgs([]string{"testorg", "--format", "csv"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "invalid format") {
+		t.Errorf("Expected format error, got %v", err)
+	}
+}
+
+func newApprovalsServer() *utils.MockGitHubServer {
+	server := utils.NewMockGitHubServer()
+	pending := data.PendingDeployment{
+		CurrentUserCanApprove: true,
+		Reviewers:             []data.Reviewers{{Type: "User", Reviewer: data.Reviewer{ID: 1, Login: "octocat"}}},
+	}
+	pending.Environment.ID = 100
+	pending.Environment.Name = "production"
+	server.AddRepo(1, "app").WaitingRuns = []*utils.MockWorkflowRun{{
+		WorkflowRun: data.WorkflowRun{ID: 42, Name: "Deploy", HeadBranch: "main", Actor: data.User{Login: "hubot"}, CreatedAt: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)},
+		Pending:     []data.PendingDeployment{pending},
+	}}
+	server.AddRepo(2, "web")
+	return server
+}
+
+func TestRunCmdList(t *testing.T) {
+	ctx := context.Background()
+	g, err := newApprovalsServer().APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err = runCmdList(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, &cmdFlags{format: "table"}, g, &out)
+	if err != nil {
+		t.Fatalf("runCmdList() error = %v", err)
+	}
+	for _, want := range []string{
+		"app         42   Deploy    main    hubot  production   2024-06-01T12:00:00Z  User:octocat  true",
+		"1 deployment(s) waiting for approval, 1 of which you can review",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	err = runCmdList(ctx, "testorg", []string{"web"}, utils.RepoFilter{}, utils.EnvFilter{}, &cmdFlags{format: "json"}, g, &out)
+	if err != nil {
+		t.Fatalf("runCmdList() error = %v", err)
+	}
+	var approvals []utils.PendingApproval
+	if err := json.Unmarshal(out.Bytes(), &approvals); err != nil || approvals == nil || len(approvals) != 0 {
+		t.Errorf("Expected an empty JSON list, got %q (%v)", out.String(), err)
+	}
+}
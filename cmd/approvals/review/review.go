@@ -0,0 +1,178 @@
+package approvalsreview
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"github.com/katiem0/gh-environments/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type cmdFlags struct {
+	approve    bool
+	reject     bool
+	yes        bool
+	comment    string
+	runs       []int
+	repoFilter utils.RepoFilterFlags
+	envFilter  utils.EnvFilterFlags
+}
+
+func NewCmdReview() *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	reviewCmd := cobra.Command{
+		Use:   "review [flags] <organization> [repo ...]",
+		Short: "Approve or reject deployments waiting for approval.",
+		Long:  "Approve or reject, in bulk, the deployments waiting for approval that you can review, selected by repository, environment and run.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(reviewCmd *cobra.Command, args []string) error {
+			ctx := reviewCmd.Context()
+			var err error
+
+			if cmdFlags.approve == cmdFlags.reject {
+				return exitcode.New(exitcode.ValidationFailure, errors.New("exactly one of --approve or --reject is required"))
+			}
+			if strings.TrimSpace(cmdFlags.comment) == "" {
+				return exitcode.New(exitcode.ValidationFailure, errors.New("--comment is required"))
+			}
+
+			repos, err := cmdFlags.repoFilter.Repos(args[1:])
+			if err != nil {
+				return err
+			}
+			filter, err := cmdFlags.repoFilter.Filter()
+			if err != nil {
+				return err
+			}
+			envs, err := cmdFlags.envFilter.Filter()
+			if err != nil {
+				return err
+			}
+
+			clientConfig := utils.ClientConfigFromFlags(reviewCmd.Flags())
+			clientConfig.Owner = args[0]
+			g, err := utils.NewGetter(clientConfig)
+			if err != nil {
+				return err
+			}
+
+			return runCmdReview(ctx, args[0], repos, filter, envs, &cmdFlags, g, reviewCmd.OutOrStdout())
+		},
+	}
+
+	// Configure flags for command
+	reviewCmd.Flags().BoolVar(&cmdFlags.approve, "approve", false, "Approve the selected deployments")
+	reviewCmd.Flags().BoolVar(&cmdFlags.reject, "reject", false, "Reject the selected deployments")
+	reviewCmd.Flags().StringVarP(&cmdFlags.comment, "comment", "c", "", "Comment to leave with the review (required)")
+	reviewCmd.Flags().BoolVarP(&cmdFlags.yes, "yes", "y", false, "Review the selected deployments instead of only listing them")
+	reviewCmd.Flags().IntSliceVar(&cmdFlags.runs, "run", nil, "Only review these workflow run IDs (repeatable)")
+	cmdFlags.envFilter.AddFlags(reviewCmd.Flags())
+	cmdFlags.repoFilter.AddFlags(reviewCmd.Flags())
+
+	return &reviewCmd
+}
+
+// runReview is the environments of a single run that are reviewed together.
+type runReview struct {
+	repository   string
+	runID        int
+	environments []string
+	ids          []int
+}
+
+func runCmdReview(ctx context.Context, owner string, repos []string, filter utils.RepoFilter, envs utils.EnvFilter, cmdFlags *cmdFlags, g *utils.APIGetter, out io.Writer) error {
+	approvals, err := utils.GatherOrgPendingApprovals(ctx, g, owner, repos, filter, envs)
+	if err != nil {
+		return err
+	}
+
+	p := utils.NewPrinter(out)
+	tracker := progress.FromContext(ctx)
+	var reviews []*runReview
+	byRun := make(map[int]*runReview)
+	skipped := 0
+	for _, approval := range approvals {
+		if len(cmdFlags.runs) > 0 && !slices.Contains(cmdFlags.runs, approval.RunID) {
+			continue
+		}
+		if !approval.CanApprove {
+			p.Printf("Skipped run %d in %s: you are not a reviewer for environment %s\n", approval.RunID, approval.Repository, approval.Environment)
+			tracker.Skipped(1)
+			skipped++
+			continue
+		}
+		review, ok := byRun[approval.RunID]
+		if !ok {
+			review = &runReview{repository: approval.Repository, runID: approval.RunID}
+			byRun[approval.RunID] = review
+			reviews = append(reviews, review)
+		}
+		review.environments = append(review.environments, approval.Environment)
+		review.ids = append(review.ids, approval.EnvironmentID)
+	}
+
+	action, state, verb := "approve", "approved", "Approved"
+	if cmdFlags.reject {
+		action, state, verb = "reject", "rejected", "Rejected"
+	}
+	if len(reviews) > 0 && !cmdFlags.yes {
+		if err := p.Err(); err != nil {
+			return err
+		}
+		if err := writeReviews(out, reviews); err != nil {
+			return err
+		}
+		p.Printf("\nRun again with --yes to %s these %d run(s).\n", action, len(reviews))
+		return p.Err()
+	}
+
+	failed := 0
+	for _, review := range reviews {
+		zap.S().Debugf("Reviewing run %d in repo %s", review.runID, review.repository)
+		payload, err := json.Marshal(data.ReviewPendingDeployments{EnvironmentIDs: review.ids, State: state, Comment: cmdFlags.comment})
+		if err != nil {
+			return err
+		}
+		err = g.ReviewPendingDeployments(ctx, owner, review.repository, review.runID, bytes.NewReader(payload))
+		if err != nil {
+			zap.S().Errorf("Error reviewing run %d in repo %s: %v", review.runID, review.repository, err)
+			tracker.Failed()
+			failed++
+			continue
+		}
+		tracker.Updated()
+		p.Printf("%s run %d in %s/%s for environment(s) %s\n", verb, review.runID, owner, review.repository, strings.Join(review.environments, ", "))
+	}
+
+	p.Printf("%d run(s) %s, %d deployment(s) skipped\n", len(reviews)-failed, state, skipped)
+	if failed > 0 {
+		return exitcode.Failures(failed, len(reviews), fmt.Errorf("failed to review %d run(s)", failed))
+	}
+	return p.Err()
+}
+
+// writeReviews lists the runs that would be reviewed.
+func writeReviews(out io.Writer, reviews []*runReview) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	p := utils.NewPrinter(w)
+	p.Println("\nRepository\tRun\tEnvironments")
+	for _, review := range reviews {
+		p.Printf("%s\t%d\t%s\n", review.repository, review.runID, strings.Join(review.environments, ", "))
+	}
+	if err := p.Err(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
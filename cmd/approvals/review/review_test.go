@@ -0,0 +1,120 @@
+package approvalsreview
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/utils"
+)
+
+func TestNewCmdReview(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"testorg", "--comment", "ok"}, "exactly one of --approve or --reject is required"},
+		{[]string{"testorg", "--approve", "--reject", "--comment", "ok"}, "exactly one of --approve or --reject is required"},
+		{[]string{"testorg", "--approve"}, "--comment is required"},
+	}
+	for _, tt := range tests {
+		cmd := NewCmdReview()
+		cmd.SetArgs(tt.args)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		err := cmd.Execute()
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%v: expected error %q, got %v", tt.args, tt.want, err)
+		}
+		if code := exitcode.Code(err); code != exitcode.ValidationFailure {
+			t.Errorf("%v: expected exit code %d, got %d", tt.args, exitcode.ValidationFailure, code)
+		}
+	}
+}
+
+func pending(id int, name string, canApprove bool) data.PendingDeployment {
+	p := data.PendingDeployment{
+		CurrentUserCanApprove: canApprove,
+		Reviewers:             []data.Reviewers{{Type: "User", Reviewer: data.Reviewer{ID: 1, Login: "octocat"}}},
+	}
+	p.Environment.ID = id
+	p.Environment.Name = name
+	return p
+}
+
+func TestRunCmdReview(t *testing.T) {
+	ctx := context.Background()
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "app").WaitingRuns = []*utils.MockWorkflowRun{
+		{WorkflowRun: data.WorkflowRun{ID: 1}, Pending: []data.PendingDeployment{pending(100, "production", true), pending(101, "production-eu", true), pending(102, "staging", false)}},
+		{WorkflowRun: data.WorkflowRun{ID: 2}, Pending: []data.PendingDeployment{pending(100, "production", true)}},
+	}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without --yes the selected runs are only listed
+	var out bytes.Buffer
+	flags := &cmdFlags{approve: true, comment: "Ship it"}
+	err = runCmdReview(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, flags, g, &out)
+	if err != nil {
+		t.Fatalf("runCmdReview() error = %v", err)
+	}
+	for _, want := range []string{
+		"app         1    production, production-eu",
+		"app         2    production",
+		"Run again with --yes to approve these 2 run(s).",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+	for _, run := range server.Repos["app"].WaitingRuns {
+		if len(run.Reviews) != 0 {
+			t.Fatalf("Expected no run to be reviewed without --yes, got %+v", run.Reviews)
+		}
+	}
+
+	out.Reset()
+	flags = &cmdFlags{reject: true, yes: true, comment: "Not today", runs: []int{1}}
+	err = runCmdReview(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, flags, g, &out)
+	if err != nil {
+		t.Fatalf("runCmdReview() error = %v", err)
+	}
+	for _, want := range []string{
+		"Skipped run 1 in app: you are not a reviewer for environment staging",
+		"Rejected run 1 in testorg/app for environment(s) production, production-eu",
+		"1 run(s) rejected, 1 deployment(s) skipped",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+
+	runs := server.Repos["app"].WaitingRuns
+	want := []data.ReviewPendingDeployments{{EnvironmentIDs: []int{100, 101}, State: "rejected", Comment: "Not today"}}
+	if !reflect.DeepEqual(runs[0].Reviews, want) {
+		t.Errorf("Unexpected reviews %+v", runs[0].Reviews)
+	}
+	if len(runs[1].Reviews) != 0 {
+		t.Error("Expected run 2 not to be reviewed")
+	}
+
+	out.Reset()
+	flags = &cmdFlags{approve: true, yes: true, comment: "Ship it"}
+	err = runCmdReview(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{Globs: []string{"production"}}, flags, g, &out)
+	if err != nil {
+		t.Fatalf("runCmdReview() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Approved run 2 in testorg/app for environment(s) production") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	if len(runs[1].Reviews) != 1 || runs[1].Reviews[0].State != "approved" {
+		t.Errorf("Expected run 2 to be approved, got %+v", runs[1].Reviews)
+	}
+}
@@ -0,0 +1,89 @@
+package apps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type appsGetter interface {
+	GetAvailableDeploymentApps(ctx context.Context, owner string, repo string, env string) ([]byte, error)
+	GetDeploymentProtectionRules(ctx context.Context, owner string, repo string, env string) ([]byte, error)
+}
+
+func NewCmdApps() *cobra.Command {
+	appsCmd := cobra.Command{
+		Use:   "apps [flags] <organization> <repo> <environment>",
+		Short: "List custom deployment protection apps for an environment.",
+		Long:  "List the GitHub Apps that can be enabled as custom deployment protection rules for an environment, and whether each is enabled.",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(appsCmd *cobra.Command, args []string) error {
+			ctx := appsCmd.Context()
+			var err error
+
+			clientConfig := utils.ClientConfigFromFlags(appsCmd.Flags())
+			clientConfig.Owner = args[0]
+			g, err := utils.NewGetter(clientConfig)
+			if err != nil {
+				return err
+			}
+
+			return runCmdApps(ctx, args[0], args[1], args[2], g, os.Stdout)
+		},
+	}
+
+	return &appsCmd
+}
+
+func runCmdApps(ctx context.Context, owner string, repo string, env string, g appsGetter, out io.Writer) error {
+	var available data.AvailableDeploymentApps
+	var enabled data.DeploymentProtectionPolicy
+
+	zap.S().Debugf("Gathering available deployment protection apps for %s/%s/%s", owner, repo, env)
+	appsResp, err := g.GetAvailableDeploymentApps(ctx, owner, repo, env)
+	if err != nil {
+		zap.S().Error("Error raised in gathering available apps", zap.Error(err))
+		return err
+	}
+	if err = json.Unmarshal(appsResp, &available); err != nil {
+		return err
+	}
+
+	zap.S().Debugf("Gathering enabled deployment protection rules for %s/%s/%s", owner, repo, env)
+	rulesResp, err := g.GetDeploymentProtectionRules(ctx, owner, repo, env)
+	if err != nil {
+		if !strings.Contains(err.Error(), "404: Not Found") {
+			zap.S().Error("Error raised in gathering deployment protection rules", zap.Error(err))
+			return err
+		}
+	} else if err = json.Unmarshal(rulesResp, &enabled); err != nil {
+		return err
+	}
+
+	enabledApps := make(map[int]int)
+	for _, rule := range enabled.CustomDeploymentRules {
+		enabledApps[rule.App.IntegrationID] = rule.PolicyID
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "AppID\tAppSlug\tEnabled\tPolicyID")
+	for _, app := range available.Apps {
+		policyID, ok := enabledApps[app.ID]
+		policy := ""
+		if ok {
+			policy = strconv.Itoa(policyID)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%t\t%s\n", app.ID, app.Slug, ok, policy)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,66 @@
+package apps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/utils"
+)
+
+func TestNewCmdApps(t *testing.T) {
+	cmd := NewCmdApps()
+
+	if cmd == nil {
+		t.Fatal("NewCmdApps() returned nil")
+	}
+
+	// Test basic properties
+	if cmd.Use != "apps [flags] <organization> <repo> <environment>" {
+		t.Errorf("Expected Use to be 'apps [flags] <organization> <repo> <environment>', got %s", cmd.Use)
+	}
+
+	// Test argument validation
+	if err := cmd.Args(cmd, []string{"testorg", "testrepo"}); err == nil {
+		t.Error("Expected error for missing environment argument, got nil")
+	}
+}
+
+func TestRunCmdApps(t *testing.T) {
+	ctx := context.Background()
+	mockGetter := utils.NewMockAPIGetter()
+	mockGetter.AvailableAppsData, _ = json.Marshal(data.AvailableDeploymentApps{
+		TotalCount: 2,
+		Apps: []data.AvailableDeploymentApp{
+			{ID: 5, Slug: "deploy-gate"},
+			{ID: 9, Slug: "security-gate"},
+		},
+	})
+	mockGetter.ProtectionRulesData, _ = json.Marshal(data.DeploymentProtectionPolicy{
+		TotalCount: 1,
+		CustomDeploymentRules: []data.DeploymentProtectionPolicyApp{
+			{PolicyID: 11, Enabled: true, App: data.DeploymentApp{IntegrationID: 9, Slug: "security-gate"}},
+		},
+	})
+
+	var buf bytes.Buffer
+	if err := runCmdApps(ctx, "testorg", "testrepo", "production", mockGetter, &buf); err != nil {
+		t.Fatalf("runCmdApps() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected header and 2 apps, got %d lines:\n%s", len(lines), buf.String())
+	}
+
+	if strings.Join(strings.Fields(lines[1]), " ") != "5 deploy-gate false" {
+		t.Errorf("Unexpected line for deploy-gate: %q", lines[1])
+	}
+
+	if strings.Join(strings.Fields(lines[2]), " ") != "9 security-gate true 11" {
+		t.Errorf("Unexpected line for security-gate: %q", lines[2])
+	}
+}
@@ -0,0 +1,87 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/backup"
+	"github.com/katiem0/gh-environments/internal/utils"
+	"github.com/katiem0/gh-environments/internal/version"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type cmdFlags struct {
+	hostname   string
+	outputFile string
+	repoFilter utils.RepoFilterFlags
+	envFilter  utils.EnvFilterFlags
+}
+
+func NewCmdBackup() *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	backupCmd := cobra.Command{
+		Use:   "backup [flags] <organization> [repo ...]",
+		Short: "Back up environments to an archive.",
+		Long:  "Back up the environments of an organization, including branch policies, custom deployment protection rules, variables and secret names, to an archive that restore can recreate them from.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(backupCmd *cobra.Command, args []string) error {
+			ctx := backupCmd.Context()
+			var err error
+
+			repos, err := cmdFlags.repoFilter.Repos(args[1:])
+			if err != nil {
+				return err
+			}
+			filter, err := cmdFlags.repoFilter.Filter()
+			if err != nil {
+				return err
+			}
+			envs, err := cmdFlags.envFilter.Filter()
+			if err != nil {
+				return err
+			}
+
+			clientConfig := utils.ClientConfigFromFlags(backupCmd.Flags())
+			clientConfig.Owner = args[0]
+			cmdFlags.hostname = clientConfig.Hostname
+			g, err := utils.NewGetter(clientConfig)
+			if err != nil {
+				return err
+			}
+
+			return runCmdBackup(ctx, args[0], repos, filter, envs, &cmdFlags, g, backupCmd.OutOrStdout())
+		},
+	}
+
+	outputFileDefault := fmt.Sprintf("backup-environments-%s.tar.gz", time.Now().Format("20060102150405"))
+
+	// Configure flags for command
+	backupCmd.Flags().StringVarP(&cmdFlags.outputFile, "output-file", "o", outputFileDefault, "Name of the archive file to write")
+	cmdFlags.envFilter.AddFlags(backupCmd.Flags())
+	cmdFlags.repoFilter.AddFlags(backupCmd.Flags())
+
+	return &backupCmd
+}
+
+func runCmdBackup(ctx context.Context, owner string, repos []string, filter utils.RepoFilter, envs utils.EnvFilter, cmdFlags *cmdFlags, g *utils.APIGetter, out io.Writer) error {
+	// An interrupted backup still saves the environments gathered so far
+	environments, err := utils.GatherOrgEnvironments(ctx, g, owner, repos, filter, envs, true)
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+
+	archive := backup.New(cmdFlags.hostname, owner, version.Version(), environments, time.Now())
+	zap.S().Debugf("Writing backup of %d environment(s) in %d repositories", archive.Manifest.Environments, len(archive.Repositories))
+	if err := archive.WriteFile(cmdFlags.outputFile); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return utils.PartialOutputError(ctx, cmdFlags.outputFile)
+	}
+	_, err = fmt.Fprintf(out, "Successfully backed up %d environment(s) from %d repositories to file: %s\n", archive.Manifest.Environments, len(archive.Repositories), cmdFlags.outputFile)
+	return err
+}
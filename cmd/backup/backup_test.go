@@ -0,0 +1,97 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/backup"
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/utils"
+)
+
+func TestNewCmdBackup(t *testing.T) {
+	cmd := NewCmdBackup()
+
+	if cmd.Use != "backup [flags] <organization> [repo ...]" {
+		t.Errorf("Unexpected Use %q", cmd.Use)
+	}
+	for _, flag := range []string{"output-file", "env", "visibility"} {
+		if cmd.Flag(flag) == nil {
+			t.Errorf("%s flag not found", flag)
+		}
+	}
+	if !strings.HasSuffix(cmd.Flag("output-file").DefValue, ".tar.gz") {
+		t.Errorf("Unexpected default output file %q", cmd.Flag("output-file").DefValue)
+	}
+}
+
+func TestRunCmdBackup(t *testing.T) {
+	ctx := context.Background()
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "app").Environments["production"] = &utils.MockEnvironment{
+		Name:      "production",
+		Variables: []data.Variable{{Name: "REGION", Value: "eu"}},
+		Secrets:   []data.Secret{{Name: "TOKEN"}},
+	}
+	server.AddRepo(2, "web")
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileName := filepath.Join(t.TempDir(), "backup.tar.gz")
+	var out bytes.Buffer
+	err = runCmdBackup(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, &cmdFlags{hostname: "github.com", outputFile: fileName}, g, &out)
+	if err != nil {
+		t.Fatalf("runCmdBackup() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Successfully backed up 1 environment(s) from 1 repositories") {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+
+	if entries, err := os.ReadDir(filepath.Dir(fileName)); err != nil || len(entries) != 1 {
+		t.Errorf("Expected only the archive to be written, got %v", entries)
+	}
+
+	archive, err := backup.Read(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archive.Manifest.Organization != "testorg" || archive.Manifest.ToolVersion == "" {
+		t.Errorf("Unexpected manifest %+v", archive.Manifest)
+	}
+	env := archive.Repositories[0].Environments[0]
+	if env.Name != "production" || len(env.Variables) != 1 || len(env.Secrets) != 1 {
+		t.Errorf("Unexpected environment %+v", env)
+	}
+}
+
+func TestRunCmdBackupInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "app")
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileName := filepath.Join(t.TempDir(), "backup.tar.gz")
+	var out bytes.Buffer
+	err = runCmdBackup(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, &cmdFlags{outputFile: fileName}, g, &out)
+	if code := exitcode.Code(err); code != exitcode.PartialFailure {
+		t.Fatalf("Expected a partial failure, got %v", err)
+	}
+	// The environments gathered before the interrupt are still backed up
+	if _, err := backup.Read(fileName); err != nil {
+		t.Errorf("Expected a partial archive, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected no success message, got %q", out.String())
+	}
+}
@@ -0,0 +1,20 @@
+package config
+
+import (
+	showCmd "github.com/katiem0/gh-environments/cmd/config/show"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdConfig() *cobra.Command {
+
+	cmd := &cobra.Command{
+		Use:   "config <command>",
+		Short: "Show configuration profiles.",
+		Long:  "Show the settings read from configuration profiles in ~/.config/gh-environments/config.yml and the repository's .gh-environments.yml.",
+	}
+	cmd.Flags().Bool("help", false, "Show help for command")
+
+	cmd.AddCommand(showCmd.NewCmdShow())
+
+	return cmd
+}
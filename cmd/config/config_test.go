@@ -0,0 +1,21 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestNewCmdConfig(t *testing.T) {
+	cmd := NewCmdConfig()
+
+	if cmd.Use != "config <command>" {
+		t.Errorf("Unexpected Use %q", cmd.Use)
+	}
+
+	found := make(map[string]bool)
+	for _, subcmd := range cmd.Commands() {
+		found[subcmd.Name()] = true
+	}
+	if !found["show"] {
+		t.Error("show subcommand not found")
+	}
+}
@@ -0,0 +1,119 @@
+package configshow
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/katiem0/gh-environments/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func NewCmdShow() *cobra.Command {
+	showCmd := cobra.Command{
+		Use:   "show [flags]",
+		Short: "Show the effective settings.",
+		Long:  "Show the profile in use, the configuration files it was read from and the effective value and source of each setting.",
+		Args:  cobra.NoArgs,
+		RunE: func(showCmd *cobra.Command, args []string) error {
+			profileName, _ := showCmd.Flags().GetString("profile")
+			selection, err := config.Select(profileName)
+			if err != nil {
+				return err
+			}
+			return runCmdShow(selection, showCmd.Flags(), showCmd.OutOrStdout())
+		},
+	}
+
+	return &showCmd
+}
+
+type setting struct {
+	name   string
+	value  string
+	source string
+}
+
+// runCmdShow prints the settings of selection, overridden by the flags given
+// on the command line. Tokens are never printed, only where they are read
+// from.
+func runCmdShow(selection config.Selection, flags *pflag.FlagSet, out io.Writer) error {
+	profile := selection.Profile
+
+	name := selection.Name
+	if name == "" {
+		name = "none"
+	}
+	files := strings.Join(selection.Paths, ", ")
+	if files == "" {
+		files = "none"
+	}
+	fmt.Fprintf(out, "Profile: %s\n", name)
+	fmt.Fprintf(out, "Configuration files: %s\n\n", files)
+
+	settings := []setting{
+		flagSetting(flags, "hostname", "github.com"),
+		tokenSetting(profile, flags),
+		flagSetting(flags, "concurrency", "1"),
+	}
+	addProfileSetting := func(name string, value string) {
+		if value != "" {
+			settings = append(settings, setting{name, value, "profile"})
+		}
+	}
+	addProfileSetting("org", profile.Org)
+	addProfileSetting("format", profile.Format)
+	addProfileSetting("filters.env", strings.Join(profile.Filters.Env, ", "))
+	addProfileSetting("filters.env_regex", profile.Filters.EnvRegex)
+	addProfileSetting("filters.visibility", profile.Filters.Visibility)
+	addProfileSetting("filters.topics", strings.Join(profile.Filters.Topics, ", "))
+	addProfileSetting("filters.name_regex", profile.Filters.NameRegex)
+	if profile.Filters.ExcludeArchived {
+		addProfileSetting("filters.exclude_archived", strconv.FormatBool(true))
+	}
+	if profile.Filters.ExcludeForks {
+		addProfileSetting("filters.exclude_forks", strconv.FormatBool(true))
+	}
+	addProfileSetting("filters.properties", strings.Join(profile.Filters.Properties, ", "))
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Setting\tValue\tSource")
+	for _, s := range settings {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.name, s.value, s.source)
+	}
+	return w.Flush()
+}
+
+// flagSetting reads a global flag, which the root command has already set
+// from the profile unless it was given on the command line.
+func flagSetting(flags *pflag.FlagSet, name string, fallback string) setting {
+	flag := flags.Lookup(name)
+	if flag == nil {
+		return setting{name, fallback, "default"}
+	}
+	source := "default"
+	switch {
+	case config.FromProfile(flag):
+		source = "profile"
+	case flag.Changed:
+		source = "flag"
+	}
+	return setting{name, flag.Value.String(), source}
+}
+
+func tokenSetting(profile config.Profile, flags *pflag.FlagSet) setting {
+	if config.Explicit(flags, "token") {
+		return setting{"token", "--token", "flag"}
+	}
+	if flags.Changed("app-id") {
+		appID, _ := flags.GetInt64("app-id")
+		return setting{"token", fmt.Sprintf("GitHub App %d", appID), "flag"}
+	}
+	if profile.Token != "" {
+		return setting{"token", profile.Token, "profile"}
+	}
+	return setting{"token", "gh auth token", "default"}
+}
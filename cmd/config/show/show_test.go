@@ -0,0 +1,89 @@
+package configshow
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/config"
+	"github.com/spf13/pflag"
+)
+
+func newFlags(t *testing.T, args ...string) *pflag.FlagSet {
+	t.Helper()
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("hostname", "github.com", "")
+	flags.String("token", "", "")
+	flags.Int("concurrency", 1, "")
+	flags.Int64("app-id", 0, "")
+	if err := flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return flags
+}
+
+func TestNewCmdShow(t *testing.T) {
+	cmd := NewCmdShow()
+
+	if cmd.Use != "show [flags]" {
+		t.Errorf("Unexpected Use %q", cmd.Use)
+	}
+	if err := cmd.Args(cmd, []string{"extra"}); err == nil {
+		t.Error("Expected error for arguments")
+	}
+}
+
+func TestRunCmdShow(t *testing.T) {
+	selection := config.Selection{
+		Name:  "work",
+		Paths: []string{"/home/me/.config/gh-environments/config.yml"},
+		Profile: config.Profile{
+			Hostname:    "github.example.com",
+			Org:         "acme",
+			Token:       "env:GHE_TOKEN",
+			Concurrency: 4,
+			Filters:     config.Filters{Env: []string{"prod*"}, ExcludeForks: true},
+		},
+	}
+	// The token variable is only read once a client is built
+	flags := newFlags(t, "--concurrency", "8")
+	if err := selection.Profile.Apply(flags); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runCmdShow(selection, flags, &out); err != nil {
+		t.Fatal(err)
+	}
+	output := out.String()
+	for _, want := range []string{
+		"Profile: work",
+		"Configuration files: /home/me/.config/gh-environments/config.yml",
+		"hostname               github.example.com  profile",
+		"token                  env:GHE_TOKEN       profile",
+		"concurrency            8                   flag",
+		"org                    acme                profile",
+		"filters.env            prod*               profile",
+		"filters.exclude_forks  true                profile",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output:\n%s", want, output)
+		}
+	}
+}
+
+func TestRunCmdShowWithoutProfile(t *testing.T) {
+	var out bytes.Buffer
+	if err := runCmdShow(config.Selection{}, newFlags(t, "--token", "abc"), &out); err != nil {
+		t.Fatal(err)
+	}
+	output := out.String()
+	for _, want := range []string{"Profile: none", "Configuration files: none", "github.com", "--token"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "abc") {
+		t.Errorf("Expected the token not to be printed:\n%s", output)
+	}
+}
@@ -2,31 +2,29 @@ package create
 
 import (
 	"bytes"
-	"encoding/csv"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 
-	"github.com/cli/go-gh/v2/pkg/api"
-	"github.com/cli/go-gh/v2/pkg/auth"
 	"github.com/katiem0/gh-environments/internal/data"
-	"github.com/katiem0/gh-environments/internal/log"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/progress"
 	"github.com/katiem0/gh-environments/internal/utils"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
 type cmdFlags struct {
-	fileName string
-	token    string
-	hostname string
-	debug    bool
+	fileName      string
+	pruneBranches bool
+	pruneRules    bool
+	envFilter     utils.EnvFilterFlags
 }
 
 func NewCmdCreate() *cobra.Command {
 	//var repository string
 	cmdFlags := cmdFlags{}
-	var authToken string
 
 	createCmd := cobra.Command{
 		Use:   "create  <target organization> [flags]",
@@ -34,60 +32,28 @@ func NewCmdCreate() *cobra.Command {
 		Long:  "Create environments and metadata for specified environments per repository in an organization from a file.",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(createCmd *cobra.Command, args []string) error {
+			ctx := createCmd.Context()
 			var err error
-			var gqlClient *api.GraphQLClient
-			var restClient *api.RESTClient
-			if cmdFlags.token != "" {
-				authToken = cmdFlags.token
-			} else {
-				t, _ := auth.TokenForHost(cmdFlags.hostname)
-				authToken = t
-			}
-
-			if cmdFlags.debug {
-				logger, _ := log.NewLogger(cmdFlags.debug)
-				defer logger.Sync() // nolint:errcheck
-				zap.ReplaceGlobals(logger)
-			}
-
-			gqlClient, err = api.NewGraphQLClient(api.ClientOptions{
-				Headers: map[string]string{
-					"Accept": "application/vnd.github.hawkgirl-preview+json",
-				},
-				Host:      cmdFlags.hostname,
-				AuthToken: authToken,
-			})
-
-			if err != nil {
-				zap.S().Errorf("Error arose retrieving graphql client")
-				return err
-			}
-
-			restClient, err = api.NewRESTClient(api.ClientOptions{
-				Headers: map[string]string{
-					"Accept": "application/vnd.github+json",
-				},
-				Host:      cmdFlags.hostname,
-				AuthToken: authToken,
-			})
 
+			clientConfig := utils.ClientConfigFromFlags(createCmd.Flags())
+			clientConfig.Owner = args[0]
+			g, err := utils.NewGetter(clientConfig)
 			if err != nil {
-				zap.S().Errorf("Error arose retrieving rest client")
 				return err
 			}
 
 			owner := args[0]
 
-			return runCmdCreate(owner, &cmdFlags, utils.NewAPIGetter(gqlClient, restClient))
+			return runCmdCreate(ctx, owner, &cmdFlags, g)
 		},
 	}
 
 	// Configure flags for command
 
-	createCmd.PersistentFlags().StringVarP(&cmdFlags.token, "token", "t", "", `GitHub personal access token for organization to write to (default "gh auth token")`)
-	createCmd.PersistentFlags().StringVarP(&cmdFlags.hostname, "hostname", "", "github.com", "GitHub Enterprise Server hostname")
 	createCmd.Flags().StringVarP(&cmdFlags.fileName, "from-file", "f", "", "Path and Name of CSV file to create environments from")
-	createCmd.PersistentFlags().BoolVarP(&cmdFlags.debug, "debug", "d", false, "To debug logging")
+	createCmd.Flags().BoolVar(&cmdFlags.pruneBranches, "prune-branch-policies", false, "Delete existing deployment branch policies that are not listed in the file")
+	createCmd.Flags().BoolVar(&cmdFlags.pruneRules, "prune-protection-rules", false, "Disable existing custom deployment protection rules that are not listed in the file")
+	cmdFlags.envFilter.AddFlags(createCmd.Flags())
 	if err := createCmd.MarkFlagRequired("from-file"); err != nil {
 		zap.S().Errorf("Error marking flag 'from-file' as required: %v", err)
 		return nil
@@ -96,36 +62,55 @@ func NewCmdCreate() *cobra.Command {
 	return &createCmd
 }
 
-func runCmdCreate(owner string, cmdFlags *cmdFlags, g *utils.APIGetter) error {
-	var environmentData [][]string
+func runCmdCreate(ctx context.Context, owner string, cmdFlags *cmdFlags, g *utils.APIGetter) error {
+	envs, err := cmdFlags.envFilter.Filter()
+	if err != nil {
+		return err
+	}
+
 	var environmentList []data.ImportedEnvironment
+	tracker := progress.FromContext(ctx)
+	failed := 0
 
 	if len(cmdFlags.fileName) > 0 {
-		f, err := os.Open(cmdFlags.fileName)
-		zap.S().Debugf("Opening up file %s", cmdFlags.fileName)
+		zap.S().Debugf("Reading in all lines from csv file %s", cmdFlags.fileName)
+		environmentData, err := utils.ReadCSVFile(cmdFlags.fileName)
 		if err != nil {
-			zap.S().Errorf("Error arose opening environments csv file")
+			zap.S().Errorf("Error arose reading environments from csv file")
+			return exitcode.New(exitcode.ValidationFailure, err)
 		}
-		defer func() {
-			if closeErr := f.Close(); closeErr != nil {
-				zap.S().Warnf("Error closing file: %v", closeErr)
-			}
-		}()
 
-		// read csv values using csv.Reader
-		csvReader := csv.NewReader(f)
-		environmentData, err = csvReader.ReadAll()
-		zap.S().Debugf("Reading in all lines from csv file")
-		if err != nil {
-			zap.S().Errorf("Error arose reading environments from csv file")
+		zap.S().Debugf("Validating environments file before creating environments")
+		validationErrors := utils.ValidateEnvironmentList(environmentData)
+		for _, validationError := range validationErrors {
+			fmt.Fprintln(os.Stderr, validationError.Error())
+		}
+		if len(validationErrors) > 0 {
+			return exitcode.New(exitcode.ValidationFailure, fmt.Errorf("%s failed validation with %d error(s)", cmdFlags.fileName, len(validationErrors)))
 		}
 
-		environmentList = g.CreateEnvironmentList(environmentData)
+		environmentList, err = g.CreateEnvironmentList(environmentData)
+		if err != nil {
+			zap.S().Errorf("Error arose parsing environments from csv file")
+			return exitcode.New(exitcode.ValidationFailure, fmt.Errorf("%s: %w", cmdFlags.fileName, err))
+		}
+		selected := utils.SelectEnvironments(envs, environmentList, func(environment data.ImportedEnvironment) (string, string) {
+			return environment.RepositoryName, environment.EnvironmentName
+		})
+		tracker.Skipped(len(environmentList) - len(selected))
+		environmentList = selected
 		zap.S().Debugf("Identifying Environments list to create under %s", owner)
 		zap.S().Debugf("Determining environments to create")
 
+		index := utils.NewEnvironmentIndex(g, owner)
 		for _, environment := range environmentList {
-			fmt.Printf("Gathering environment %s for repo %s", environment.EnvironmentName, environment.RepositoryName)
+			// Environments left once the run is interrupted are not created
+			if ctx.Err() != nil {
+				break
+			}
+			fmt.Fprintf(os.Stderr, "Gathering environment %s for repo %s\n", environment.EnvironmentName, environment.RepositoryName)
+			exists := index.Exists(ctx, environment.RepositoryName, environment.EnvironmentName)
+			succeeded := true
 			importEnv := utils.CreateEnvironmentData(environment)
 			createEnvironment, err := json.Marshal(importEnv)
 			if err != nil {
@@ -133,29 +118,49 @@ func runCmdCreate(owner string, cmdFlags *cmdFlags, g *utils.APIGetter) error {
 			}
 			reader := bytes.NewReader(createEnvironment)
 			zap.S().Debugf("Creating Environment %s for %s/%s", environment.EnvironmentName, owner, environment.RepositoryName)
-			err = g.CreateEnvironment(owner, environment.RepositoryName, environment.EnvironmentName, reader)
+			err = g.CreateEnvironment(ctx, owner, environment.RepositoryName, environment.EnvironmentName, reader)
 			if err != nil {
 				zap.S().Errorf("Error arose creating environment %s", environment.EnvironmentName)
+				succeeded = false
 			}
 			if environment.DeploymentPolicy == "custom" {
-				zap.S().Debugf("Creating Branch/Tag Deployment Policy for %s/%s/%s", owner, environment.RepositoryName, environment.EnvironmentName)
-				for _, branch := range environment.Branches {
-					createEnvironmentBranch, err := json.Marshal(branch)
-					if err != nil {
-						return err
-					}
-					readerBranch := bytes.NewReader(createEnvironmentBranch)
-					err = g.CreateDeploymentBranches(owner, environment.RepositoryName, environment.EnvironmentName, readerBranch)
-					if err != nil {
-						zap.S().Errorf("Error arose creating deployment policy for %s", environment.EnvironmentName)
-					}
+				zap.S().Debugf("Syncing Branch/Tag Deployment Policy for %s/%s/%s", owner, environment.RepositoryName, environment.EnvironmentName)
+				summary, err := utils.SyncDeploymentBranches(ctx, owner, environment, cmdFlags.pruneBranches, g)
+				if err != nil {
+					zap.S().Errorf("Error arose syncing deployment policy for %s: %v", environment.EnvironmentName, err)
+					succeeded = false
+				}
+				fmt.Fprintln(os.Stderr, summary)
+			}
+			if len(environment.ProtectionRules) > 0 || cmdFlags.pruneRules {
+				zap.S().Debugf("Syncing Custom Deployment Protection Rules for %s/%s/%s", owner, environment.RepositoryName, environment.EnvironmentName)
+				summary, err := utils.SyncProtectionRules(ctx, owner, environment, cmdFlags.pruneRules, g)
+				if err != nil {
+					zap.S().Errorf("Error arose syncing deployment protection rules for %s: %v", environment.EnvironmentName, err)
+					succeeded = false
 				}
+				fmt.Fprintln(os.Stderr, summary)
+			}
+			switch {
+			case !succeeded:
+				tracker.Failed()
+				failed++
+			case exists:
+				tracker.Updated()
+			default:
+				tracker.Created()
 			}
 		}
 		// Gathering Envs for each repository listed
 	} else {
 		zap.S().Errorf("Error arose identifying environments")
 	}
-	fmt.Printf("Successfully created environments from file: %s.", cmdFlags.fileName)
+	if ctx.Err() != nil {
+		return utils.InterruptedError(ctx)
+	}
+	if failed > 0 {
+		return exitcode.Failures(failed, len(environmentList), fmt.Errorf("failed to create %d environment(s)", failed))
+	}
+	fmt.Fprintf(os.Stderr, "Successfully created environments from file: %s\n", cmdFlags.fileName)
 	return nil
 }
@@ -1,11 +1,20 @@
 package create
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"io"
+	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/progress"
 	"github.com/katiem0/gh-environments/internal/utils"
 )
 
@@ -26,14 +35,6 @@ func TestNewCmdCreate(t *testing.T) {
 		t.Error("from-file flag not found")
 	}
 
-	if cmd.Flag("hostname") == nil {
-		t.Error("hostname flag not found")
-	}
-
-	if cmd.Flag("debug") == nil {
-		t.Error("debug flag not found")
-	}
-
 	// Test short description
 	if cmd.Short == "" {
 		t.Error("Command should have a short description")
@@ -97,7 +98,7 @@ type MockAPIGetter struct {
 }
 
 // Implement the required methods from the APIGetter interface
-func (m *MockAPIGetter) CreateEnvironmentList(filedata [][]string) []data.ImportedEnvironment {
+func (m *MockAPIGetter) CreateEnvironmentList(filedata [][]string) ([]data.ImportedEnvironment, error) {
 	// Basic implementation that creates environment data from CSV rows
 	result := []data.ImportedEnvironment{}
 
@@ -127,15 +128,249 @@ func (m *MockAPIGetter) CreateEnvironmentList(filedata [][]string) []data.Import
 		result = append(result, env)
 	}
 
-	return result
+	return result, nil
 }
 
-func (m *MockAPIGetter) CreateEnvironment(owner string, repo string, env string, data io.Reader) error {
+func (m *MockAPIGetter) CreateEnvironment(ctx context.Context, owner string, repo string, env string, data io.Reader) error {
 	// This is a mock implementation that does nothing
 	return nil
 }
 
-func (m *MockAPIGetter) CreateDeploymentBranches(owner string, repo string, env string, data io.Reader) error {
+func (m *MockAPIGetter) CreateDeploymentBranches(ctx context.Context, owner string, repo string, env string, data io.Reader) error {
 	// This is a mock implementation that does nothing
 	return nil
 }
+
+func TestSyncDeploymentBranches(t *testing.T) {
+	ctx := context.Background()
+	environment := data.ImportedEnvironment{
+		RepositoryName:   "testrepo",
+		EnvironmentName:  "production",
+		DeploymentPolicy: "custom",
+		Branches: []data.CreateDeploymentBranch{
+			{Name: "main", Type: "branch"},
+			{Name: "release/*", Type: "branch"},
+		},
+	}
+	existing := data.BranchPolicies{
+		TotalCount: 2,
+		BranchPolicies: []data.BranchPolicy{
+			{ID: 1, Name: "main", Type: "branch"},
+			{ID: 2, Name: "old/*", Type: "branch"},
+		},
+	}
+
+	mockGetter := utils.NewMockAPIGetter()
+	mockGetter.BranchPoliciesData, _ = json.Marshal(existing)
+
+	summary, err := utils.SyncDeploymentBranches(ctx, "testorg", environment, false, mockGetter)
+	if err != nil {
+		t.Fatalf("SyncDeploymentBranches() error = %v", err)
+	}
+
+	if len(mockGetter.CreatedBranchPolicies) != 1 || mockGetter.CreatedBranchPolicies[0].Name != "release/*" {
+		t.Errorf("Expected only release/* to be created, got %v", mockGetter.CreatedBranchPolicies)
+	}
+
+	if len(mockGetter.DeletedBranchPolicies) != 0 {
+		t.Errorf("Expected no policies to be deleted without prune, got %v", mockGetter.DeletedBranchPolicies)
+	}
+
+	expected := "Branch policies for testorg/testrepo/production: 1 added, 0 removed, 1 unchanged, 1 not in file"
+	if !strings.HasPrefix(summary, expected) {
+		t.Errorf("Expected summary to start with %q, got %q", expected, summary)
+	}
+
+	mockGetter = utils.NewMockAPIGetter()
+	mockGetter.BranchPoliciesData, _ = json.Marshal(existing)
+
+	summary, err = utils.SyncDeploymentBranches(ctx, "testorg", environment, true, mockGetter)
+	if err != nil {
+		t.Fatalf("SyncDeploymentBranches() error = %v", err)
+	}
+
+	if len(mockGetter.DeletedBranchPolicies) != 1 || mockGetter.DeletedBranchPolicies[0] != 2 {
+		t.Errorf("Expected old/* to be deleted, got %v", mockGetter.DeletedBranchPolicies)
+	}
+
+	expected = "Branch policies for testorg/testrepo/production: 1 added, 1 removed, 1 unchanged"
+	if summary != expected {
+		t.Errorf("Expected summary %q, got %q", expected, summary)
+	}
+}
+
+func TestSyncDeploymentBranchesFailure(t *testing.T) {
+	environment := data.ImportedEnvironment{
+		RepositoryName:   "testrepo",
+		EnvironmentName:  "production",
+		DeploymentPolicy: "custom",
+		Branches:         []data.CreateDeploymentBranch{{Name: "main", Type: "branch"}},
+	}
+	mockGetter := utils.NewMockAPIGetter()
+	mockGetter.BranchPoliciesData, _ = json.Marshal(data.BranchPolicies{})
+	mockGetter.ShouldFailCreateBranch = true
+
+	summary, err := utils.SyncDeploymentBranches(context.Background(), "testorg", environment, false, mockGetter)
+	if err == nil {
+		t.Error("Expected an error when a branch policy cannot be added")
+	}
+	if !strings.HasSuffix(summary, "0 added, 0 removed, 0 unchanged, 1 failed") {
+		t.Errorf("Expected the failure in the summary, got %q", summary)
+	}
+}
+
+func TestSyncDeploymentBranchesRejected(t *testing.T) {
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "testrepo").Environments["production"] = &utils.MockEnvironment{
+		Name:             "production",
+		DeploymentPolicy: &data.DeploymentPolicy{CustomPolicies: true},
+	}
+	// The API rejects the first branch policy, and the second is still added
+	rejected := false
+	server.Reject = func(req *http.Request) int {
+		if req.Method == "POST" && strings.HasSuffix(req.URL.Path, "deployment-branch-policies") && !rejected {
+			rejected = true
+			return http.StatusUnprocessableEntity
+		}
+		return 0
+	}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	environment := data.ImportedEnvironment{
+		RepositoryName:   "testrepo",
+		EnvironmentName:  "production",
+		DeploymentPolicy: "custom",
+		Branches:         []data.CreateDeploymentBranch{{Name: "release/[", Type: "branch"}, {Name: "main", Type: "branch"}},
+	}
+
+	summary, err := utils.SyncDeploymentBranches(context.Background(), "testorg", environment, false, g)
+	if err == nil {
+		t.Error("Expected an error when a branch policy is rejected")
+	}
+	if !strings.HasSuffix(summary, "1 added, 0 removed, 0 unchanged, 1 failed") {
+		t.Errorf("Expected the failure in the summary, got %q", summary)
+	}
+	policies := server.Repos["testrepo"].Environments["production"].BranchPolicies
+	if len(policies) != 1 || policies[0].Name != "main" {
+		t.Errorf("Expected only main to be added, got %+v", policies)
+	}
+}
+
+func TestSyncProtectionRules(t *testing.T) {
+	ctx := context.Background()
+	environment := data.ImportedEnvironment{
+		RepositoryName:  "testrepo",
+		EnvironmentName: "production",
+		ProtectionRules: []data.DeploymentProtectionPolicyApp{
+			{Enabled: true, App: data.DeploymentApp{IntegrationID: 1, Slug: "deploy-gate"}},
+			{Enabled: true, App: data.DeploymentApp{IntegrationID: 2, Slug: "missing-gate"}},
+		},
+	}
+	existing := data.DeploymentProtectionPolicy{
+		TotalCount: 1,
+		CustomDeploymentRules: []data.DeploymentProtectionPolicyApp{
+			{PolicyID: 11, Enabled: true, App: data.DeploymentApp{IntegrationID: 9, Slug: "old-gate"}},
+		},
+	}
+	available := data.AvailableDeploymentApps{
+		TotalCount: 2,
+		Apps: []data.AvailableDeploymentApp{
+			{ID: 5, Slug: "deploy-gate"},
+			{ID: 9, Slug: "old-gate"},
+		},
+	}
+
+	mockGetter := utils.NewMockAPIGetter()
+	mockGetter.ProtectionRulesData, _ = json.Marshal(existing)
+	mockGetter.AvailableAppsData, _ = json.Marshal(available)
+
+	summary, err := utils.SyncProtectionRules(ctx, "testorg", environment, true, mockGetter)
+	if err != nil {
+		t.Fatalf("utils.SyncProtectionRules() error = %v", err)
+	}
+
+	if len(mockGetter.CreatedProtectionRules) != 1 || mockGetter.CreatedProtectionRules[0].IntegrationID != 5 {
+		t.Errorf("Expected deploy-gate to be enabled with ID 5, got %v", mockGetter.CreatedProtectionRules)
+	}
+
+	if len(mockGetter.DeletedProtectionRules) != 1 || mockGetter.DeletedProtectionRules[0] != 11 {
+		t.Errorf("Expected old-gate rule 11 to be disabled, got %v", mockGetter.DeletedProtectionRules)
+	}
+
+	expected := "Protection rules for testorg/testrepo/production: 1 added, 1 removed, 0 unchanged, 1 unavailable (missing-gate)"
+	if summary != expected {
+		t.Errorf("Expected summary %q, got %q", expected, summary)
+	}
+
+	mockGetter = utils.NewMockAPIGetter()
+	mockGetter.ProtectionRulesData, _ = json.Marshal(existing)
+	mockGetter.AvailableAppsData, _ = json.Marshal(available)
+	mockGetter.ShouldFailCreateRule = true
+	if _, err := utils.SyncProtectionRules(ctx, "testorg", environment, false, mockGetter); err == nil {
+		t.Error("Expected an error when a protection rule cannot be enabled")
+	}
+}
+
+func TestRunCmdCreateFailure(t *testing.T) {
+	ctx := context.Background()
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "app")
+	// The API rejects the production environment only
+	server.Reject = func(req *http.Request) int {
+		if req.Method == "PUT" && strings.HasSuffix(req.URL.Path, "repos/testorg/app/environments/production") {
+			return http.StatusUnprocessableEntity
+		}
+		return 0
+	}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileName := filepath.Join(t.TempDir(), "environments.csv")
+	if err := os.WriteFile(fileName, []byte("RepositoryName,EnvironmentName\napp,production\napp,staging\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tracker := progress.NewTracker()
+	err = runCmdCreate(progress.WithTracker(ctx, tracker), "testorg", &cmdFlags{fileName: fileName}, g)
+	if code := exitcode.Code(err); code != exitcode.PartialFailure {
+		t.Fatalf("Expected a partial failure, got %v", err)
+	}
+	if _, ok := server.Repos["app"].Environments["staging"]; !ok {
+		t.Error("Expected staging to be created after production failed")
+	}
+	if summary := tracker.Summary(err); summary.Created != 1 || summary.Failed != 1 {
+		t.Errorf("Expected 1 environment created and 1 failed, got %+v", summary)
+	}
+}
+
+func TestRunCmdCreateInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "app")
+	// The run is interrupted while production is created
+	server.BeforeRequest = func(req *http.Request) {
+		if req.Method == "PUT" && strings.HasSuffix(req.URL.Path, "repos/testorg/app/environments/production") {
+			cancel()
+		}
+	}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileName := filepath.Join(t.TempDir(), "environments.csv")
+	if err := os.WriteFile(fileName, []byte("RepositoryName,EnvironmentName\napp,production\napp,staging\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	err = runCmdCreate(ctx, "testorg", &cmdFlags{fileName: fileName}, g)
+	if code := exitcode.Code(err); code != exitcode.PartialFailure || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected a partial failure for the interrupt, got %v", err)
+	}
+	if len(server.Repos["app"].Environments) != 0 {
+		t.Errorf("Expected no environment to be created after the interrupt, got %v", server.Repos["app"].Environments)
+	}
+}
@@ -0,0 +1,149 @@
+package deployments
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"github.com/katiem0/gh-environments/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type cmdFlags struct {
+	reportFile string
+	limit      int
+	repoFilter utils.RepoFilterFlags
+	envFilter  utils.EnvFilterFlags
+}
+
+func NewCmdDeployments() *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	deploymentsCmd := cobra.Command{
+		Use:   "deployments [flags] <organization> [repo ...]",
+		Short: "Generate a report of deployment history per environment.",
+		Long:  "Generate a report of the deployments to each environment, including the last successful deployment, who made it, its ref and SHA, and how many deployments failed, to find environments that are configured but never used.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(deploymentsCmd *cobra.Command, args []string) error {
+			ctx := deploymentsCmd.Context()
+			var err error
+
+			if cmdFlags.limit < 1 {
+				return exitcode.New(exitcode.ValidationFailure, errors.New("--limit must be at least 1"))
+			}
+			repos, err := cmdFlags.repoFilter.Repos(args[1:])
+			if err != nil {
+				return err
+			}
+			filter, err := cmdFlags.repoFilter.Filter()
+			if err != nil {
+				return err
+			}
+			envs, err := cmdFlags.envFilter.Filter()
+			if err != nil {
+				return err
+			}
+
+			clientConfig := utils.ClientConfigFromFlags(deploymentsCmd.Flags())
+			clientConfig.Owner = args[0]
+			g, err := utils.NewGetter(clientConfig)
+			if err != nil {
+				return err
+			}
+
+			reportWriter, err := os.Create(cmdFlags.reportFile)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if closeErr := reportWriter.Close(); closeErr != nil {
+					zap.S().Warnf("Error closing file: %v", closeErr)
+				}
+			}()
+
+			return runCmdDeployments(ctx, args[0], repos, filter, envs, &cmdFlags, g, reportWriter, deploymentsCmd.OutOrStdout())
+		},
+	}
+
+	// Determine default report file based on current timestamp; for more info see https://pkg.go.dev/time#pkg-constants
+	reportFileDefault := fmt.Sprintf("report-deployments-%s.csv", time.Now().Format("20060102150405"))
+
+	// Configure flags for command
+	deploymentsCmd.Flags().StringVarP(&cmdFlags.reportFile, "output-file", "o", reportFileDefault, "Name of file to write CSV report")
+	deploymentsCmd.Flags().IntVarP(&cmdFlags.limit, "limit", "l", 100, "Maximum number of recent deployments to inspect per environment")
+	cmdFlags.envFilter.AddFlags(deploymentsCmd.Flags())
+	cmdFlags.repoFilter.AddFlags(deploymentsCmd.Flags())
+
+	return &deploymentsCmd
+}
+
+func runCmdDeployments(ctx context.Context, owner string, repos []string, filter utils.RepoFilter, envs utils.EnvFilter, cmdFlags *cmdFlags, g *utils.APIGetter, reportWriter io.Writer, out io.Writer) error {
+	csvWriter := csv.NewWriter(reportWriter)
+	if err := csvWriter.Write(utils.DeploymentReportColumns); err != nil {
+		return err
+	}
+
+	allRepos, err := utils.GatherRepos(ctx, g, owner, repos, filter)
+	if err != nil {
+		if ctx.Err() != nil {
+			return utils.WritePartialReport(ctx, csvWriter, cmdFlags.reportFile)
+		}
+		zap.S().Error("Error raised in gathering repos", zap.Error(err))
+		return err
+	}
+	allRepos, _, err = utils.SelectReposByProperties(ctx, g, owner, allRepos, filter.Properties)
+	if err != nil {
+		if ctx.Err() != nil {
+			return utils.WritePartialReport(ctx, csvWriter, cmdFlags.reportFile)
+		}
+		zap.S().Error("Error raised in gathering repository custom properties", zap.Error(err))
+		return err
+	}
+
+	var total, unused int
+	tracker := progress.FromContext(ctx)
+	for _, repo := range allRepos {
+		names, err := utils.GatherEnvironmentNames(ctx, g, owner, repo.Name, envs)
+		if err != nil {
+			if ctx.Err() != nil {
+				return utils.WritePartialReport(ctx, csvWriter, cmdFlags.reportFile)
+			}
+			zap.S().Error("Error raised in gathering environments", zap.Error(err))
+			return err
+		}
+		for _, name := range names {
+			summary, err := utils.GatherDeploymentSummary(ctx, g, owner, repo.Name, name, cmdFlags.limit)
+			if err != nil {
+				if ctx.Err() != nil {
+					return utils.WritePartialReport(ctx, csvWriter, cmdFlags.reportFile)
+				}
+				// The other environments are still reported
+				tracker.Warn("could not read the deployments of %s/%s: %v", repo.Name, name, err)
+				continue
+			}
+			total++
+			if summary.Deployments == 0 {
+				unused++
+			}
+			if err := csvWriter.Write(summary.Row()); err != nil {
+				return err
+			}
+		}
+		tracker.RepositoryProcessed()
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Successfully exported deployment data to csv file: %s\n", cmdFlags.reportFile)
+	fmt.Fprintf(out, "%d of %d environment(s) have never been deployed to\n", unused, total)
+	return nil
+}
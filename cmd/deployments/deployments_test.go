@@ -0,0 +1,116 @@
+package deployments
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"github.com/katiem0/gh-environments/internal/utils"
+)
+
+func TestNewCmdDeployments(t *testing.T) {
+	cmd := NewCmdDeployments()
+
+	if cmd.Use != "deployments [flags] <organization> [repo ...]" {
+		t.Errorf("Unexpected Use %q", cmd.Use)
+	}
+	for _, flag := range []string{"output-file", "limit", "env", "visibility"} {
+		if cmd.Flag(flag) == nil {
+			t.Errorf("%s flag not found", flag)
+		}
+	}
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("Expected error when no organization is given")
+	}
+
+	cmd.SetArgs([]string{"testorg", "--limit", "0"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--limit") {
+		t.Errorf("Expected --limit error, got %v", err)
+	}
+}
+
+func TestRunCmdDeployments(t *testing.T) {
+	ctx := context.Background()
+	deployedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	server := utils.NewMockGitHubServer()
+	app := server.AddRepo(1, "app")
+	app.Environments["production"] = &utils.MockEnvironment{Name: "production"}
+	app.Environments["staging"] = &utils.MockEnvironment{Name: "staging"}
+	app.Deployments = []utils.MockDeployment{{
+		Deployment: data.Deployment{ID: 1, SHA: "abc123", Ref: "main", Environment: "production", Creator: data.User{Login: "octocat"}, CreatedAt: deployedAt},
+		Statuses:   []data.DeploymentStatus{{ID: 1, State: "success", CreatedAt: deployedAt.Add(time.Minute)}},
+	}}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report, out bytes.Buffer
+	err = runCmdDeployments(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, &cmdFlags{reportFile: "report.csv", limit: 100}, g, &report, &out)
+	if err != nil {
+		t.Fatalf("runCmdDeployments() error = %v", err)
+	}
+
+	records, err := csv.NewReader(&report).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected header and 2 rows, got %v", records)
+	}
+	production := strings.Join(records[1], ",")
+	if production != "app,production,1,success,2024-06-01T12:00:00Z,2024-06-01T12:01:00Z,octocat,main,abc123,0" {
+		t.Errorf("Unexpected production row %q", production)
+	}
+	if staging := strings.Join(records[2], ","); staging != "app,staging,0,,,,,,,0" {
+		t.Errorf("Unexpected staging row %q", staging)
+	}
+	if !strings.Contains(out.String(), "1 of 2 environment(s) have never been deployed to") {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+}
+
+func TestRunCmdDeploymentsFailure(t *testing.T) {
+	server := utils.NewMockGitHubServer()
+	app := server.AddRepo(1, "app")
+	app.Environments["production"] = &utils.MockEnvironment{Name: "production"}
+	app.Environments["staging"] = &utils.MockEnvironment{Name: "staging"}
+	// The deployments of staging cannot be read
+	server.Reject = func(req *http.Request) int {
+		if strings.HasSuffix(req.URL.Path, "/deployments") && req.URL.Query().Get("environment") == "staging" {
+			return http.StatusInternalServerError
+		}
+		return 0
+	}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker := progress.NewTracker()
+	ctx := progress.WithTracker(context.Background(), tracker)
+
+	var report, out bytes.Buffer
+	err = runCmdDeployments(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, &cmdFlags{reportFile: "report.csv", limit: 100}, g, &report, &out)
+	if err != nil {
+		t.Fatalf("runCmdDeployments() error = %v", err)
+	}
+	records, err := csv.NewReader(&report).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1][1] != "production" {
+		t.Errorf("Expected only production to be reported, got %v", records)
+	}
+	warnings := tracker.Summary(nil).Warnings
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "app/staging") {
+		t.Errorf("Expected a warning for staging, got %v", warnings)
+	}
+}
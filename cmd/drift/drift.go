@@ -0,0 +1,135 @@
+package drift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/config"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/snapshot"
+	"github.com/katiem0/gh-environments/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type cmdFlags struct {
+	hostname   string
+	since      string
+	format     string
+	outputFile string
+	exitCode   bool
+	envFilter  utils.EnvFilterFlags
+}
+
+func NewCmdDrift() *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	driftCmd := cobra.Command{
+		Use:   "drift [flags] --since <snapshot.json> [repo ...]",
+		Short: "Report changes to environments since a snapshot.",
+		Long:  "Compare the live environments of the organization in a snapshot against it and report added, removed and changed environments, reviewers, branch policies, variables and secrets.",
+		Args:  cobra.ArbitraryArgs,
+		// Drift is reported as an error with --exit-code, which should not print usage
+		SilenceUsage: true,
+		RunE: func(driftCmd *cobra.Command, args []string) error {
+			ctx := driftCmd.Context()
+			var err error
+
+			if cmdFlags.format != "table" && cmdFlags.format != "json" {
+				return exitcode.New(exitcode.ValidationFailure, fmt.Errorf("invalid format %q, expected table or json", cmdFlags.format))
+			}
+			previous, err := snapshot.Read(cmdFlags.since)
+			if err != nil {
+				return err
+			}
+			envs, err := cmdFlags.envFilter.Filter()
+			if err != nil {
+				return err
+			}
+			// Compare against the host the snapshot was taken from
+			clientConfig := utils.ClientConfigFromFlags(driftCmd.Flags())
+			if !config.Explicit(driftCmd.Flags(), "hostname") && previous.Host != "" {
+				clientConfig.Hostname = previous.Host
+			}
+			cmdFlags.hostname = clientConfig.Hostname
+			clientConfig.Owner = previous.Organization
+			g, err := utils.NewGetter(clientConfig)
+			if err != nil {
+				return err
+			}
+
+			out := driftCmd.OutOrStdout()
+			if cmdFlags.outputFile != "" {
+				f, err := os.Create(cmdFlags.outputFile)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					if closeErr := f.Close(); closeErr != nil {
+						zap.S().Warnf("Error closing file: %v", closeErr)
+					}
+				}()
+				out = f
+			}
+
+			return runCmdDrift(ctx, previous, args, envs, &cmdFlags, g, out)
+		},
+	}
+
+	// Configure flags for command
+	driftCmd.Flags().StringVarP(&cmdFlags.since, "since", "s", "", "Snapshot file to compare against")
+	driftCmd.Flags().StringVarP(&cmdFlags.format, "format", "F", "table", "Output format: table or json")
+	driftCmd.Flags().StringVarP(&cmdFlags.outputFile, "output-file", "o", "", "Name of file to write the report to (default: standard output)")
+	driftCmd.Flags().BoolVar(&cmdFlags.exitCode, "exit-code", false, "Exit with an error if any drift is found")
+	cmdFlags.envFilter.AddFlags(driftCmd.Flags())
+	_ = driftCmd.MarkFlagRequired("since")
+
+	return &driftCmd
+}
+
+// runCmdDrift compares previous with the live state of its organization. When
+// repos or environment filters are given, only those environments are
+// compared on both sides.
+func runCmdDrift(ctx context.Context, previous snapshot.Snapshot, repos []string, envs utils.EnvFilter, cmdFlags *cmdFlags, g *utils.APIGetter, out io.Writer) error {
+	owner := previous.Organization
+	environments, err := utils.GatherOrgEnvironments(ctx, g, owner, repos, utils.RepoFilter{}, envs, true)
+	if err != nil {
+		return err
+	}
+	current := snapshot.New(cmdFlags.hostname, owner, environments, time.Now())
+
+	previous.Filter(func(repo string, env string) bool {
+		if !envs.Match(env) {
+			return false
+		}
+		if len(repos) == 0 {
+			return true
+		}
+		for _, name := range repos {
+			if strings.EqualFold(name, repo) {
+				return true
+			}
+		}
+		return false
+	})
+
+	drift := snapshot.Compare(previous, current)
+	if cmdFlags.format == "json" {
+		err = drift.WriteJSON(out)
+	} else {
+		err = drift.WriteTable(out)
+	}
+	if err != nil {
+		return err
+	}
+
+	if cmdFlags.exitCode && len(drift.Changes) > 0 {
+		return exitcode.New(exitcode.PolicyViolation, errors.New("drift detected"))
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package drift
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/snapshot"
+	"github.com/katiem0/gh-environments/internal/utils"
+)
+
+func TestNewCmdDrift(t *testing.T) {
+	cmd := NewCmdDrift()
+
+	for _, flag := range []string{"since", "format", "output-file", "exit-code", "env"} {
+		if cmd.Flag(flag) == nil {
+			t.Errorf("%s flag not found", flag)
+		}
+	}
+
+	cmd.SetArgs([]string{})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "since") {
+		t.Errorf("Expected --since to be required, got %v", err)
+	}
+
+	cmd = NewCmdDrift()
+	cmd.SetArgs([]string{"--since", filepath.Join(t.TempDir(), "missing.json")})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for a missing snapshot file")
+	}
+}
+
+func TestRunCmdDrift(t *testing.T) {
+	ctx := context.Background()
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "app").Environments["production"] = &utils.MockEnvironment{Name: "production", WaitTimer: 5}
+	server.AddRepo(2, "web").Environments["staging"] = &utils.MockEnvironment{Name: "staging"}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	environments, err := utils.GatherOrgEnvironments(ctx, g, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := snapshot.New("github.com", "testorg", environments, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+
+	var out bytes.Buffer
+	flags := &cmdFlags{hostname: "github.com", format: "table", exitCode: true}
+	if err := runCmdDrift(ctx, previous, nil, utils.EnvFilter{}, flags, g, &out); err != nil {
+		t.Fatalf("Expected no drift, got %v\n%s", err, out.String())
+	}
+
+	env := server.Repos["app"].Environments["production"]
+	env.WaitTimer = 0
+	env.Reviewers = []data.Reviewers{{Type: "User", Reviewer: data.Reviewer{Login: "octocat", ID: 1}}}
+	server.Repos["web"].Environments["qa"] = &utils.MockEnvironment{Name: "qa"}
+
+	out.Reset()
+	err = runCmdDrift(ctx, previous, []string{"app"}, utils.EnvFilter{}, flags, g, &out)
+	if err == nil || err.Error() != "drift detected" || exitcode.Code(err) != exitcode.PolicyViolation {
+		t.Errorf("Expected drift to be detected as a policy violation, got %v", err)
+	}
+	for _, want := range []string{"wait_timer", "User:octocat", "2 change(s) since 2024-06-01T00:00:00Z"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "web") {
+		t.Errorf("Expected only app to be compared, got:\n%s", out.String())
+	}
+
+	// The snapshot is also filtered by the environment filters
+	out.Reset()
+	flags.exitCode = false
+	flags.format = "json"
+	if err := runCmdDrift(ctx, previous, nil, utils.EnvFilter{Globs: []string{"qa"}}, flags, g, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"environment": "qa"`) || !strings.Contains(out.String(), `"kind": "added"`) || strings.Contains(out.String(), "production") {
+		t.Errorf("Unexpected report:\n%s", out.String())
+	}
+}
+
+func TestRunCmdDriftUnreadable(t *testing.T) {
+	ctx := context.Background()
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "app").Environments["production"] = &utils.MockEnvironment{
+		Name:    "production",
+		Secrets: []data.Secret{{Name: "TOKEN"}},
+	}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	environments, err := utils.GatherOrgEnvironments(ctx, g, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := snapshot.New("github.com", "testorg", environments, time.Now())
+
+	// Secrets that cannot be read are not reported as removed
+	server.Reject = func(req *http.Request) int {
+		if strings.HasSuffix(req.URL.Path, "environments/production/secrets") {
+			return http.StatusInternalServerError
+		}
+		return 0
+	}
+	var out bytes.Buffer
+	err = runCmdDrift(ctx, previous, nil, utils.EnvFilter{}, &cmdFlags{hostname: "github.com", format: "table", exitCode: true}, g, &out)
+	if err == nil || exitcode.Code(err) == exitcode.PolicyViolation {
+		t.Errorf("Expected the read error instead of drift, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected no drift report, got:\n%s", out.String())
+	}
+}
+
+func TestDriftSnapshotFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := os.WriteFile(fileName, []byte(`{"version": 99}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := NewCmdDrift()
+	cmd.SetArgs([]string{"--since", fileName})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "unsupported snapshot version") {
+		t.Errorf("Expected version error, got %v", err)
+	}
+}
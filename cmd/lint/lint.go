@@ -0,0 +1,232 @@
+package lint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/lint"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"github.com/katiem0/gh-environments/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type cmdFlags struct {
+	configFile string
+	rulesDir   string
+	testRules  bool
+	fix        bool
+	yes        bool
+	format     string
+	outputFile string
+	repoFilter utils.RepoFilterFlags
+	envFilter  utils.EnvFilterFlags
+}
+
+func NewCmdLint() *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	lintCmd := cobra.Command{
+		Use:   "lint [flags] <organization> [repo ...]",
+		Short: "Check environments against policy rules.",
+		Long:  "Check the environments of an organization against built-in and configured policy rules, and exit with an error if any rule with error severity fails.",
+		Args:  cobra.ArbitraryArgs,
+		// Violations are reported as an error, which should not print usage
+		SilenceUsage: true,
+		RunE: func(lintCmd *cobra.Command, args []string) error {
+			ctx := lintCmd.Context()
+			var err error
+
+			if err = lint.ValidateFormat(cmdFlags.format); err != nil {
+				return exitcode.New(exitcode.ValidationFailure, err)
+			}
+			if cmdFlags.yes && !cmdFlags.fix {
+				return exitcode.New(exitcode.ValidationFailure, fmt.Errorf("--yes requires --fix"))
+			}
+			if cmdFlags.testRules && cmdFlags.rulesDir == "" {
+				return exitcode.New(exitcode.ValidationFailure, fmt.Errorf("--test requires --rules-dir"))
+			}
+			if !cmdFlags.testRules && len(args) == 0 {
+				return exitcode.New(exitcode.ValidationFailure, fmt.Errorf("requires an organization argument"))
+			}
+			cfg := lint.DefaultConfig()
+			if cmdFlags.configFile != "" {
+				cfg, err = lint.LoadConfig(cmdFlags.configFile)
+				if err != nil {
+					return exitcode.New(exitcode.ValidationFailure, err)
+				}
+			}
+			var tests []lint.RuleTest
+			if cmdFlags.rulesDir != "" {
+				var rules []lint.RuleConfig
+				rules, tests, err = lint.LoadRulesDir(cmdFlags.rulesDir)
+				if err != nil {
+					return exitcode.New(exitcode.ValidationFailure, err)
+				}
+				if err = cfg.AddRules(rules); err != nil {
+					return exitcode.New(exitcode.ValidationFailure, fmt.Errorf("%s: %w", cmdFlags.rulesDir, err))
+				}
+			}
+			if cmdFlags.testRules {
+				return runRuleTests(cfg, tests, lintCmd.OutOrStdout())
+			}
+			repos, err := cmdFlags.repoFilter.Repos(args[1:])
+			if err != nil {
+				return err
+			}
+			filter, err := cmdFlags.repoFilter.Filter()
+			if err != nil {
+				return err
+			}
+			envs, err := cmdFlags.envFilter.Filter()
+			if err != nil {
+				return err
+			}
+
+			clientConfig := utils.ClientConfigFromFlags(lintCmd.Flags())
+			clientConfig.Owner = args[0]
+			g, err := utils.NewGetter(clientConfig)
+			if err != nil {
+				return err
+			}
+
+			out := lintCmd.OutOrStdout()
+			if cmdFlags.outputFile != "" {
+				f, err := os.Create(cmdFlags.outputFile)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					if closeErr := f.Close(); closeErr != nil {
+						zap.S().Warnf("Error closing file: %v", closeErr)
+					}
+				}()
+				out = f
+			}
+
+			return runCmdLint(ctx, args[0], repos, filter, envs, cfg, &cmdFlags, g, out, lintCmd.ErrOrStderr())
+		},
+	}
+
+	// Configure flags for command
+	lintCmd.Flags().StringVarP(&cmdFlags.configFile, "config", "c", "", "Path to a YAML file of lint rules (default: built-in production rules)")
+	lintCmd.Flags().StringVarP(&cmdFlags.rulesDir, "rules-dir", "", "", "Directory of YAML files defining custom expression rules and their tests")
+	lintCmd.Flags().BoolVarP(&cmdFlags.testRules, "test", "", false, "Run the rule tests in --rules-dir instead of linting an organization")
+	lintCmd.Flags().BoolVarP(&cmdFlags.fix, "fix", "", false, "Show a plan of changes that fix violations with an automatic remediation")
+	lintCmd.Flags().BoolVarP(&cmdFlags.yes, "yes", "y", false, "Apply the changes planned by --fix")
+	lintCmd.Flags().StringVarP(&cmdFlags.format, "format", "F", lint.FormatTable, "Output format: table, json or sarif")
+	lintCmd.Flags().StringVarP(&cmdFlags.outputFile, "output-file", "o", "", "Name of file to write results to (default: standard output)")
+	cmdFlags.envFilter.AddFlags(lintCmd.Flags())
+	cmdFlags.repoFilter.AddFlags(lintCmd.Flags())
+
+	return &lintCmd
+}
+
+// runCmdLint writes the lint report to out. With --fix, the remediation plan
+// is written to planOut so that JSON and SARIF reports stay parseable.
+func runCmdLint(ctx context.Context, owner string, repos []string, filter utils.RepoFilter, envs utils.EnvFilter, cfg *lint.Config, cmdFlags *cmdFlags, g *utils.APIGetter, out io.Writer, planOut io.Writer) error {
+	environments, err := utils.GatherOrgEnvironments(ctx, g, owner, repos, filter, envs, false)
+	if err != nil {
+		return err
+	}
+
+	zap.S().Debugf("Linting %d environment(s) against %d rule(s)", len(environments), len(cfg.Rules))
+	result := lint.Lint(environments, cfg)
+	if err := lint.Write(out, result, cmdFlags.format); err != nil {
+		return err
+	}
+
+	if cmdFlags.fix {
+		plan := lint.PlanFixes(environments, cfg, result)
+		if err := lint.WritePlan(planOut, plan); err != nil {
+			return err
+		}
+		if cmdFlags.yes {
+			return applyFixes(ctx, owner, plan, g, planOut)
+		}
+		if len(plan.Fixes) > 0 {
+			fmt.Fprintln(planOut, "Run again with --yes to apply these changes.")
+		}
+	}
+
+	if count := result.Count(lint.SeverityError); count > 0 {
+		return exitcode.New(exitcode.PolicyViolation, fmt.Errorf("lint found %d error(s)", count))
+	}
+	return nil
+}
+
+// runRuleTests evaluates the fixtures shipped with custom rules. No API calls
+// are made, so rules can be tested in CI without a token.
+func runRuleTests(cfg *lint.Config, tests []lint.RuleTest, out io.Writer) error {
+	failures := lint.RunTests(cfg, tests)
+	for _, failure := range failures {
+		fmt.Fprintf(out, "FAIL %s\n", failure.Error())
+	}
+	fmt.Fprintf(out, "%d rule test(s) run, %d failed\n", len(tests), len(failures))
+	if len(failures) > 0 {
+		return fmt.Errorf("%d rule test(s) failed", len(failures))
+	}
+	return nil
+}
+
+// applyFixes updates each environment in plan. Failures are reported and the
+// remaining environments are still updated.
+func applyFixes(ctx context.Context, owner string, plan lint.Plan, g *utils.APIGetter, out io.Writer) error {
+	p := utils.NewPrinter(out)
+	tracker := progress.FromContext(ctx)
+	failed := 0
+	for _, fix := range plan.Fixes {
+		// Environments left once the run is interrupted are not updated
+		if ctx.Err() != nil {
+			break
+		}
+		zap.S().Debugf("Applying fixes to environment %s in repo %s", fix.Environment, fix.Repository)
+		reviewers, err := utils.ResolveReviewers(ctx, g, owner, fix.AddTeams, fix.AddUsers)
+		if err != nil {
+			zap.S().Errorf("Error resolving reviewers for %s/%s: %v", fix.Repository, fix.Environment, err)
+			tracker.Failed()
+			failed++
+			continue
+		}
+		fix.AddReviewers(reviewers)
+
+		payload, err := json.Marshal(fix.Payload)
+		if err != nil {
+			return err
+		}
+		err = g.CreateEnvironment(ctx, owner, fix.Repository, fix.Environment, bytes.NewReader(payload))
+		if err != nil {
+			zap.S().Errorf("Error updating environment %s in repo %s: %v", fix.Environment, fix.Repository, err)
+			tracker.Failed()
+			failed++
+			continue
+		}
+		tracker.Updated()
+		p.Printf("Updated %s/%s environment %s\n", fix.Organization, fix.Repository, fix.Environment)
+	}
+
+	if ctx.Err() != nil {
+		return utils.InterruptedError(ctx)
+	}
+	if failed > 0 {
+		return exitcode.Failures(failed, len(plan.Fixes), fmt.Errorf("failed to fix %d environment(s)", failed))
+	}
+	if err := p.Err(); err != nil {
+		return err
+	}
+	manual := 0
+	for _, v := range plan.Manual {
+		if v.Severity == lint.SeverityError {
+			manual++
+		}
+	}
+	if manual > 0 {
+		return exitcode.New(exitcode.PolicyViolation, fmt.Errorf("lint found %d error(s) that must be fixed manually", manual))
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+package lint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/lint"
+	"github.com/katiem0/gh-environments/internal/utils"
+)
+
+func TestNewCmdLint(t *testing.T) {
+	cmd := NewCmdLint()
+
+	if cmd.Use != "lint [flags] <organization> [repo ...]" {
+		t.Errorf("Unexpected Use %q", cmd.Use)
+	}
+	for _, flag := range []string{"config", "format", "output-file", "env", "visibility"} {
+		if cmd.Flag(flag) == nil {
+			t.Errorf("%s flag not found", flag)
+		}
+	}
+
+	cmd.SetArgs([]string{"testorg", "--format", "xml"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "invalid format") {
+		t.Errorf("Expected invalid format error, got %v", err)
+	}
+}
+
+func newLintServer(t *testing.T) *utils.APIGetter {
+	t.Helper()
+	server := utils.NewMockGitHubServer()
+	repo := server.AddRepo(1, "app")
+	repo.Environments["production"] = &utils.MockEnvironment{
+		Name:              "production",
+		WaitTimer:         10,
+		PreventSelfReview: true,
+		Reviewers: []data.Reviewers{
+			{Type: "User", Reviewer: data.Reviewer{Login: "octocat", ID: 1}},
+			{Type: "User", Reviewer: data.Reviewer{Login: "hubot", ID: 2}},
+		},
+		DeploymentPolicy: &data.DeploymentPolicy{ProtectedBranches: true},
+	}
+	server.AddRepo(2, "api").Environments["production"] = &utils.MockEnvironment{Name: "production", CanAdminsBypass: true}
+
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestRunCmdLint(t *testing.T) {
+	ctx := context.Background()
+	g := newLintServer(t)
+
+	var out bytes.Buffer
+	err := runCmdLint(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, lint.DefaultConfig(), &cmdFlags{format: lint.FormatJSON}, g, &out, io.Discard)
+	if err == nil || err.Error() != "lint found 4 error(s)" || exitcode.Code(err) != exitcode.PolicyViolation {
+		t.Errorf("Expected 4 errors to be reported as a policy violation, got %v", err)
+	}
+	var report struct {
+		Environments int              `json:"environments"`
+		Violations   []lint.Violation `json:"violations"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Invalid JSON output: %v\n%s", err, out.String())
+	}
+	if report.Environments != 2 {
+		t.Errorf("Expected 2 environments checked, got %d", report.Environments)
+	}
+	for _, v := range report.Violations {
+		if v.Repository != "api" {
+			t.Errorf("Unexpected violation for compliant repository: %+v", v)
+		}
+	}
+
+	// Only the compliant repository passes without errors
+	out.Reset()
+	err = runCmdLint(ctx, "testorg", []string{"app"}, utils.RepoFilter{}, utils.EnvFilter{}, lint.DefaultConfig(), &cmdFlags{format: lint.FormatTable}, g, &out, io.Discard)
+	if err != nil {
+		t.Errorf("Expected no errors, got %v", err)
+	}
+	if !strings.Contains(out.String(), "1 environment(s) checked: 0 error(s)") {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+}
+
+func TestLintRuleTests(t *testing.T) {
+	dir := t.TempDir()
+	content := "rules:\n  - id: min-wait\n    expr: wait_timer >= 5\ntests:\n  - name: short wait fails\n    rule: min-wait\n    environment: {wait_timer: 1}\n    pass: false\n"
+	if err := os.WriteFile(filepath.Join(dir, "rules.yml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := NewCmdLint()
+	var out bytes.Buffer
+	cmd.SetArgs([]string{"--rules-dir", dir, "--test"})
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "1 rule test(s) run, 0 failed") {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+
+	cmd = NewCmdLint()
+	cmd.SetArgs([]string{"--test"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--test requires --rules-dir") {
+		t.Errorf("Expected --rules-dir error, got %v", err)
+	}
+}
+
+func TestRunCmdLintFix(t *testing.T) {
+	ctx := context.Background()
+	server := utils.NewMockGitHubServer()
+	server.Accounts = []data.Reviewers{
+		{Type: "Team", Reviewer: data.Reviewer{Slug: "release-managers", ID: 10}},
+	}
+	server.AddRepo(1, "api").Environments["production"] = &utils.MockEnvironment{
+		Name:            "production",
+		CanAdminsBypass: true,
+		Reviewers:       []data.Reviewers{{Type: "User", Reviewer: data.Reviewer{Login: "octocat", ID: 1}}},
+	}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &lint.Config{Rules: []lint.RuleConfig{
+		{Check: "required-reviewers", With: lint.Params{Teams: []string{"release-managers"}}},
+		{Check: "no-admin-bypass"},
+		{Check: "protected-branches"},
+		{Check: "min-wait-timer", Severity: lint.SeverityWarning, With: lint.Params{Minutes: 5}},
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without --yes only the plan is shown
+	var plan bytes.Buffer
+	err = runCmdLint(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, cfg, &cmdFlags{format: lint.FormatTable, fix: true}, g, io.Discard, &plan)
+	if err == nil || err.Error() != "lint found 3 error(s)" {
+		t.Errorf("Expected lint errors, got %v", err)
+	}
+	if !strings.Contains(plan.String(), "add team release-managers as a required reviewer") || !strings.Contains(plan.String(), "--yes") {
+		t.Errorf("Unexpected plan:\n%s", plan.String())
+	}
+	if server.Repos["api"].Environments["production"].CanAdminsBypass != true {
+		t.Fatal("Expected environment to be unchanged without --yes")
+	}
+
+	plan.Reset()
+	err = runCmdLint(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, cfg, &cmdFlags{format: lint.FormatTable, fix: true, yes: true}, g, io.Discard, &plan)
+	if err != nil {
+		t.Fatalf("runCmdLint() error = %v\n%s", err, plan.String())
+	}
+	if !strings.Contains(plan.String(), "Updated testorg/api environment production") {
+		t.Errorf("Unexpected output:\n%s", plan.String())
+	}
+
+	env := server.Repos["api"].Environments["production"]
+	if env.CanAdminsBypass || env.WaitTimer != 5 || env.DeploymentPolicy == nil || !env.DeploymentPolicy.ProtectedBranches {
+		t.Errorf("Environment was not fixed: %+v", env)
+	}
+	if len(env.Reviewers) != 2 || env.Reviewers[0].Reviewer.ID != 1 || env.Reviewers[1].Reviewer.Slug != "release-managers" {
+		t.Errorf("Expected existing and added reviewers, got %+v", env.Reviewers)
+	}
+
+	// The fixed environment now passes
+	var out bytes.Buffer
+	if err := runCmdLint(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, cfg, &cmdFlags{format: lint.FormatTable}, g, &out, io.Discard); err != nil {
+		t.Errorf("Expected no violations after fixing, got %v\n%s", err, out.String())
+	}
+}
+
+func TestRunCmdLintFixFailure(t *testing.T) {
+	ctx := context.Background()
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "api").Environments["production"] = &utils.MockEnvironment{Name: "production", CanAdminsBypass: true}
+	server.AddRepo(2, "web").Environments["production"] = &utils.MockEnvironment{Name: "production", CanAdminsBypass: true}
+	// The API rejects the update of api only
+	server.Reject = func(req *http.Request) int {
+		if req.Method == "PUT" && strings.HasSuffix(req.URL.Path, "repos/testorg/api/environments/production") {
+			return http.StatusUnprocessableEntity
+		}
+		return 0
+	}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &lint.Config{Rules: []lint.RuleConfig{{Check: "no-admin-bypass"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var plan bytes.Buffer
+	err = runCmdLint(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, cfg, &cmdFlags{format: lint.FormatTable, fix: true, yes: true}, g, io.Discard, &plan)
+	if code := exitcode.Code(err); code != exitcode.PartialFailure {
+		t.Fatalf("Expected a partial failure, got %v", err)
+	}
+	if server.Repos["web"].Environments["production"].CanAdminsBypass {
+		t.Error("Expected web to be fixed after api failed")
+	}
+	if !strings.Contains(plan.String(), "Updated testorg/web environment production") {
+		t.Errorf("Unexpected output:\n%s", plan.String())
+	}
+}
+
+func TestRunCmdLintFixInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "api").Environments["production"] = &utils.MockEnvironment{Name: "production", CanAdminsBypass: true}
+	server.AddRepo(2, "web").Environments["production"] = &utils.MockEnvironment{Name: "production", CanAdminsBypass: true}
+	// The run is interrupted while api is updated
+	server.BeforeRequest = func(req *http.Request) {
+		if req.Method == "PUT" && strings.HasSuffix(req.URL.Path, "repos/testorg/api/environments/production") {
+			cancel()
+		}
+	}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &lint.Config{Rules: []lint.RuleConfig{{Check: "no-admin-bypass"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	err = runCmdLint(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, cfg, &cmdFlags{format: lint.FormatTable, fix: true, yes: true}, g, io.Discard, io.Discard)
+	if code := exitcode.Code(err); code != exitcode.PartialFailure || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected a partial failure for the interrupt, got %v", err)
+	}
+	if !server.Repos["web"].Environments["production"].CanAdminsBypass {
+		t.Error("Expected web not to be updated after the interrupt")
+	}
+}
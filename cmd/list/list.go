@@ -1,88 +1,73 @@
 package list
 
 import (
+	"context"
 	"encoding/csv"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
-	"strconv"
-	"strings"
 	"time"
 
-	"github.com/cli/go-gh/v2/pkg/api"
-	"github.com/cli/go-gh/v2/pkg/auth"
-	"github.com/katiem0/gh-environments/internal/data"
-	"github.com/katiem0/gh-environments/internal/log"
+	"github.com/katiem0/gh-environments/internal/exitcode"
 	"github.com/katiem0/gh-environments/internal/utils"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
 type cmdFlags struct {
-	hostname   string
-	token      string
 	reportFile string
-	debug      bool
+	repoFilter utils.RepoFilterFlags
+	envFilter  utils.EnvFilterFlags
+	enterprise string
 }
 
 func NewCmdList() *cobra.Command {
 	//var repository string
 	cmdFlags := cmdFlags{}
-	var authToken string
 
 	listCmd := cobra.Command{
 		Use:   "list [flags] <organization> [repo ...] ",
 		Short: "Generate a report of environments and metadata.",
 		Long:  "Generate a report of environments and metadata for a single repository or all repositories in an organization.",
-		Args:  cobra.MinimumNArgs(1),
+		Args:  cobra.ArbitraryArgs,
 		RunE: func(listCmd *cobra.Command, args []string) error {
+			ctx := listCmd.Context()
+			if cmdFlags.enterprise == "" && len(args) == 0 {
+				return exitcode.New(exitcode.ValidationFailure, errors.New("requires an organization or --enterprise"))
+			}
+			if cmdFlags.enterprise != "" && len(args) > 0 {
+				return exitcode.New(exitcode.ValidationFailure, errors.New("--enterprise cannot be combined with an organization or repositories"))
+			}
 			var err error
-			var gqlClient *api.GraphQLClient
-			var restClient *api.RESTClient
-			// Reinitialize logging if debugging was enabled
-			if cmdFlags.debug {
-				logger, _ := log.NewLogger(cmdFlags.debug)
-				defer logger.Sync() // nolint:errcheck
-				zap.ReplaceGlobals(logger)
+			// Requests for an enterprise scan name their organization
+			owner := ""
+			if len(args) > 0 {
+				owner = args[0]
 			}
-
-			if cmdFlags.token != "" {
-				authToken = cmdFlags.token
-			} else {
-				t, _ := auth.TokenForHost(cmdFlags.hostname)
-				authToken = t
+			clientConfig := utils.ClientConfigFromFlags(listCmd.Flags())
+			clientConfig.Owner = owner
+			g, err := utils.NewGetter(clientConfig)
+			if err != nil {
+				return err
 			}
 
-			gqlClient, err = api.NewGraphQLClient(api.ClientOptions{
-				Headers: map[string]string{
-					"Accept": "application/vnd.github.hawkgirl-preview+json",
-				},
-				Host:      cmdFlags.hostname,
-				AuthToken: authToken,
-			})
-
+			var repos []string
+			if len(args) > 0 {
+				repos = args[1:]
+			}
+			repos, err = cmdFlags.repoFilter.Repos(repos)
 			if err != nil {
-				zap.S().Errorf("Error arose retrieving graphql client")
 				return err
 			}
-
-			restClient, err = api.NewRESTClient(api.ClientOptions{
-				Headers: map[string]string{
-					"Accept": "application/vnd.github+json",
-				},
-				Host:      cmdFlags.hostname,
-				AuthToken: authToken,
-			})
-
+			filter, err := cmdFlags.repoFilter.Filter()
+			if err != nil {
+				return err
+			}
+			envs, err := cmdFlags.envFilter.Filter()
 			if err != nil {
-				zap.S().Errorf("Error arose retrieving rest client")
 				return err
 			}
-
-			owner := args[0]
-			repos := args[1:]
 
 			if _, err := os.Stat(cmdFlags.reportFile); errors.Is(err, os.ErrExist) {
 				return err
@@ -94,7 +79,16 @@ func NewCmdList() *cobra.Command {
 				return err
 			}
 
-			return runCmdList(owner, repos, &cmdFlags, utils.NewAPIGetter(gqlClient, restClient), reportWriter)
+			owners := args[:1]
+			if cmdFlags.enterprise != "" {
+				owners, err = utils.GatherEnterpriseOrgs(ctx, g, cmdFlags.enterprise)
+				if err != nil {
+					zap.S().Error("Error raised in gathering enterprise organizations", zap.Error(err))
+					return err
+				}
+			}
+
+			return runCmdList(ctx, owners, repos, filter, envs, &cmdFlags, g, reportWriter)
 		},
 	}
 
@@ -103,227 +97,91 @@ func NewCmdList() *cobra.Command {
 
 	// Configure flags for command
 
-	listCmd.PersistentFlags().StringVarP(&cmdFlags.token, "token", "t", "", `GitHub Personal Access Token (default "gh auth token")`)
-	listCmd.PersistentFlags().StringVarP(&cmdFlags.hostname, "hostname", "", "github.com", "GitHub Enterprise Server hostname")
 	listCmd.Flags().StringVarP(&cmdFlags.reportFile, "output-file", "o", reportFileDefault, "Name of file to write CSV report")
-	listCmd.PersistentFlags().BoolVarP(&cmdFlags.debug, "debug", "d", false, "To debug logging")
+	listCmd.Flags().StringVar(&cmdFlags.enterprise, "enterprise", "", "Enterprise slug to scan every organization of, instead of a single organization")
+	cmdFlags.envFilter.AddFlags(listCmd.Flags())
+	cmdFlags.repoFilter.AddFlags(listCmd.Flags())
 
 	return &listCmd
 }
 
-func runCmdList(owner string, repos []string, cmdFlags *cmdFlags, g *utils.APIGetter, reportWriter io.Writer) error {
-	var reposCursor *string
-	var allRepos []data.RepoInfo
-
+func runCmdList(ctx context.Context, owners []string, repos []string, filter utils.RepoFilter, envs utils.EnvFilter, cmdFlags *cmdFlags, g *utils.APIGetter, reportWriter io.Writer) error {
 	csvWriter := csv.NewWriter(reportWriter)
 
-	err := csvWriter.Write([]string{
-		"RepositoryName",
-		"RepositoryID",
-		"EnvironmentName",
-		"AdminBypass",
-		"WaitTimer",
-		"Reviewers",
-		"PreventSelfReview",
-		"BranchPolicyType",
-		"Branches",
-		"CustomDeploymentProtectionPolicy",
-		"SecretsTotalCount",
-		"VariablesTotalCount",
-	})
+	propertyNames := utils.PropertyNames(filter.Properties)
+	header := append(utils.ReportHeaders(utils.EnvironmentReportColumns), propertyNames...)
+	if cmdFlags.enterprise != "" {
+		header = append([]string{"Organization"}, header...)
+	}
+	err := csvWriter.Write(header)
 
 	if err != nil {
 		zap.S().Error("Error raised in writing output", zap.Error(err))
 	}
 
-	if len(repos) > 0 {
-		zap.S().Infof("Processing repos: %s", repos)
-
-		for _, repo := range repos {
-
-			zap.S().Debugf("Processing %s/%s", owner, repo)
-
-			repoQuery, err := g.GetRepo(owner, repo)
-			if err != nil {
-				zap.S().Error("Error raised in gathering repo", zap.Error(err))
-			}
-			allRepos = append(allRepos, repoQuery.Repository)
-		}
-
-	} else {
-		// Prepare writer for outputting report
-		for {
-			zap.S().Debugf("Processing list of repositories for %s", owner)
-			reposQuery, err := g.GetReposList(owner, reposCursor)
-
-			if err != nil {
-				zap.S().Error("Error raised in gathering repos", zap.Error(err))
+	var summaries []utils.OrgSummary
+	for _, owner := range owners {
+		summary, err := listOrganization(ctx, owner, repos, filter, envs, cmdFlags, g, csvWriter, propertyNames)
+		if err != nil {
+			if ctx.Err() != nil {
+				return utils.WritePartialReport(ctx, csvWriter, cmdFlags.reportFile)
 			}
-
-			allRepos = append(allRepos, reposQuery.Organization.Repositories.Nodes...)
-
-			reposCursor = &reposQuery.Organization.Repositories.PageInfo.EndCursor
-
-			if !reposQuery.Organization.Repositories.PageInfo.HasNextPage {
-				break
+			if cmdFlags.enterprise == "" {
+				return err
 			}
+			zap.S().Errorf("Error raised in processing organization %s: %v", owner, err)
+			summary.Err = err
 		}
+		summaries = append(summaries, summary)
 	}
-	// Gathering Envs for each repository listed
 
-	zap.S().Debug("Gathering all repository environments")
-	for _, singleRepo := range allRepos {
-		zap.S().Debugf("Gathering Environments for repo %s", singleRepo.Name)
-		repoEnvs, err := g.GetRepoEnvironments(owner, singleRepo.Name)
-		if err != nil {
-			zap.S().Errorf("Error accessing repo environments for %s: %v", singleRepo.Name, err)
-			continue
-		}
-		var responseEnvs data.EnvResponse
-		err = json.Unmarshal(repoEnvs, &responseEnvs)
-		if err != nil {
-			zap.S().Errorf("Error unmarshaling response for %s: %v", singleRepo.Name, err)
-			continue
+	csvWriter.Flush()
+	fmt.Fprintf(os.Stderr, "Successfully exported environment data to csv file: %s\n", cmdFlags.reportFile)
+	if cmdFlags.enterprise != "" {
+		if err := utils.WriteOrgSummary(os.Stderr, summaries, "Environments"); err != nil {
+			return err
 		}
+	}
+	return utils.OrgFailures(ctx, summaries)
+}
 
-		zap.S().Debugf("Writing data for %d environment(s) to output for repository %s", responseEnvs.TotalCount, singleRepo.Name)
-		for _, env := range responseEnvs.Environments {
-			var waitTimer int
-			var preventSelfReview bool
-			var Reviewers []string
-			var BranchPolicyType string
-			var Branches []string
-			var Apps []string
-
-			var envDeploymentProtectionPolicy data.DeploymentProtectionPolicy
-			var envVars data.EnvVariables
-			var envSecrets data.EnvSecret
-
-			for _, rules := range env.ProtectionRules {
-				zap.S().Debugf("Gathering Protection Rules for environment %s", env.Name)
-				switch rules.Type {
-				case "wait_timer":
-					waitTimer = rules.WaitTimer
-				case "required_reviewers":
-					zap.S().Debugf("Gathering Required Reviewers for environment %s", env.Name)
-					preventSelfReview = rules.PreventSelfReview
-					for _, reviewer := range rules.Reviewers {
-						var reviewList []string
-						reviewList = append(reviewList, reviewer.Type)
-						reviewList = append(reviewList, reviewer.Reviewer.Login)
-						reviewList = append(reviewList, strconv.Itoa(reviewer.Reviewer.ID))
-						reviewLists := strings.Join(reviewList, ";")
-						Reviewers = append(Reviewers, reviewLists)
-					}
-				case "branch_policy":
-					zap.S().Debugf("Gathering Branch Policies for environment %s", env.Name)
-					if env.DeploymentPolicy.CustomPolicies {
-						BranchPolicyType = "custom"
-
-						BranchResp, err := g.GetDeploymentBranchPolicies(owner, singleRepo.Name, env.Name)
-						if err != nil {
-							zap.S().Error("Error raised in writing output", zap.Error(err))
-						}
-						var branchEnvs data.BranchPolicies
-						err = json.Unmarshal(BranchResp, &branchEnvs)
-						if err != nil {
-							return err
-						}
-						for _, branch := range branchEnvs.BranchPolicies {
-							var branchList []string
-							branchList = append(branchList, branch.Name)
-							branchList = append(branchList, branch.Type)
-							BranchLists := strings.Join(branchList, ";")
-							Branches = append(Branches, BranchLists)
-						}
-					} else if env.DeploymentPolicy.ProtectedBranches {
-						BranchPolicyType = "protected"
-					}
-				}
-			}
-
-			//Get enabled Custom Deployment Protection Policies for Env
-			zap.S().Debugf("Gathering Custom Deployment Protection Policies for environment %s", env.Name)
-			envProtectionResp, err := g.GetDeploymentProtectionRules(owner, singleRepo.Name, env.Name)
-			if err != nil {
-				if strings.Contains(err.Error(), "404: Not Found") {
-					zap.S().Debug("No custom deployment protection policies found for environment")
-				} else {
-					zap.S().Error("Error raised in writing output for deployment protection policies", zap.Error(err))
-				}
-			} else {
-
-				err = json.Unmarshal(envProtectionResp, &envDeploymentProtectionPolicy)
-				if err != nil {
-					return err
-				}
-				for _, apps := range envDeploymentProtectionPolicy.CustomDeploymentRules {
-					zap.S().Debugf("Gathering Custom DeploymentProtection Rules for environment %s", env.Name)
-					var appList []string
-					appList = append(appList, strconv.Itoa(apps.PolicyID))
-					appList = append(appList, strconv.FormatBool(apps.Enabled))
-					appList = append(appList, strconv.Itoa(apps.App.IntegrationID))
-					appList = append(appList, apps.App.Slug)
-					AppLists := strings.Join(appList, ";")
-					Apps = append(Apps, AppLists)
-				}
-			}
-
-			//Get Secret Total Count
-			zap.S().Debugf("Gathering Count of Secrets for environment %s", env.Name)
-			envSecretResp, err := g.GetEnvironmentSecrets(owner, singleRepo.Name, env.Name)
-			if err != nil {
-				if strings.Contains(err.Error(), "404: Not Found") {
-					zap.S().Debug("No secrets found for environment")
-				} else {
-					zap.S().Error("Error raised in writing output for environment secrets", zap.Error(err))
-				}
-			} else {
-
-				err = json.Unmarshal(envSecretResp, &envSecrets)
-				if err != nil {
-					return err
-				}
-			}
-
-			//Get Variable total Count
-			zap.S().Debugf("Gathering Count of Variables for environment %s", env.Name)
-			envVarsResp, err := g.GetEnvironmentVariables(owner, singleRepo.Name, env.Name)
-			if err != nil {
-				if strings.Contains(err.Error(), "404: Not Found") {
-					zap.S().Debug("No variables found for environment")
-				} else {
-					zap.S().Error("Error raised in writing output for environment variables", zap.Error(err))
-				}
-			} else {
+// listOrganization writes the report rows for a single organization,
+// prefixing each with the organization name during an enterprise scan.
+func listOrganization(ctx context.Context, owner string, repos []string, filter utils.RepoFilter, envs utils.EnvFilter, cmdFlags *cmdFlags, g *utils.APIGetter, csvWriter *csv.Writer, propertyNames []string) (utils.OrgSummary, error) {
+	summary := utils.OrgSummary{Organization: owner}
+	writeRow := func(row []string) error {
+		if cmdFlags.enterprise != "" {
+			row = append([]string{owner}, row...)
+		}
+		summary.Rows++
+		return csvWriter.Write(row)
+	}
 
-				err = json.Unmarshal(envVarsResp, &envVars)
-				if err != nil {
-					zap.S().Error("Error raised in writing output", zap.Error(err))
-					return err
-				}
-			}
-			err = csvWriter.Write([]string{
-				singleRepo.Name,
-				strconv.Itoa(singleRepo.DatabaseId),
-				env.Name,
-				strconv.FormatBool(env.AdminByPass),
-				strconv.Itoa(waitTimer),
-				strings.Join(Reviewers, "|"),
-				strconv.FormatBool(preventSelfReview),
-				BranchPolicyType,
-				strings.Join(Branches, "|"),
-				strings.Join(Apps, "|"),
-				strconv.Itoa(envSecrets.TotalCount),
-				strconv.Itoa(envVars.TotalCount),
-			})
+	allRepos, err := utils.GatherRepos(ctx, g, owner, repos, filter)
+	if err != nil {
+		zap.S().Error("Error raised in gathering repos", zap.Error(err))
+		return summary, err
+	}
+	allRepos, properties, err := utils.SelectReposByProperties(ctx, g, owner, allRepos, filter.Properties)
+	if err != nil {
+		zap.S().Error("Error raised in gathering repository custom properties", zap.Error(err))
+		return summary, err
+	}
+	// Environments gathered before an interrupt are still written
+	environments, gatherErr := utils.GatherEnvironments(ctx, g, owner, allRepos, envs, false)
+	if gatherErr != nil && ctx.Err() == nil {
+		zap.S().Error("Error raised in gathering environments", zap.Error(gatherErr))
+		return summary, gatherErr
+	}
 
-			if err != nil {
-				zap.S().Error("Error raised in writing output", zap.Error(err))
-			}
+	zap.S().Debugf("Writing data for %d environment(s) to output", len(environments))
+	for _, env := range environments {
+		row := utils.EnvironmentReportRow(env)
+		err = writeRow(append(row, properties.Values(env.Repository, propertyNames)...))
+		if err != nil {
+			zap.S().Error("Error raised in writing output", zap.Error(err))
 		}
 	}
-	csvWriter.Flush()
-	fmt.Printf("Successfully exported environment data to csv file: %s\n", cmdFlags.reportFile)
-	return nil
+	summary.Repositories = len(allRepos)
+	return summary, gatherErr
 }
@@ -2,6 +2,7 @@ package list
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
@@ -45,54 +46,54 @@ type testAPIGetter struct {
 }
 
 // Implement the necessary methods from APIGetter
-func (t *testAPIGetter) GetReposList(owner string, endCursor *string) (*data.ReposQuery, error) {
+func (t *testAPIGetter) GetReposList(ctx context.Context, owner string, endCursor *string) (*data.ReposQuery, error) {
 	// Use the ReposResponse directly since MockAPIGetter doesn't have GetReposList method
 	return t.mock.ReposResponse, nil
 }
 
-func (t *testAPIGetter) GetRepo(owner string, name string) (*data.RepoSingleQuery, error) {
+func (t *testAPIGetter) GetRepo(ctx context.Context, owner string, name string) (*data.RepoSingleQuery, error) {
 	// Use the RepoResponse directly since MockAPIGetter doesn't have GetRepo method
 	return t.mock.RepoResponse, nil
 }
 
-func (t *testAPIGetter) GetRepoEnvironments(owner string, repo string) ([]byte, error) {
-	return t.mock.GetRepoEnvironments(owner, repo)
+func (t *testAPIGetter) GetRepoEnvironments(ctx context.Context, owner string, repo string) ([]byte, error) {
+	return t.mock.GetRepoEnvironments(ctx, owner, repo)
 }
 
-func (t *testAPIGetter) GetDeploymentBranchPolicies(owner string, repo string, env string) ([]byte, error) {
-	return t.mock.GetDeploymentBranchPolicies(owner, repo, env)
+func (t *testAPIGetter) GetDeploymentBranchPolicies(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error) {
+	return t.mock.GetDeploymentBranchPolicies(ctx, owner, repo, env, page)
 }
 
-func (t *testAPIGetter) GetDeploymentProtectionRules(owner string, repo string, env string) ([]byte, error) {
-	return t.mock.GetDeploymentProtectionRules(owner, repo, env)
+func (t *testAPIGetter) GetDeploymentProtectionRules(ctx context.Context, owner string, repo string, env string) ([]byte, error) {
+	return t.mock.GetDeploymentProtectionRules(ctx, owner, repo, env)
 }
 
-func (t *testAPIGetter) GetEnvironmentSecrets(owner string, repo string, env string) ([]byte, error) {
-	return t.mock.GetEnvironmentSecrets(owner, repo, env)
+func (t *testAPIGetter) GetEnvironmentSecrets(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error) {
+	return t.mock.GetEnvironmentSecrets(ctx, owner, repo, env, page)
 }
 
-func (t *testAPIGetter) GetEnvironmentVariables(owner string, repo string, env string) ([]byte, error) {
-	return t.mock.GetEnvironmentVariables(owner, repo, env)
+func (t *testAPIGetter) GetEnvironmentVariables(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error) {
+	return t.mock.GetEnvironmentVariables(ctx, owner, repo, env, page)
 }
 
-func (t *testAPIGetter) CreateEnvironment(owner string, repo string, env string, data io.Reader) error {
-	return t.mock.CreateEnvironment(owner, repo, env, data)
+func (t *testAPIGetter) CreateEnvironment(ctx context.Context, owner string, repo string, env string, data io.Reader) error {
+	return t.mock.CreateEnvironment(ctx, owner, repo, env, data)
 }
 
-func (t *testAPIGetter) CreateDeploymentBranches(owner string, repo string, env string, data io.Reader) error {
-	return t.mock.CreateDeploymentBranches(owner, repo, env, data)
+func (t *testAPIGetter) CreateDeploymentBranches(ctx context.Context, owner string, repo string, env string, data io.Reader) error {
+	return t.mock.CreateDeploymentBranches(ctx, owner, repo, env, data)
 }
 
-func (t *testAPIGetter) CreateEnvironmentSecret(owner string, repo string, env string, secret string, data io.Reader) error {
-	return t.mock.CreateEnvironmentSecret(owner, repo, env, secret, data)
+func (t *testAPIGetter) CreateEnvironmentSecret(ctx context.Context, owner string, repo string, env string, secret string, data io.Reader) error {
+	return t.mock.CreateEnvironmentSecret(ctx, owner, repo, env, secret, data)
 }
 
-func (t *testAPIGetter) CreateEnvironmentVariables(owner string, repo string, env string, data io.Reader) error {
-	return t.mock.CreateEnvironmentVariables(owner, repo, env, data)
+func (t *testAPIGetter) CreateEnvironmentVariables(ctx context.Context, owner string, repo string, env string, data io.Reader) error {
+	return t.mock.CreateEnvironmentVariables(ctx, owner, repo, env, data)
 }
 
-func (t *testAPIGetter) GetEnvironmentPublicKey(owner string, repo string, env string) ([]byte, error) {
-	return t.mock.GetEnvironmentPublicKey(owner, repo, env)
+func (t *testAPIGetter) GetEnvironmentPublicKey(ctx context.Context, owner string, repo string, env string) ([]byte, error) {
+	return t.mock.GetEnvironmentPublicKey(ctx, owner, repo, env)
 }
 
 func (t *testAPIGetter) EncryptSecret(publicKey string, secret string) (string, error) {
@@ -100,7 +101,7 @@ func (t *testAPIGetter) EncryptSecret(publicKey string, secret string) (string,
 }
 
 // Helper function to wrap RunCmdList for testing
-func testRunCmdList(owner string, repos []string, cmdFlags *cmdFlags, getter *testAPIGetter, reportWriter io.Writer) error {
+func testRunCmdList(ctx context.Context, owner string, repos []string, cmdFlags *cmdFlags, getter *testAPIGetter, reportWriter io.Writer) error {
 	// Create a CSV writer for our test output
 	csvWriter := csv.NewWriter(reportWriter)
 
@@ -129,7 +130,7 @@ func testRunCmdList(owner string, repos []string, cmdFlags *cmdFlags, getter *te
 	// Populate repos list either from specific repos or from org
 	if len(repos) > 0 {
 		for _, repo := range repos {
-			repoQuery, err := getter.GetRepo(owner, repo)
+			repoQuery, err := getter.GetRepo(ctx, owner, repo)
 			if err != nil {
 				return err
 			}
@@ -137,7 +138,7 @@ func testRunCmdList(owner string, repos []string, cmdFlags *cmdFlags, getter *te
 		}
 	} else {
 		// Get all repos from organization
-		reposQuery, err := getter.GetReposList(owner, nil)
+		reposQuery, err := getter.GetReposList(ctx, owner, nil)
 		if err != nil {
 			return err
 		}
@@ -146,7 +147,7 @@ func testRunCmdList(owner string, repos []string, cmdFlags *cmdFlags, getter *te
 
 	// Process environments for each repo
 	for _, singleRepo := range allRepos {
-		repoEnvs, err := getter.GetRepoEnvironments(owner, singleRepo.Name)
+		repoEnvs, err := getter.GetRepoEnvironments(ctx, owner, singleRepo.Name)
 		if err != nil {
 			continue
 		}
@@ -159,7 +160,7 @@ func testRunCmdList(owner string, repos []string, cmdFlags *cmdFlags, getter *te
 
 		for _, env := range responseEnvs.Environments {
 			// Get branch policies
-			branchPoliciesData, _ := getter.GetDeploymentBranchPolicies(owner, singleRepo.Name, env.Name)
+			branchPoliciesData, _ := getter.GetDeploymentBranchPolicies(ctx, owner, singleRepo.Name, env.Name, 1)
 			var branchPolicies data.BranchPolicies
 			if branchPoliciesData != nil {
 				if err := json.Unmarshal(branchPoliciesData, &branchPolicies); err != nil {
@@ -169,7 +170,7 @@ func testRunCmdList(owner string, repos []string, cmdFlags *cmdFlags, getter *te
 			}
 
 			// Get protection rules
-			protectionRulesData, _ := getter.GetDeploymentProtectionRules(owner, singleRepo.Name, env.Name)
+			protectionRulesData, _ := getter.GetDeploymentProtectionRules(ctx, owner, singleRepo.Name, env.Name)
 			var protectionRules data.DeploymentProtectionPolicy
 			if protectionRulesData != nil {
 				if err := json.Unmarshal(protectionRulesData, &protectionRules); err != nil {
@@ -179,7 +180,7 @@ func testRunCmdList(owner string, repos []string, cmdFlags *cmdFlags, getter *te
 			}
 
 			// Get secrets count
-			secretsData, _ := getter.GetEnvironmentSecrets(owner, singleRepo.Name, env.Name)
+			secretsData, _ := getter.GetEnvironmentSecrets(ctx, owner, singleRepo.Name, env.Name, 1)
 			var secrets data.EnvSecret
 			if secretsData != nil {
 				if err := json.Unmarshal(secretsData, &secrets); err != nil {
@@ -189,7 +190,7 @@ func testRunCmdList(owner string, repos []string, cmdFlags *cmdFlags, getter *te
 			}
 
 			// Get variables count
-			variablesData, _ := getter.GetEnvironmentVariables(owner, singleRepo.Name, env.Name)
+			variablesData, _ := getter.GetEnvironmentVariables(ctx, owner, singleRepo.Name, env.Name, 1)
 			var variables data.EnvVariables
 			if variablesData != nil {
 				if err := json.Unmarshal(variablesData, &variables); err != nil {
@@ -226,13 +227,12 @@ func testRunCmdList(owner string, repos []string, cmdFlags *cmdFlags, getter *te
 
 // TestRunCmdListWithSpecificRepository tests listing environments for a specific repository
 func TestRunCmdListWithSpecificRepository(t *testing.T) {
+	ctx := context.Background()
 	// Setup
 	owner := "testorg"
 	repos := []string{"testrepo"}
 	flags := &cmdFlags{
 		reportFile: "test-environments.csv",
-		hostname:   "github.com",
-		debug:      false,
 	}
 
 	// Create mock API getter
@@ -341,7 +341,7 @@ func TestRunCmdListWithSpecificRepository(t *testing.T) {
 	}
 
 	// Execute
-	err := testRunCmdList(owner, repos, flags, testGetter, &buf)
+	err := testRunCmdList(ctx, owner, repos, flags, testGetter, &buf)
 
 	// Verify
 	if err != nil {
@@ -383,61 +383,38 @@ func TestRunCmdListWithSpecificRepository(t *testing.T) {
 
 // TestRunCmdListWithOrganization tests listing environments for all repositories in an organization
 func TestRunCmdListWithOrganization(t *testing.T) {
+	ctx := context.Background()
 	// Setup
 	owner := "testorg"
 	repos := []string{} // Empty means all repos in org
 	flags := &cmdFlags{
 		reportFile: "test-org-environments.csv",
-		hostname:   "github.com",
-		debug:      false,
 	}
 
 	// Create mock API getter
 	mockGetter := utils.NewMockAPIGetter()
 
 	// Mock repositories response
-	mockGetter.ReposResponse = &data.ReposQuery{
-		Organization: struct {
-			Repositories struct {
-				TotalCount int
-				Nodes      []data.RepoInfo
-				PageInfo   struct {
-					EndCursor   string
-					HasNextPage bool
-				}
-			} `graphql:"repositories(first: 100, after: $endCursor)"`
-		}{
-			Repositories: struct {
-				TotalCount int
-				Nodes      []data.RepoInfo
-				PageInfo   struct {
-					EndCursor   string
-					HasNextPage bool
-				}
-			}{
-				TotalCount: 2,
-				Nodes: []data.RepoInfo{
-					{
-						DatabaseId: 12345,
-						Name:       "repo1",
-						UpdatedAt:  time.Now(),
-						Visibility: "private",
-					},
-					{
-						DatabaseId: 67890,
-						Name:       "repo2",
-						UpdatedAt:  time.Now(),
-						Visibility: "public",
-					},
-				},
-				PageInfo: struct {
-					EndCursor   string
-					HasNextPage bool
-				}{
-					EndCursor:   "",
-					HasNextPage: false,
-				},
+	mockGetter.ReposResponse = &data.ReposQuery{}
+	mockGetter.ReposResponse.Organization.Repositories = data.RepoConnection{
+		TotalCount: 2,
+		Nodes: []data.RepoInfo{
+			{
+				DatabaseId: 12345,
+				Name:       "repo1",
+				UpdatedAt:  time.Now(),
+				Visibility: "private",
 			},
+			{
+				DatabaseId: 67890,
+				Name:       "repo2",
+				UpdatedAt:  time.Now(),
+				Visibility: "public",
+			},
+		},
+		PageInfo: data.PageInfo{
+			EndCursor:   "",
+			HasNextPage: false,
 		},
 	}
 
@@ -479,7 +456,7 @@ func TestRunCmdListWithOrganization(t *testing.T) {
 	}
 
 	// Execute
-	err := testRunCmdList(owner, repos, flags, testGetter, &buf)
+	err := testRunCmdList(ctx, owner, repos, flags, testGetter, &buf)
 
 	// Verify
 	if err != nil {
@@ -0,0 +1,223 @@
+package prune
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"github.com/katiem0/gh-environments/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type cmdFlags struct {
+	delete     bool
+	yes        bool
+	repoFilter utils.RepoFilterFlags
+	envFilter  utils.EnvFilterFlags
+}
+
+// unusedEnvironment is an environment that no workflow references and that
+// has never been deployed to.
+type unusedEnvironment struct {
+	Repository  string
+	Environment string
+}
+
+// analysis is the result of checking every environment of an organization.
+type analysis struct {
+	Environments int
+	Unused       []unusedEnvironment
+	// Skipped maps repositories with workflows that could not be parsed to
+	// the number of those files. Their environments are never reported.
+	Skipped map[string]int
+}
+
+func NewCmdPrune() *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	pruneCmd := cobra.Command{
+		Use:   "prune [flags] <organization> [repo ...]",
+		Short: "Find and delete unused environments.",
+		Long:  "Find environments that are not referenced by any workflow in .github/workflows and have never been deployed to, and optionally delete them.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(pruneCmd *cobra.Command, args []string) error {
+			ctx := pruneCmd.Context()
+			var err error
+
+			if cmdFlags.yes && !cmdFlags.delete {
+				return exitcode.New(exitcode.ValidationFailure, fmt.Errorf("--yes requires --delete"))
+			}
+
+			repos, err := cmdFlags.repoFilter.Repos(args[1:])
+			if err != nil {
+				return err
+			}
+			filter, err := cmdFlags.repoFilter.Filter()
+			if err != nil {
+				return err
+			}
+			envs, err := cmdFlags.envFilter.Filter()
+			if err != nil {
+				return err
+			}
+
+			clientConfig := utils.ClientConfigFromFlags(pruneCmd.Flags())
+			clientConfig.Owner = args[0]
+			g, err := utils.NewGetter(clientConfig)
+			if err != nil {
+				return err
+			}
+
+			return runCmdPrune(ctx, args[0], repos, filter, envs, &cmdFlags, g, pruneCmd.OutOrStdout())
+		},
+	}
+
+	// Configure flags for command
+	pruneCmd.Flags().BoolVarP(&cmdFlags.delete, "delete", "", false, "Show the unused environments that would be deleted")
+	pruneCmd.Flags().BoolVarP(&cmdFlags.yes, "yes", "y", false, "Delete the environments listed by --delete")
+	cmdFlags.envFilter.AddFlags(pruneCmd.Flags())
+	cmdFlags.repoFilter.AddFlags(pruneCmd.Flags())
+
+	return &pruneCmd
+}
+
+func runCmdPrune(ctx context.Context, owner string, repos []string, filter utils.RepoFilter, envs utils.EnvFilter, cmdFlags *cmdFlags, g *utils.APIGetter, out io.Writer) error {
+	result, err := analyze(ctx, owner, repos, filter, envs, g)
+	if err != nil {
+		return err
+	}
+	if err := writeAnalysis(out, result); err != nil {
+		return err
+	}
+
+	if !cmdFlags.delete || len(result.Unused) == 0 {
+		return nil
+	}
+	p := utils.NewPrinter(out)
+	if !cmdFlags.yes {
+		p.Printf("\nRun again with --delete --yes to delete these %d environment(s).\n", len(result.Unused))
+		return p.Err()
+	}
+
+	p.Println()
+	tracker := progress.FromContext(ctx)
+	failed := 0
+	for _, env := range result.Unused {
+		zap.S().Debugf("Deleting environment %s in repo %s", env.Environment, env.Repository)
+		if err := g.DeleteEnvironment(ctx, owner, env.Repository, env.Environment); err != nil {
+			zap.S().Errorf("Error deleting environment %s in repo %s: %v", env.Environment, env.Repository, err)
+			tracker.Failed()
+			failed++
+			continue
+		}
+		tracker.Deleted()
+		p.Printf("Deleted %s/%s environment %s\n", owner, env.Repository, env.Environment)
+	}
+	if failed > 0 {
+		return exitcode.Failures(failed, len(result.Unused), fmt.Errorf("failed to delete %d environment(s)", failed))
+	}
+	return p.Err()
+}
+
+// analyze checks each environment against the workflows of its repository
+// first, and only looks up deployments of environments no workflow targets.
+func analyze(ctx context.Context, owner string, repos []string, filter utils.RepoFilter, envs utils.EnvFilter, g *utils.APIGetter) (analysis, error) {
+	result := analysis{Skipped: make(map[string]int)}
+
+	allRepos, err := utils.GatherRepos(ctx, g, owner, repos, filter)
+	if err != nil {
+		zap.S().Error("Error raised in gathering repos", zap.Error(err))
+		return result, err
+	}
+	allRepos, _, err = utils.SelectReposByProperties(ctx, g, owner, allRepos, filter.Properties)
+	if err != nil {
+		zap.S().Error("Error raised in gathering repository custom properties", zap.Error(err))
+		return result, err
+	}
+
+	for _, repo := range allRepos {
+		if err := analyzeRepo(ctx, owner, repo.Name, envs, g, &result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// analyzeRepo adds the environments of repo to result.
+func analyzeRepo(ctx context.Context, owner string, repo string, envs utils.EnvFilter, g *utils.APIGetter, result *analysis) error {
+	defer progress.FromContext(ctx).RepositoryProcessed()
+
+	names, err := utils.GatherEnvironmentNames(ctx, g, owner, repo, envs)
+	if err != nil {
+		zap.S().Error("Error raised in gathering environments", zap.Error(err))
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	result.Environments += len(names)
+
+	refs, invalid, err := utils.GatherWorkflowReferences(ctx, g, owner, repo)
+	if err != nil {
+		zap.S().Error("Error raised in gathering workflows", zap.Error(err))
+		return err
+	}
+	if len(invalid) > 0 {
+		result.Skipped[repo] = len(invalid)
+		return nil
+	}
+
+environments:
+	for _, name := range names {
+		for _, ref := range refs {
+			if ref.Matches(name) {
+				zap.S().Debugf("Environment %s in repo %s is used by %s job %s", name, repo, ref.Workflow, ref.Job)
+				continue environments
+			}
+		}
+		deployments, err := utils.GatherDeployments(ctx, g, owner, repo, name, 1)
+		if err != nil {
+			zap.S().Errorf("Error raised in gathering deployments for %s/%s", repo, name)
+			return err
+		}
+		if len(deployments) == 0 {
+			result.Unused = append(result.Unused, unusedEnvironment{Repository: repo, Environment: name})
+		}
+	}
+	return nil
+}
+
+func writeAnalysis(out io.Writer, result analysis) error {
+	p := utils.NewPrinter(out)
+	if len(result.Unused) > 0 {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		tw := utils.NewPrinter(w)
+		tw.Println("Repository\tEnvironment")
+		for _, env := range result.Unused {
+			tw.Printf("%s\t%s\n", env.Repository, env.Environment)
+		}
+		if err := tw.Err(); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		p.Println()
+	}
+	var skipped []string
+	for repo := range result.Skipped {
+		skipped = append(skipped, repo)
+	}
+	sort.Strings(skipped)
+	for _, repo := range skipped {
+		p.Printf("Skipped %s: %d workflow file(s) could not be parsed\n", repo, result.Skipped[repo])
+	}
+	p.Printf("%d of %d environment(s) are not referenced by any workflow and have never been deployed to\n",
+		len(result.Unused), result.Environments)
+	return p.Err()
+}
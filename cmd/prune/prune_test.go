@@ -0,0 +1,113 @@
+package prune
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"github.com/katiem0/gh-environments/internal/utils"
+)
+
+func TestNewCmdPrune(t *testing.T) {
+	cmd := NewCmdPrune()
+
+	if cmd.Use != "prune [flags] <organization> [repo ...]" {
+		t.Errorf("Unexpected Use %q", cmd.Use)
+	}
+	for _, flag := range []string{"delete", "yes", "env", "visibility"} {
+		if cmd.Flag(flag) == nil {
+			t.Errorf("%s flag not found", flag)
+		}
+	}
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("Expected error when no organization is given")
+	}
+
+	cmd.SetArgs([]string{"testorg", "--yes"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || err.Error() != "--yes requires --delete" {
+		t.Errorf("Expected --yes error, got %v", err)
+	}
+}
+
+func newPruneServer() *utils.MockGitHubServer {
+	server := utils.NewMockGitHubServer()
+	app := server.AddRepo(1, "app")
+	for _, name := range []string{"production", "staging", "qa", "old-preview"} {
+		app.Environments[name] = &utils.MockEnvironment{Name: name}
+	}
+	app.Workflows = map[string]string{
+		"deploy.yml": "jobs:\n  deploy:\n    environment:\n      name: Production\n",
+	}
+	app.Deployments = []utils.MockDeployment{{
+		Deployment: data.Deployment{ID: 1, Environment: "staging", CreatedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+	}}
+
+	web := server.AddRepo(2, "web")
+	web.Environments["pr-1"] = &utils.MockEnvironment{Name: "pr-1"}
+	web.Environments["unused"] = &utils.MockEnvironment{Name: "unused"}
+	web.Workflows = map[string]string{
+		"preview.yml": "jobs:\n  preview:\n    environment: pr-${{ github.event.number }}\n",
+	}
+
+	broken := server.AddRepo(3, "broken")
+	broken.Environments["legacy"] = &utils.MockEnvironment{Name: "legacy"}
+	broken.Workflows = map[string]string{"ci.yml": "jobs: ["}
+	return server
+}
+
+func TestRunCmdPrune(t *testing.T) {
+	ctx := context.Background()
+	server := newPruneServer()
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err = runCmdPrune(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, &cmdFlags{delete: true}, g, &out)
+	if err != nil {
+		t.Fatalf("runCmdPrune() error = %v", err)
+	}
+	output := out.String()
+	for _, want := range []string{
+		"app         old-preview",
+		"app         qa",
+		"web         unused",
+		"Skipped broken: 1 workflow file(s) could not be parsed",
+		"3 of 7 environment(s) are not referenced by any workflow and have never been deployed to",
+		"Run again with --delete --yes to delete these 3 environment(s).",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+	if len(server.Repos["app"].Environments) != 4 {
+		t.Error("Expected no environments to be deleted without --yes")
+	}
+
+	out.Reset()
+	tracker := progress.NewTracker()
+	err = runCmdPrune(progress.WithTracker(ctx, tracker), "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, &cmdFlags{delete: true, yes: true}, g, &out)
+	if err != nil {
+		t.Fatalf("runCmdPrune() error = %v", err)
+	}
+	// Repositories skipped for their workflows are still processed
+	if summary := tracker.Summary(nil); summary.Deleted != 3 || summary.RepositoriesScanned != 3 {
+		t.Errorf("Expected 3 deletions in 3 repositories, got %+v", summary)
+	}
+	if !strings.Contains(out.String(), "Deleted testorg/web environment unused") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+	if _, ok := server.Repos["app"].Environments["qa"]; ok {
+		t.Error("Expected qa to be deleted")
+	}
+	if len(server.Repos["app"].Environments) != 2 || len(server.Repos["web"].Environments) != 1 || len(server.Repos["broken"].Environments) != 1 {
+		t.Error("Expected only unused environments to be deleted")
+	}
+}
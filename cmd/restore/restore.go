@@ -0,0 +1,263 @@
+package restore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/katiem0/gh-environments/internal/backup"
+	"github.com/katiem0/gh-environments/internal/config"
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"github.com/katiem0/gh-environments/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type cmdFlags struct {
+	hostname      string
+	organization  string
+	pruneBranches bool
+	pruneRules    bool
+	envFilter     utils.EnvFilterFlags
+}
+
+func NewCmdRestore() *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	restoreCmd := cobra.Command{
+		Use:   "restore [flags] <archive> [repo ...]",
+		Short: "Restore environments from a backup archive.",
+		Long:  "Recreate environments, branch policies, custom deployment protection rules and variables from a backup archive, and list the secrets that must be entered again.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(restoreCmd *cobra.Command, args []string) error {
+			ctx := restoreCmd.Context()
+			var err error
+
+			archive, err := backup.Read(args[0])
+			if err != nil {
+				return err
+			}
+			envs, err := cmdFlags.envFilter.Filter()
+			if err != nil {
+				return err
+			}
+			// Restore to the organization and host of the backup by default
+			if cmdFlags.organization == "" {
+				cmdFlags.organization = archive.Manifest.Organization
+			}
+			clientConfig := utils.ClientConfigFromFlags(restoreCmd.Flags())
+			if !config.Explicit(restoreCmd.Flags(), "hostname") && archive.Manifest.Host != "" {
+				clientConfig.Hostname = archive.Manifest.Host
+			}
+			cmdFlags.hostname = clientConfig.Hostname
+			clientConfig.Owner = cmdFlags.organization
+			g, err := utils.NewGetter(clientConfig)
+			if err != nil {
+				return err
+			}
+
+			return runCmdRestore(ctx, archive, args[1:], envs, &cmdFlags, g, restoreCmd.OutOrStdout())
+		},
+	}
+
+	// Configure flags for command
+	restoreCmd.Flags().StringVar(&cmdFlags.organization, "org", "", "Organization to restore to (default: the backup's organization)")
+	restoreCmd.Flags().BoolVar(&cmdFlags.pruneBranches, "prune-branch-policies", false, "Delete existing deployment branch policies that are not in the backup")
+	restoreCmd.Flags().BoolVar(&cmdFlags.pruneRules, "prune-protection-rules", false, "Disable existing custom deployment protection rules that are not in the backup")
+	cmdFlags.envFilter.AddFlags(restoreCmd.Flags())
+
+	return &restoreCmd
+}
+
+// runCmdRestore restores the environments in archive, limited to repos when
+// any are given. Environments that fail are reported and the rest are still
+// restored.
+func runCmdRestore(ctx context.Context, archive backup.Archive, repos []string, envs utils.EnvFilter, cmdFlags *cmdFlags, g *utils.APIGetter, out io.Writer) error {
+	owner := cmdFlags.organization
+	// Reviewer IDs are only valid in the organization they were backed up from
+	resolveReviewers := !strings.EqualFold(owner, archive.Manifest.Organization) || !strings.EqualFold(cmdFlags.hostname, archive.Manifest.Host)
+
+	p := utils.NewPrinter(out)
+	tracker := progress.FromContext(ctx)
+	index := utils.NewEnvironmentIndex(g, owner)
+	var restored, failed int
+	var secrets []string
+	for _, repo := range archive.Repositories {
+		if !selected(repo.Name, repos) {
+			zap.S().Debugf("Skipping repository %s as it was not selected", repo.Name)
+			tracker.Skipped(len(repo.Environments))
+			continue
+		}
+		for _, env := range repo.Environments {
+			// Environments left once the run is interrupted are not restored
+			if ctx.Err() != nil {
+				break
+			}
+			if !envs.Match(env.Name) {
+				zap.S().Debugf("Skipping environment %s as it does not match the environment filters", env.Name)
+				tracker.Skipped(1)
+				continue
+			}
+			p.Printf("Restoring environment %s for repo %s\n", env.Name, repo.Name)
+			exists := index.Exists(ctx, env.Repository, env.Name)
+			if err := restoreEnvironment(ctx, owner, env, resolveReviewers, cmdFlags, g, p); err != nil {
+				zap.S().Errorf("Error arose restoring environment %s for repo %s: %v", env.Name, repo.Name, err)
+				tracker.Failed()
+				failed++
+				continue
+			}
+			if exists {
+				tracker.Updated()
+			} else {
+				tracker.Created()
+			}
+			restored++
+			for _, secret := range env.Secrets {
+				secrets = append(secrets, fmt.Sprintf("%s/%s/%s", repo.Name, env.Name, secret.Name))
+			}
+		}
+	}
+
+	p.Printf("Restored %d environment(s) to %s from a backup of %s taken %s\n", restored, owner, archive.Manifest.Organization, archive.Manifest.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+	if len(secrets) > 0 {
+		p.Printf("%d secret(s) must be entered again, for example with gh environments secrets create:\n", len(secrets))
+		for _, secret := range secrets {
+			p.Printf("  %s\n", secret)
+		}
+	}
+	if err := p.Err(); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return utils.InterruptedError(ctx)
+	}
+	if failed > 0 {
+		return exitcode.Failures(failed, restored+failed, fmt.Errorf("failed to restore %d environment(s)", failed))
+	}
+	return nil
+}
+
+func restoreEnvironment(ctx context.Context, owner string, env data.EnvironmentDetails, resolveReviewers bool, cmdFlags *cmdFlags, g *utils.APIGetter, p *utils.Printer) error {
+	environment := utils.ImportedEnvironment(env)
+	if resolveReviewers && len(environment.Reviewers) > 0 {
+		reviewers, err := remapReviewers(ctx, owner, environment.Reviewers, g)
+		if err != nil {
+			return err
+		}
+		environment.Reviewers = reviewers
+	}
+
+	createEnvironment, err := json.Marshal(utils.CreateEnvironmentData(environment))
+	if err != nil {
+		return err
+	}
+	zap.S().Debugf("Creating Environment %s for %s/%s", env.Name, owner, env.Repository)
+	if err := g.CreateEnvironment(ctx, owner, env.Repository, env.Name, bytes.NewReader(createEnvironment)); err != nil {
+		return err
+	}
+
+	if environment.DeploymentPolicy == "custom" {
+		summary, err := utils.SyncDeploymentBranches(ctx, owner, environment, cmdFlags.pruneBranches, g)
+		p.Println(summary)
+		if err != nil {
+			return err
+		}
+	}
+	if len(environment.ProtectionRules) > 0 || cmdFlags.pruneRules {
+		summary, err := utils.SyncProtectionRules(ctx, owner, environment, cmdFlags.pruneRules, g)
+		p.Println(summary)
+		if err != nil {
+			return err
+		}
+	}
+	if len(env.Variables) > 0 {
+		summary, err := restoreVariables(ctx, owner, env, g)
+		if err != nil {
+			return err
+		}
+		p.Println(summary)
+	}
+	return nil
+}
+
+// remapReviewers looks up the IDs of reviewers by team slug or user login in
+// owner.
+func remapReviewers(ctx context.Context, owner string, reviewers []data.Reviewers, g *utils.APIGetter) ([]data.Reviewers, error) {
+	var remapped []data.Reviewers
+	for _, reviewer := range reviewers {
+		var teams, users []string
+		if reviewer.Type == "Team" {
+			slug := reviewer.Reviewer.Slug
+			if slug == "" {
+				slug = reviewer.Reviewer.Login
+			}
+			teams = append(teams, slug)
+		} else {
+			users = append(users, reviewer.Reviewer.Login)
+		}
+		resolved, err := utils.ResolveReviewers(ctx, g, owner, teams, users)
+		if err != nil {
+			return nil, err
+		}
+		reviewer.Reviewer.ID = resolved[0].ID
+		remapped = append(remapped, reviewer)
+	}
+	return remapped, nil
+}
+
+// restoreVariables creates the variables of env that do not exist and
+// updates those whose value differs.
+func restoreVariables(ctx context.Context, owner string, env data.EnvironmentDetails, g *utils.APIGetter) (string, error) {
+	target := fmt.Sprintf("%s/%s/%s", owner, env.Repository, env.Name)
+
+	existing, err := utils.GatherEnvironmentVariables(ctx, g, owner, env.Repository, env.Name)
+	if err != nil {
+		return "", err
+	}
+	values := make(map[string]string)
+	for _, variable := range existing {
+		values[variable.Name] = variable.Value
+	}
+
+	var created, updated, unchanged int
+	for _, variable := range env.Variables {
+		payload, err := json.Marshal(data.CreateVariable{Name: variable.Name, Value: variable.Value})
+		if err != nil {
+			return "", err
+		}
+		value, ok := values[variable.Name]
+		switch {
+		case !ok:
+			zap.S().Debugf("Creating variable %s for %s", variable.Name, target)
+			err = g.CreateEnvironmentVariables(ctx, owner, env.Repository, env.Name, bytes.NewReader(payload))
+			created++
+		case value != variable.Value:
+			zap.S().Debugf("Updating variable %s for %s", variable.Name, target)
+			err = g.UpdateEnvironmentVariable(ctx, owner, env.Repository, env.Name, variable.Name, bytes.NewReader(payload))
+			updated++
+		default:
+			unchanged++
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("Variables for %s: %d created, %d updated, %d unchanged", target, created, updated, unchanged), nil
+}
+
+func selected(repo string, repos []string) bool {
+	if len(repos) == 0 {
+		return true
+	}
+	for _, name := range repos {
+		if strings.EqualFold(name, repo) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package restore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/backup"
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/utils"
+)
+
+func TestNewCmdRestore(t *testing.T) {
+	cmd := NewCmdRestore()
+
+	if cmd.Use != "restore [flags] <archive> [repo ...]" {
+		t.Errorf("Unexpected Use %q", cmd.Use)
+	}
+	for _, flag := range []string{"org", "prune-branch-policies", "prune-protection-rules", "env"} {
+		if cmd.Flag(flag) == nil {
+			t.Errorf("%s flag not found", flag)
+		}
+	}
+
+	cmd.SetArgs([]string{filepath.Join(t.TempDir(), "missing.tar.gz")})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for a missing archive")
+	}
+}
+
+func TestRunCmdRestore(t *testing.T) {
+	ctx := context.Background()
+	archive := backup.New("github.com", "testorg", "dev", []data.EnvironmentDetails{
+		{
+			Repository: "app",
+			Name:       "production",
+			WaitTimer:  10,
+			Variables:  []data.Variable{{Name: "REGION", Value: "eu"}, {Name: "TIER", Value: "gold"}, {Name: "URL", Value: "https://example.com"}},
+			Secrets:    []data.Secret{{Name: "TOKEN"}},
+		},
+		{Repository: "app", Name: "staging"},
+		{Repository: "web", Name: "production"},
+	}, time.Now())
+
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "app").Environments["production"] = &utils.MockEnvironment{
+		Name:      "production",
+		Variables: []data.Variable{{Name: "REGION", Value: "us"}, {Name: "URL", Value: "https://example.com"}},
+	}
+	server.AddRepo(2, "web")
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	flags := &cmdFlags{organization: "testorg", hostname: "github.com"}
+	err = runCmdRestore(ctx, archive, []string{"app"}, utils.EnvFilter{Globs: []string{"prod*"}}, flags, g, &out)
+	if err != nil {
+		t.Fatalf("runCmdRestore() error = %v\n%s", err, out.String())
+	}
+
+	app := server.Repos["app"]
+	if len(app.Environments) != 1 || app.Environments["production"].WaitTimer != 10 {
+		t.Errorf("Expected only app/production to be restored, got %+v", app.Environments)
+	}
+	if len(server.Repos["web"].Environments) != 0 {
+		t.Error("Expected web not to be restored")
+	}
+	variables := make(map[string]string)
+	for _, variable := range app.Environments["production"].Variables {
+		variables[variable.Name] = variable.Value
+	}
+	if variables["REGION"] != "eu" || variables["TIER"] != "gold" || len(variables) != 3 {
+		t.Errorf("Unexpected variables %v", variables)
+	}
+	for _, want := range []string{
+		"Variables for testorg/app/production: 1 created, 1 updated, 1 unchanged",
+		"Restored 1 environment(s) to testorg",
+		"1 secret(s) must be entered again",
+		"app/production/TOKEN",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunCmdRestoreFailure(t *testing.T) {
+	ctx := context.Background()
+	archive := backup.New("github.com", "testorg", "dev", []data.EnvironmentDetails{
+		{Repository: "app", Name: "production"},
+		{Repository: "app", Name: "staging"},
+	}, time.Now())
+
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "app")
+	// The API rejects the production environment only
+	server.Reject = func(req *http.Request) int {
+		if req.Method == "PUT" && strings.HasSuffix(req.URL.Path, "repos/testorg/app/environments/production") {
+			return http.StatusUnprocessableEntity
+		}
+		return 0
+	}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err = runCmdRestore(ctx, archive, nil, utils.EnvFilter{}, &cmdFlags{organization: "testorg", hostname: "github.com"}, g, &out)
+	if code := exitcode.Code(err); code != exitcode.PartialFailure {
+		t.Fatalf("Expected a partial failure, got %v", err)
+	}
+	if _, ok := server.Repos["app"].Environments["staging"]; !ok {
+		t.Error("Expected staging to be restored after production failed")
+	}
+	if !strings.Contains(out.String(), "Restored 1 environment(s) to testorg") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+}
+
+func TestRunCmdRestoreInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	archive := backup.New("github.com", "testorg", "dev", []data.EnvironmentDetails{
+		{Repository: "app", Name: "production"},
+		{Repository: "app", Name: "staging"},
+	}, time.Now())
+
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "app")
+	// The run is interrupted while production is restored
+	server.BeforeRequest = func(req *http.Request) {
+		if req.Method == "PUT" && strings.HasSuffix(req.URL.Path, "repos/testorg/app/environments/production") {
+			cancel()
+		}
+	}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err = runCmdRestore(ctx, archive, nil, utils.EnvFilter{}, &cmdFlags{organization: "testorg", hostname: "github.com"}, g, &out)
+	if code := exitcode.Code(err); code != exitcode.PartialFailure || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected a partial failure for the interrupt, got %v", err)
+	}
+	if _, ok := server.Repos["app"].Environments["staging"]; ok {
+		t.Error("Expected staging not to be restored after the interrupt")
+	}
+}
+
+func TestRestoreVariablesPaged(t *testing.T) {
+	ctx := context.Background()
+	// More variables exist than fit in a page, and all of them are restored
+	var variables []data.Variable
+	for i := 0; i < 40; i++ {
+		variables = append(variables, data.Variable{Name: fmt.Sprintf("VAR_%d", i), Value: "value"})
+	}
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "app").Environments["production"] = &utils.MockEnvironment{
+		Name:      "production",
+		Variables: append([]data.Variable(nil), variables...),
+	}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := data.EnvironmentDetails{Repository: "app", Name: "production", Variables: variables}
+	summary, err := restoreVariables(ctx, "testorg", env, g)
+	if err != nil {
+		t.Fatalf("restoreVariables() error = %v", err)
+	}
+	if summary != "Variables for testorg/app/production: 0 created, 0 updated, 40 unchanged" {
+		t.Errorf("Unexpected summary %q", summary)
+	}
+}
+
+func TestRemapReviewersUnknown(t *testing.T) {
+	ctx := context.Background()
+	server := utils.NewMockGitHubServer()
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reviewers := []data.Reviewers{{Type: "Team", Reviewer: data.Reviewer{Slug: "missing", ID: 1}}}
+	if _, err := remapReviewers(ctx, "neworg", reviewers, g); err == nil {
+		t.Error("Expected error for a team that does not exist")
+	}
+}
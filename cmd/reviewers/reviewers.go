@@ -0,0 +1,134 @@
+package reviewers
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type cmdFlags struct {
+	reportFile string
+	repoFilter utils.RepoFilterFlags
+	envFilter  utils.EnvFilterFlags
+}
+
+func NewCmdReviewers() *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	reviewersCmd := cobra.Command{
+		Use:   "reviewers [flags] <organization> [repo ...]",
+		Short: "Generate a report of who can approve deployments.",
+		Long:  "Generate a report of the users who can approve deployments to each environment, expanding reviewer teams to their members, and list environments whose reviewer teams are empty or whose reviewers are all suspended or deleted.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(reviewersCmd *cobra.Command, args []string) error {
+			ctx := reviewersCmd.Context()
+			var err error
+
+			repos, err := cmdFlags.repoFilter.Repos(args[1:])
+			if err != nil {
+				return err
+			}
+			filter, err := cmdFlags.repoFilter.Filter()
+			if err != nil {
+				return err
+			}
+			envs, err := cmdFlags.envFilter.Filter()
+			if err != nil {
+				return err
+			}
+
+			clientConfig := utils.ClientConfigFromFlags(reviewersCmd.Flags())
+			clientConfig.Owner = args[0]
+			g, err := utils.NewGetter(clientConfig)
+			if err != nil {
+				return err
+			}
+
+			reportWriter, err := os.Create(cmdFlags.reportFile)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if closeErr := reportWriter.Close(); closeErr != nil {
+					zap.S().Warnf("Error closing file: %v", closeErr)
+				}
+			}()
+
+			return runCmdReviewers(ctx, args[0], repos, filter, envs, &cmdFlags, g, reportWriter, reviewersCmd.OutOrStdout())
+		},
+	}
+
+	// Determine default report file based on current timestamp; for more info see https://pkg.go.dev/time#pkg-constants
+	reportFileDefault := fmt.Sprintf("report-reviewers-%s.csv", time.Now().Format("20060102150405"))
+
+	// Configure flags for command
+	reviewersCmd.Flags().StringVarP(&cmdFlags.reportFile, "output-file", "o", reportFileDefault, "Name of file to write CSV report")
+	cmdFlags.envFilter.AddFlags(reviewersCmd.Flags())
+	cmdFlags.repoFilter.AddFlags(reviewersCmd.Flags())
+
+	return &reviewersCmd
+}
+
+func runCmdReviewers(ctx context.Context, owner string, repos []string, filter utils.RepoFilter, envs utils.EnvFilter, cmdFlags *cmdFlags, g *utils.APIGetter, reportWriter io.Writer, out io.Writer) error {
+	csvWriter := csv.NewWriter(reportWriter)
+	if err := csvWriter.Write(utils.ReviewerReportColumns); err != nil {
+		return err
+	}
+
+	environments, err := utils.GatherOrgEnvironments(ctx, g, owner, repos, filter, envs, false)
+	if err != nil {
+		if ctx.Err() != nil {
+			return utils.WritePartialReport(ctx, csvWriter, cmdFlags.reportFile)
+		}
+		return err
+	}
+	// The grants audited before an interrupt are still written
+	audit, auditErr := utils.AuditReviewers(ctx, g, owner, environments)
+	if auditErr != nil && ctx.Err() == nil {
+		zap.S().Error("Error raised in gathering reviewers", zap.Error(auditErr))
+		return auditErr
+	}
+
+	users := make(map[string]bool)
+	protected := make(map[string]bool)
+	for _, grant := range audit.Grants {
+		if err := csvWriter.Write(grant.Row()); err != nil {
+			return err
+		}
+		if grant.Status == utils.UserActive {
+			users[grant.User] = true
+			protected[grant.Repository+"/"+grant.Environment] = true
+		}
+	}
+	if auditErr != nil {
+		return utils.WritePartialReport(ctx, csvWriter, cmdFlags.reportFile)
+	}
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Successfully exported reviewer data to csv file: %s\n", cmdFlags.reportFile)
+
+	if len(audit.Findings) > 0 {
+		fmt.Fprintln(out)
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Repository\tEnvironment\tFinding")
+		for _, finding := range audit.Findings {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", finding.Repository, finding.Environment, finding.Message)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(out)
+	}
+	_, err = fmt.Fprintf(out, "%d active user(s) can approve deployments to %d environment(s), %d finding(s)\n", len(users), len(protected), len(audit.Findings))
+	return err
+}
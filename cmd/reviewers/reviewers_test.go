@@ -0,0 +1,70 @@
+package reviewers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/utils"
+)
+
+func TestNewCmdReviewers(t *testing.T) {
+	cmd := NewCmdReviewers()
+
+	if cmd.Use != "reviewers [flags] <organization> [repo ...]" {
+		t.Errorf("Unexpected Use %q", cmd.Use)
+	}
+	for _, flag := range []string{"output-file", "env", "visibility"} {
+		if cmd.Flag(flag) == nil {
+			t.Errorf("%s flag not found", flag)
+		}
+	}
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("Expected error when no organization is given")
+	}
+}
+
+func TestRunCmdReviewers(t *testing.T) {
+	ctx := context.Background()
+	server := utils.NewMockGitHubServer()
+	server.TeamMembers = map[string][]string{"release-managers": {"hubot"}, "empty": {}}
+	app := server.AddRepo(1, "app")
+	app.Environments["production"] = &utils.MockEnvironment{
+		Name:      "production",
+		Reviewers: []data.Reviewers{{Type: "Team", Reviewer: data.Reviewer{ID: 2, Login: "release-managers", Slug: "release-managers"}}},
+	}
+	app.Environments["staging"] = &utils.MockEnvironment{
+		Name:      "staging",
+		Reviewers: []data.Reviewers{{Type: "Team", Reviewer: data.Reviewer{ID: 3, Login: "empty", Slug: "empty"}}},
+	}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report, out bytes.Buffer
+	err = runCmdReviewers(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, &cmdFlags{reportFile: "report.csv"}, g, &report, &out)
+	if err != nil {
+		t.Fatalf("runCmdReviewers() error = %v", err)
+	}
+
+	records, err := csv.NewReader(&report).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || strings.Join(records[1], ",") != "hubot,app,production,Team:release-managers,active" {
+		t.Errorf("Unexpected report %v", records)
+	}
+	for _, want := range []string{
+		"app         staging      reviewer team empty has no members",
+		"app         staging      no active user can approve deployments",
+		"1 active user(s) can approve deployments to 1 environment(s), 2 finding(s)",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
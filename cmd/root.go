@@ -1,26 +1,102 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/term"
+	approvalsCmd "github.com/katiem0/gh-environments/cmd/approvals"
+	appsCmd "github.com/katiem0/gh-environments/cmd/apps"
+	backupCmd "github.com/katiem0/gh-environments/cmd/backup"
+	configCmd "github.com/katiem0/gh-environments/cmd/config"
 	createCmd "github.com/katiem0/gh-environments/cmd/create"
+	deploymentsCmd "github.com/katiem0/gh-environments/cmd/deployments"
+	driftCmd "github.com/katiem0/gh-environments/cmd/drift"
+	lintCmd "github.com/katiem0/gh-environments/cmd/lint"
 	listCmd "github.com/katiem0/gh-environments/cmd/list"
+	pruneCmd "github.com/katiem0/gh-environments/cmd/prune"
+	restoreCmd "github.com/katiem0/gh-environments/cmd/restore"
+	reviewersCmd "github.com/katiem0/gh-environments/cmd/reviewers"
 	secretsCmd "github.com/katiem0/gh-environments/cmd/secrets"
+	snapshotCmd "github.com/katiem0/gh-environments/cmd/snapshot"
+	validateCmd "github.com/katiem0/gh-environments/cmd/validate"
 	variablesCmd "github.com/katiem0/gh-environments/cmd/variables"
+	workflowsCmd "github.com/katiem0/gh-environments/cmd/workflows"
+	"github.com/katiem0/gh-environments/internal/config"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/log"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"github.com/katiem0/gh-environments/internal/utils"
 	"github.com/spf13/cobra"
+	"go.uber.org/zap"
 )
 
 func NewCmdRoot() *cobra.Command {
+	// cancel releases the deadline set by --timeout
+	cancel := context.CancelFunc(func() {})
 
 	cmdRoot := &cobra.Command{
 		Use:   "environments <command> <subcommand> [flags]",
 		Short: "List and create repo environments and metadata.",
 		Long:  "List and create repo environments and metadata, including listing and creating environment secrets and variables.",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Reinitialize logging if debugging was enabled
+			if debug, _ := cmd.Flags().GetBool("debug"); debug {
+				logger, _ := log.NewLogger(debug)
+				zap.ReplaceGlobals(logger)
+			}
+
+			if timeout, _ := cmd.Flags().GetDuration("timeout"); timeout > 0 {
+				var ctx context.Context
+				ctx, cancel = context.WithTimeout(cmd.Context(), timeout)
+				cmd.SetContext(ctx)
+			}
+
+			selection, err := selectProfile(cmd)
+			if err != nil {
+				return err
+			}
+			if selection.Name != "" {
+				zap.S().Debugf("Using profile %s from %s", selection.Name, strings.Join(selection.Paths, ", "))
+			}
+			return selection.Profile.Apply(cmd.Flags())
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			cancel()
+			_ = zap.L().Sync()
+		},
 	}
 	cmdRoot.PersistentFlags().Bool("help", false, "Show help for command")
+	cmdRoot.PersistentFlags().String("profile", "", "Configuration profile to use (default: the default_profile of the configuration files)")
+	cmdRoot.PersistentFlags().BoolP("quiet", "q", false, "Do not show progress or the summary")
+	cmdRoot.PersistentFlags().String("summary-json", "", "Write the summary as JSON to this file, or to stdout without a value, even with --quiet")
+	cmdRoot.PersistentFlags().Lookup("summary-json").NoOptDefVal = "-"
+	cmdRoot.PersistentFlags().Duration("timeout", 0, "Time limit for the whole run, such as 10m, after which partial output is written (default: no limit)")
+	utils.AddClientFlags(cmdRoot.PersistentFlags())
 
 	cmdRoot.AddCommand(listCmd.NewCmdList())
 	cmdRoot.AddCommand(createCmd.NewCmdCreate())
 	cmdRoot.AddCommand(secretsCmd.NewCmdSecrets())
 	cmdRoot.AddCommand(variablesCmd.NewCmdVariables())
+	cmdRoot.AddCommand(validateCmd.NewCmdValidate())
+	cmdRoot.AddCommand(appsCmd.NewCmdApps())
+	cmdRoot.AddCommand(lintCmd.NewCmdLint())
+	cmdRoot.AddCommand(snapshotCmd.NewCmdSnapshot())
+	cmdRoot.AddCommand(driftCmd.NewCmdDrift())
+	cmdRoot.AddCommand(backupCmd.NewCmdBackup())
+	cmdRoot.AddCommand(restoreCmd.NewCmdRestore())
+	cmdRoot.AddCommand(deploymentsCmd.NewCmdDeployments())
+	cmdRoot.AddCommand(pruneCmd.NewCmdPrune())
+	cmdRoot.AddCommand(workflowsCmd.NewCmdWorkflows())
+	cmdRoot.AddCommand(approvalsCmd.NewCmdApprovals())
+	cmdRoot.AddCommand(reviewersCmd.NewCmdReviewers())
+	cmdRoot.AddCommand(configCmd.NewCmdConfig())
+	defaultOrganization(cmdRoot)
+	trackRun(cmdRoot)
+	validationErrors(cmdRoot)
 	cmdRoot.CompletionOptions.DisableDefaultCmd = true
 	cmdRoot.SetHelpCommand(&cobra.Command{
 		Use:    "no-help",
@@ -28,3 +104,130 @@ func NewCmdRoot() *cobra.Command {
 	})
 	return cmdRoot
 }
+
+func selectProfile(cmd *cobra.Command) (config.Selection, error) {
+	name, _ := cmd.Flags().GetString("profile")
+	return config.Select(name)
+}
+
+// defaultOrganization lets the commands under cmd that take an organization
+// as their first argument be run without one, using the org of the selected
+// profile instead.
+func defaultOrganization(cmd *cobra.Command) {
+	for _, sub := range cmd.Commands() {
+		defaultOrganization(sub)
+	}
+	if cmd.RunE == nil || !strings.Contains(cmd.Use, "organization>") {
+		return
+	}
+
+	withOrg := func(cmd *cobra.Command, args []string) ([]string, error) {
+		if len(args) > 0 {
+			return args, nil
+		}
+		// An enterprise scan names no organization
+		if enterprise := cmd.Flags().Lookup("enterprise"); enterprise != nil && enterprise.Value.String() != "" {
+			return args, nil
+		}
+		selection, err := selectProfile(cmd)
+		if err != nil || selection.Profile.Org == "" {
+			return args, err
+		}
+		return []string{selection.Profile.Org}, nil
+	}
+	validate, run := cmd.Args, cmd.RunE
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		args, err := withOrg(cmd, args)
+		if err != nil || validate == nil {
+			return err
+		}
+		return validate(cmd, args)
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		args, err := withOrg(cmd, args)
+		if err != nil {
+			return err
+		}
+		return run(cmd, args)
+	}
+}
+
+// trackRun shows the progress of the commands under cmd on stderr while they
+// run, and their summary once they end, unless --quiet is set. The tracker is
+// carried by the command's context to the code gathering data and the
+// transport counting requests.
+func trackRun(cmd *cobra.Command) {
+	for _, sub := range cmd.Commands() {
+		trackRun(sub)
+	}
+	if cmd.RunE == nil {
+		return
+	}
+
+	run := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		tracker := progress.NewTracker()
+		cmd.SetContext(progress.WithTracker(cmd.Context(), tracker))
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		out := cmd.ErrOrStderr()
+
+		var display *progress.Display
+		if !quiet {
+			file, ok := out.(*os.File)
+			display = progress.Start(tracker, out, ok && term.IsTerminal(file))
+		}
+		err := run(cmd, args)
+		display.Stop()
+
+		summary := tracker.Summary(err)
+		var writeErr error
+		if summaryJSON, _ := cmd.Flags().GetString("summary-json"); summaryJSON != "" {
+			writeErr = writeSummaryJSON(summary, summaryJSON, cmd.OutOrStdout())
+		} else if !quiet && !summary.Empty() {
+			writeErr = summary.WriteText(out)
+		}
+		if writeErr != nil {
+			zap.S().Errorf("Error writing summary: %v", writeErr)
+			if err == nil {
+				return fmt.Errorf("writing summary: %w", writeErr)
+			}
+		}
+		return err
+	}
+}
+
+// writeSummaryJSON writes summary to fileName, or to stdout when fileName is
+// "-", keeping it apart from the progress and logs written to stderr.
+func writeSummaryJSON(summary progress.Summary, fileName string, stdout io.Writer) error {
+	if fileName == "-" {
+		return summary.WriteJSON(stdout)
+	}
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil {
+			zap.S().Warnf("Error closing file: %v", closeErr)
+		}
+	}()
+	return summary.WriteJSON(f)
+}
+
+// validationErrors makes errors in the flags and arguments of the commands
+// under cmd exit with exitcode.ValidationFailure.
+func validationErrors(cmd *cobra.Command) {
+	for _, sub := range cmd.Commands() {
+		validationErrors(sub)
+	}
+	cmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return exitcode.New(exitcode.ValidationFailure, err)
+	})
+	if cmd.Args == nil {
+		return
+	}
+	validate := cmd.Args
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		return exitcode.New(exitcode.ValidationFailure, validate(cmd, args))
+	}
+}
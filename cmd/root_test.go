@@ -23,6 +23,18 @@ func TestNewCmdRoot(t *testing.T) {
 		t.Errorf("Expected at least 2 subcommands, got %d", len(subcommands))
 	}
 
+	// Test that connection flags are shared by every command
+	for _, flag := range []string{"token", "hostname", "debug", "ca-bundle", "proxy", "request-timeout", "timeout", "quiet", "summary-json", "user-agent", "app-id"} {
+		if cmd.PersistentFlags().Lookup(flag) == nil {
+			t.Errorf("%s persistent flag not found", flag)
+		}
+	}
+	for _, sub := range subcommands {
+		if sub.Runnable() && sub.InheritedFlags().Lookup("token") == nil {
+			t.Errorf("%s does not inherit the token flag", sub.Name())
+		}
+	}
+
 	// Test completion options
 	if !cmd.CompletionOptions.DisableDefaultCmd {
 		t.Error("Default completion command should be disabled")
@@ -0,0 +1,551 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"github.com/katiem0/gh-environments/internal/utils"
+)
+
+// environmentState is the part of an environment that create is expected to
+// reproduce, without server-assigned IDs or read-only data.
+type environmentState struct {
+	AdminBypass       bool
+	WaitTimer         int
+	PreventSelfReview bool
+	Reviewers         []string
+	DeploymentPolicy  string
+	Branches          []string
+	ProtectionRules   []string
+}
+
+func normalizeServer(server *utils.MockGitHubServer) map[string]environmentState {
+	state := make(map[string]environmentState)
+	for repoName, repo := range server.Repos {
+		for envName, env := range repo.Environments {
+			s := environmentState{
+				AdminBypass:       env.CanAdminsBypass,
+				WaitTimer:         env.WaitTimer,
+				PreventSelfReview: env.PreventSelfReview,
+			}
+			for _, reviewer := range env.Reviewers {
+				s.Reviewers = append(s.Reviewers, reviewer.Type+";"+reviewer.Reviewer.Login)
+			}
+			if env.DeploymentPolicy != nil {
+				if env.DeploymentPolicy.CustomPolicies {
+					s.DeploymentPolicy = "custom"
+				} else if env.DeploymentPolicy.ProtectedBranches {
+					s.DeploymentPolicy = "protected"
+				}
+			}
+			for _, branch := range env.BranchPolicies {
+				s.Branches = append(s.Branches, branch.Name+";"+branch.Type)
+			}
+			for _, rule := range env.ProtectionRules {
+				s.ProtectionRules = append(s.ProtectionRules, rule.App.Slug)
+			}
+			sort.Strings(s.Reviewers)
+			sort.Strings(s.Branches)
+			sort.Strings(s.ProtectionRules)
+			state[repoName+"/"+envName] = s
+		}
+	}
+	return state
+}
+
+func newRoundTripServer(appID int) *utils.MockGitHubServer {
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "app")
+	server.AddRepo(2, "api")
+	server.Apps = []data.AvailableDeploymentApp{{ID: appID, Slug: "deploy-gate"}}
+	server.Accounts = []data.Reviewers{
+		{Type: "User", Reviewer: data.Reviewer{ID: 1, Login: "octocat"}},
+		{Type: "Team", Reviewer: data.Reviewer{ID: 2, Login: "release-managers"}},
+	}
+	return server
+}
+
+func runRoot(t *testing.T, server *utils.MockGitHubServer, args ...string) {
+	t.Helper()
+	originalTransport := http.DefaultTransport
+	http.DefaultTransport = server
+	defer func() { http.DefaultTransport = originalTransport }()
+
+	cmd := NewCmdRoot()
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("%v failed: %v", args, err)
+	}
+}
+
+func TestListCreateRoundTrip(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	source := newRoundTripServer(5)
+	source.Repos["app"].Environments["production"] = &utils.MockEnvironment{
+		Name:              "production",
+		CanAdminsBypass:   false,
+		WaitTimer:         30,
+		PreventSelfReview: true,
+		Reviewers: []data.Reviewers{
+			{Type: "User", Reviewer: data.Reviewer{ID: 1, Login: "octocat"}},
+			{Type: "Team", Reviewer: data.Reviewer{ID: 2, Login: "release-managers"}},
+		},
+		DeploymentPolicy: &data.DeploymentPolicy{CustomPolicies: true},
+		BranchPolicies: []data.BranchPolicy{
+			{ID: 11, Name: "main", Type: "branch"},
+			{ID: 12, Name: "v*", Type: "tag"},
+		},
+		ProtectionRules: []data.DeploymentProtectionPolicyApp{
+			{PolicyID: 21, Enabled: true, App: data.DeploymentApp{IntegrationID: 5, Slug: "deploy-gate"}},
+		},
+		Secrets:   []data.Secret{{Name: "TOKEN"}},
+		Variables: []data.Variable{{Name: "REGION", Value: "us-east-1"}},
+	}
+	source.Repos["app"].Environments["staging"] = &utils.MockEnvironment{
+		Name:             "staging",
+		CanAdminsBypass:  true,
+		DeploymentPolicy: &data.DeploymentPolicy{ProtectedBranches: true},
+	}
+	source.Repos["api"].Environments["dev"] = &utils.MockEnvironment{
+		Name:            "dev",
+		CanAdminsBypass: false,
+		WaitTimer:       5,
+	}
+
+	reportFile := filepath.Join(t.TempDir(), "environments.csv")
+	runRoot(t, source, "list", "testorg", "-o", reportFile, "--token", "test-token")
+
+	// The target instance assigns a different ID to the same app
+	target := newRoundTripServer(9)
+	runRoot(t, target, "create", "testorg", "-f", reportFile, "--token", "test-token")
+
+	want := normalizeServer(source)
+	got := normalizeServer(target)
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 environments to be created, got %d", len(got))
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Round trip mismatch\nwant: %+v\ngot:  %+v", want, got)
+	}
+
+	// Applying the same file again must leave the environments unchanged
+	runRoot(t, target, "create", "testorg", "-f", reportFile, "--token", "test-token")
+	if again := normalizeServer(target); !reflect.DeepEqual(want, again) {
+		t.Errorf("Second import changed state\nwant: %+v\ngot:  %+v", want, again)
+	}
+}
+
+func TestEnvironmentReportColumnsImported(t *testing.T) {
+	// Every column that is not read-only must be read by create
+	header := utils.ReportHeaders(utils.EnvironmentReportColumns)
+	row := []string{"app", "1", "production", "false", "30", "User;octocat;1", "true", "custom", "main;branch", "21;true;5;deploy-gate", "1", "1"}
+
+	g := &utils.APIGetter{}
+	baseline, err := g.CreateEnvironmentList([][]string{header, row})
+	if err != nil {
+		t.Fatalf("CreateEnvironmentList() error = %v", err)
+	}
+
+	for i, column := range utils.EnvironmentReportColumns {
+		cleared := append([]string{}, row...)
+		cleared[i] = ""
+		result, err := g.CreateEnvironmentList([][]string{header, cleared})
+		if err != nil {
+			t.Fatalf("CreateEnvironmentList() error = %v", err)
+		}
+		changed := !reflect.DeepEqual(utils.CreateEnvironmentData(baseline[0]), utils.CreateEnvironmentData(result[0])) ||
+			baseline[0].RepositoryName != result[0].RepositoryName ||
+			baseline[0].EnvironmentName != result[0].EnvironmentName ||
+			!reflect.DeepEqual(baseline[0].Branches, result[0].Branches) ||
+			!reflect.DeepEqual(baseline[0].ProtectionRules, result[0].ProtectionRules)
+		if column.ReadOnly && changed {
+			t.Errorf("Read-only column %s affected the imported environment", column.Name)
+		}
+		if !column.ReadOnly && !changed {
+			t.Errorf("Column %s is not read-only but is ignored by create", column.Name)
+		}
+	}
+}
+
+func TestListPropertySelectors(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	server := newRoundTripServer(5)
+	server.Repos["app"].Properties = map[string]interface{}{"team": "payments", "tier": "1"}
+	server.Repos["api"].Properties = map[string]interface{}{"team": "core"}
+	server.Repos["app"].Environments["production"] = &utils.MockEnvironment{Name: "production"}
+	server.Repos["api"].Environments["production"] = &utils.MockEnvironment{Name: "production"}
+
+	reportFile := filepath.Join(t.TempDir(), "environments.csv")
+	runRoot(t, server, "list", "testorg", "-o", reportFile, "--token", "test-token", "--property", "team=payments", "--property", "tier=1")
+
+	report, err := utils.ReadCSVFile(reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 2 {
+		t.Fatalf("Expected header and one environment, got %v", report)
+	}
+	header := utils.NewCSVHeader(report[0])
+	if header.Value(report[1], "RepositoryName") != "app" {
+		t.Errorf("Expected only app to be listed, got %v", report[1])
+	}
+	if header.Value(report[1], "team") != "payments" || header.Value(report[1], "tier") != "1" {
+		t.Errorf("Expected property columns in report, got %v", report)
+	}
+}
+
+func TestListEnterprise(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	server := newRoundTripServer(5)
+	server.Organizations = []string{"org1", "org2"}
+	server.Repos["app"].Environments["production"] = &utils.MockEnvironment{
+		Name:      "production",
+		Secrets:   []data.Secret{{Name: "TOKEN"}},
+		Variables: []data.Variable{{Name: "REGION", Value: "us-east-1"}},
+	}
+
+	for _, command := range [][]string{{"list"}, {"secrets", "list"}, {"variables", "list"}} {
+		reportFile := filepath.Join(t.TempDir(), "report.csv")
+		runRoot(t, server, append(command, "--enterprise", "acme", "-o", reportFile, "--token", "test-token")...)
+
+		report, err := utils.ReadCSVFile(reportFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report) != 3 || report[0][0] != "Organization" {
+			t.Fatalf("%v: expected Organization column and one row per organization, got %v", command, report)
+		}
+		if report[1][0] != "org1" || report[2][0] != "org2" {
+			t.Errorf("%v: expected rows for org1 and org2, got %v", command, report)
+		}
+	}
+
+	cmd := NewCmdRoot()
+	cmd.SetArgs([]string{"list", "testorg", "--enterprise", "acme"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error combining an organization with --enterprise")
+	}
+}
+
+func TestEnvironmentFilters(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	source := newRoundTripServer(5)
+	for _, name := range []string{"production", "production-eu", "staging"} {
+		source.Repos["app"].Environments[name] = &utils.MockEnvironment{Name: name, WaitTimer: 10}
+	}
+
+	reportFile := filepath.Join(t.TempDir(), "environments.csv")
+	runRoot(t, source, "list", "testorg", "-o", reportFile, "--token", "test-token", "--env", "production*")
+	report, err := utils.ReadCSVFile(reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 3 {
+		t.Fatalf("Expected the two production environments to be listed, got %v", report)
+	}
+
+	target := newRoundTripServer(5)
+	runRoot(t, target, "create", "testorg", "-f", reportFile, "--token", "test-token", "--env-regex", "-eu$")
+	envs := target.Repos["app"].Environments
+	if len(envs) != 1 || envs["production-eu"] == nil {
+		t.Errorf("Expected only production-eu to be created, got %v", envs)
+	}
+}
+
+func TestListProfile(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("TEST_TOKEN", "test-token")
+
+	if err := os.MkdirAll(filepath.Join(configHome, "gh-environments"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	userConfig := `
+profiles:
+  work:
+    org: testorg
+    token: env:TEST_TOKEN
+    filters:
+      env: ["production*"]
+`
+	if err := os.WriteFile(filepath.Join(configHome, "gh-environments", "config.yml"), []byte(userConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// The repository file selects the profile by default
+	repoDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repoDir, ".gh-environments.yml"), []byte("default_profile: work\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(repoDir)
+
+	source := newRoundTripServer(5)
+	for _, name := range []string{"production", "production-eu", "staging"} {
+		source.Repos["app"].Environments[name] = &utils.MockEnvironment{Name: name, WaitTimer: 10}
+	}
+
+	reportFile := filepath.Join(t.TempDir(), "environments.csv")
+	runRoot(t, source, "list", "-o", reportFile)
+	report, err := utils.ReadCSVFile(reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 3 {
+		t.Fatalf("Expected the profile's environment filter to list the two production environments, got %v", report)
+	}
+
+	// Flags override the profile
+	stagingFile := filepath.Join(t.TempDir(), "staging.csv")
+	runRoot(t, source, "list", "testorg", "-o", stagingFile, "--env", "staging")
+	report, err = utils.ReadCSVFile(stagingFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 2 || report[1][2] != "staging" {
+		t.Errorf("Expected --env to override the profile, got %v", report)
+	}
+
+	cmd := NewCmdRoot()
+	cmd.SetArgs([]string{"list", "--profile", "missing"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected error for an unknown profile")
+	}
+}
+
+func TestDriftProfileHostname(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("TEST_TOKEN", "test-token")
+	t.Chdir(t.TempDir())
+
+	if err := os.MkdirAll(filepath.Join(configHome, "gh-environments"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	userConfig := "profiles:\n  default:\n    hostname: ghes.example.com\n    token: env:TEST_TOKEN\n"
+	if err := os.WriteFile(filepath.Join(configHome, "gh-environments", "config.yml"), []byte(userConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	source := newRoundTripServer(5)
+	source.Repos["app"].Environments["production"] = &utils.MockEnvironment{Name: "production"}
+	var hosts []string
+	source.BeforeRequest = func(req *http.Request) {
+		hosts = append(hosts, req.URL.Host)
+	}
+	snapshotFile := filepath.Join(t.TempDir(), "snapshot.json")
+	runRoot(t, source, "snapshot", "testorg", "-o", snapshotFile, "--hostname", "github.com")
+
+	// The host of the snapshot takes precedence over the one of the profile
+	hosts = nil
+	runRoot(t, source, "drift", "--since", snapshotFile, "-o", filepath.Join(t.TempDir(), "drift.txt"))
+	if len(hosts) == 0 {
+		t.Fatal("Expected drift to send requests")
+	}
+	for _, host := range hosts {
+		if host != "api.github.com" {
+			t.Errorf("Expected requests to the host of the snapshot, got %s", host)
+		}
+	}
+}
+
+func TestListTimeout(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	server := newRoundTripServer(5)
+	server.Repos["api"].Environments["production"] = &utils.MockEnvironment{Name: "production"}
+	server.Repos["app"].Environments["production"] = &utils.MockEnvironment{Name: "production"}
+	// The environments of app are only read once the run has timed out
+	server.BeforeRequest = func(req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "repos/testorg/app/environments") {
+			<-req.Context().Done()
+		}
+	}
+	originalTransport := http.DefaultTransport
+	http.DefaultTransport = server
+	defer func() { http.DefaultTransport = originalTransport }()
+
+	reportFile := filepath.Join(t.TempDir(), "environments.csv")
+	cmd := NewCmdRoot()
+	cmd.SetArgs([]string{"list", "testorg", "--token", "test-token", "-o", reportFile, "--timeout", "200ms"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "run timed out, partial output written to "+reportFile) {
+		t.Fatalf("Expected a partial output error, got %v", err)
+	}
+	if code := exitcode.Code(err); code != exitcode.PartialFailure {
+		t.Errorf("Expected exit code %d for a partial report, got %d", exitcode.PartialFailure, code)
+	}
+
+	report, err := utils.ReadCSVFile(reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 2 || report[1][0] != "api" {
+		t.Fatalf("Expected the header and the api environment, got %v", report)
+	}
+	// The rows of a partial report can still be imported
+	if validationErrors := utils.ValidateEnvironmentList(report); len(validationErrors) != 0 {
+		t.Errorf("Expected the partial report to be valid, got %v", validationErrors)
+	}
+}
+
+// captureStdout returns what fn writes to os.Stdout, including writes that do
+// not go through the output of a command.
+func captureStdout(t *testing.T, fn func()) []byte {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = original }()
+
+	output := make(chan []byte)
+	go func() {
+		content, _ := io.ReadAll(r)
+		output <- content
+	}()
+	fn()
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return <-output
+}
+
+func TestRunSummary(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	source := newRoundTripServer(5)
+	source.Repos["app"].Environments["production"] = &utils.MockEnvironment{Name: "production", WaitTimer: 10}
+	source.Repos["app"].Environments["staging"] = &utils.MockEnvironment{Name: "staging"}
+	reportFile := filepath.Join(t.TempDir(), "environments.csv")
+	runRoot(t, source, "list", "testorg", "-o", reportFile, "--token", "test-token")
+
+	target := newRoundTripServer(5)
+	target.Repos["app"].Environments["production"] = &utils.MockEnvironment{Name: "production"}
+	originalTransport := http.DefaultTransport
+	http.DefaultTransport = target
+	defer func() { http.DefaultTransport = originalTransport }()
+
+	// The JSON summary is the only thing written to stdout, apart from the
+	// progress and messages written to stderr
+	cmd := NewCmdRoot()
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{"create", "testorg", "-f", reportFile, "--token", "test-token", "--env", "production", "--summary-json"})
+	var err error
+	stdout := captureStdout(t, func() { err = cmd.Execute() })
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	var summary progress.Summary
+	if err := json.Unmarshal(stdout, &summary); err != nil {
+		t.Fatalf("Expected the summary as JSON, got %q: %v", stdout, err)
+	}
+	if summary.Updated != 1 || summary.Created != 0 || summary.Skipped != 1 || summary.Failed != 0 {
+		t.Errorf("Expected production to be updated and staging skipped, got %+v", summary)
+	}
+	if summary.Status != "success" || summary.ExitCode != exitcode.Success || summary.APICalls == 0 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+
+	summaryFile := filepath.Join(t.TempDir(), "summary.json")
+	runRoot(t, target, "create", "testorg", "-f", reportFile, "--token", "test-token", "--summary-json="+summaryFile)
+	content, err := os.ReadFile(summaryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary = progress.Summary{}
+	if err := json.Unmarshal(content, &summary); err != nil || summary.Updated != 1 || summary.Created != 1 {
+		t.Errorf("Expected the summary in %s, got %q: %v", summaryFile, content, err)
+	}
+
+	for _, args := range [][]string{
+		{"list", "testorg", "--no-such-flag"},
+		{"list"},
+		{"list", "testorg", "--env-regex", "("},
+		{"create", "testorg", "-f", filepath.Join(t.TempDir(), "missing.csv")},
+	} {
+		cmd := NewCmdRoot()
+		cmd.SetOut(new(bytes.Buffer))
+		cmd.SetErr(new(bytes.Buffer))
+		cmd.SetArgs(append(args, "--token", "test-token"))
+		if code := exitcode.Code(cmd.Execute()); code != exitcode.ValidationFailure {
+			t.Errorf("%v: expected exit code %d, got %d", args, exitcode.ValidationFailure, code)
+		}
+	}
+}
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	source := newRoundTripServer(5)
+	source.Repos["app"].Environments["production"] = &utils.MockEnvironment{
+		Name:              "production",
+		WaitTimer:         30,
+		PreventSelfReview: true,
+		Reviewers: []data.Reviewers{
+			{Type: "User", Reviewer: data.Reviewer{ID: 1, Login: "octocat"}},
+			{Type: "Team", Reviewer: data.Reviewer{ID: 2, Login: "release-managers"}},
+		},
+		DeploymentPolicy: &data.DeploymentPolicy{CustomPolicies: true},
+		BranchPolicies:   []data.BranchPolicy{{ID: 11, Name: "main", Type: "branch"}},
+		ProtectionRules: []data.DeploymentProtectionPolicyApp{
+			{PolicyID: 21, Enabled: true, App: data.DeploymentApp{IntegrationID: 5, Slug: "deploy-gate"}},
+		},
+		Secrets:   []data.Secret{{Name: "TOKEN"}},
+		Variables: []data.Variable{{Name: "REGION", Value: "us-east-1"}},
+	}
+	source.Repos["api"].Environments["dev"] = &utils.MockEnvironment{Name: "dev", WaitTimer: 5}
+
+	archive := filepath.Join(t.TempDir(), "backup.tar.gz")
+	runRoot(t, source, "backup", "testorg", "-o", archive, "--token", "test-token")
+
+	// The target assigns different IDs to the app and reviewers, so they
+	// are resolved by name when restoring to another organization
+	target := newRoundTripServer(9)
+	target.Accounts = []data.Reviewers{
+		{Type: "User", Reviewer: data.Reviewer{ID: 31, Login: "octocat"}},
+		{Type: "Team", Reviewer: data.Reviewer{ID: 32, Login: "release-managers"}},
+	}
+	runRoot(t, target, "restore", archive, "--org", "neworg", "--token", "test-token")
+
+	want := normalizeServer(source)
+	got := normalizeServer(target)
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Round trip mismatch\nwant: %+v\ngot:  %+v", want, got)
+	}
+	variables := target.Repos["app"].Environments["production"].Variables
+	if len(variables) != 1 || variables[0].Name != "REGION" || variables[0].Value != "us-east-1" {
+		t.Errorf("Expected variables to be restored, got %+v", variables)
+	}
+
+	// Selective restore only touches the named repository
+	selective := newRoundTripServer(9)
+	runRoot(t, selective, "restore", archive, "api", "--token", "test-token")
+	if got := normalizeServer(selective); len(got) != 1 || !reflect.DeepEqual(got["api/dev"], want["api/dev"]) {
+		t.Errorf("Expected only api/dev to be restored, got %+v", got)
+	}
+}
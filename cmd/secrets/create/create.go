@@ -2,31 +2,28 @@ package createsecrets
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"os"
 
-	"github.com/cli/go-gh/v2/pkg/api"
-	"github.com/cli/go-gh/v2/pkg/auth"
 	"github.com/katiem0/gh-environments/internal/data"
-	"github.com/katiem0/gh-environments/internal/log"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/progress"
 	"github.com/katiem0/gh-environments/internal/utils"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
 type cmdFlags struct {
-	fileName string
-	token    string
-	hostname string
-	debug    bool
+	fileName  string
+	envFilter utils.EnvFilterFlags
 }
 
 func NewCmdCreate() *cobra.Command {
 	//var repository string
 	cmdFlags := cmdFlags{}
-	var authToken string
 
 	createCmd := cobra.Command{
 		Use:   "create <organization> [flags]",
@@ -34,61 +31,25 @@ func NewCmdCreate() *cobra.Command {
 		Long:  "Create Environment secrets for specified environments per repository in an organization from a file.",
 		Args:  cobra.MinimumNArgs(1),
 		RunE: func(createCmd *cobra.Command, args []string) error {
+			ctx := createCmd.Context()
 			var err error
-			var gqlClient *api.GraphQLClient
-			var restClient *api.RESTClient
-
-			// Reinitialize logging if debugging was enabled
-			if cmdFlags.debug {
-				logger, _ := log.NewLogger(cmdFlags.debug)
-				defer logger.Sync() // nolint:errcheck
-				zap.ReplaceGlobals(logger)
-			}
-
-			if cmdFlags.token != "" {
-				authToken = cmdFlags.token
-			} else {
-				t, _ := auth.TokenForHost(cmdFlags.hostname)
-				authToken = t
-			}
-
-			gqlClient, err = api.NewGraphQLClient(api.ClientOptions{
-				Headers: map[string]string{
-					"Accept": "application/vnd.github.hawkgirl-preview+json",
-				},
-				Host:      cmdFlags.hostname,
-				AuthToken: authToken,
-			})
-
-			if err != nil {
-				zap.S().Errorf("Error arose retrieving graphql client")
-				return err
-			}
-
-			restClient, err = api.NewRESTClient(api.ClientOptions{
-				Headers: map[string]string{
-					"Accept": "application/vnd.github+json",
-				},
-				Host:      cmdFlags.hostname,
-				AuthToken: authToken,
-			})
 
+			clientConfig := utils.ClientConfigFromFlags(createCmd.Flags())
+			clientConfig.Owner = args[0]
+			g, err := utils.NewGetter(clientConfig)
 			if err != nil {
-				zap.S().Errorf("Error arose retrieving rest client")
 				return err
 			}
 
 			owner := args[0]
 
-			return runCmdCreate(owner, &cmdFlags, utils.NewAPIGetter(gqlClient, restClient))
+			return runCmdCreate(ctx, owner, &cmdFlags, g)
 		},
 	}
 
 	// Configure flags for command
-	createCmd.PersistentFlags().StringVarP(&cmdFlags.token, "token", "t", "", `GitHub personal access token for organization to write to (default "gh auth token")`)
-	createCmd.PersistentFlags().StringVarP(&cmdFlags.hostname, "hostname", "", "github.com", "GitHub Enterprise Server hostname")
 	createCmd.Flags().StringVarP(&cmdFlags.fileName, "from-file", "f", "", "Path and Name of CSV file to create secrets from")
-	createCmd.PersistentFlags().BoolVarP(&cmdFlags.debug, "debug", "d", false, "To debug logging")
+	cmdFlags.envFilter.AddFlags(createCmd.Flags())
 	if err := createCmd.MarkFlagRequired("from-file"); err != nil {
 		zap.S().Errorf("Error marking flag 'from-file' as required: %v", err)
 		return nil
@@ -97,8 +58,15 @@ func NewCmdCreate() *cobra.Command {
 	return &createCmd
 }
 
-func runCmdCreate(owner string, cmdFlags *cmdFlags, g *utils.APIGetter) error {
+func runCmdCreate(ctx context.Context, owner string, cmdFlags *cmdFlags, g *utils.APIGetter) error {
+	envs, err := cmdFlags.envFilter.Filter()
+	if err != nil {
+		return err
+	}
+
 	var secretData [][]string
+	tracker := progress.FromContext(ctx)
+	failed := 0
 	var secretList []data.ImportedSecret
 
 	if len(cmdFlags.fileName) > 0 {
@@ -119,17 +87,30 @@ func runCmdCreate(owner string, cmdFlags *cmdFlags, g *utils.APIGetter) error {
 		zap.S().Debugf("Reading in all lines from csv file")
 		if err != nil {
 			zap.S().Errorf("Error arose reading secrets from csv file")
+			return exitcode.New(exitcode.ValidationFailure, fmt.Errorf("%s: %w", cmdFlags.fileName, err))
 		}
-		secretList = g.CreateSecretList(secretData)
+		secretList, err = g.CreateSecretList(secretData)
+		if err != nil {
+			zap.S().Errorf("Error arose parsing secrets from csv file")
+			return exitcode.New(exitcode.ValidationFailure, fmt.Errorf("%s: %w", cmdFlags.fileName, err))
+		}
+		selected := utils.SelectEnvironments(envs, secretList, func(secret data.ImportedSecret) (string, string) {
+			return secret.RepositoryName, secret.EnvironmentName
+		})
+		tracker.Skipped(len(secretList) - len(selected))
+		secretList = selected
 		zap.S().Debugf("Identifying secrets list to create under %s", owner)
 		zap.S().Debugf("Determining secrets to create")
 
 		for _, secret := range secretList {
 
 			zap.S().Debugf("Gathering secret %s for repo %s and env %s", secret.Name, secret.RepositoryName, secret.EnvironmentName)
-			publicKey, err := g.GetEnvironmentPublicKey(owner, secret.RepositoryName, secret.EnvironmentName)
+			publicKey, err := g.GetEnvironmentPublicKey(ctx, owner, secret.RepositoryName, secret.EnvironmentName)
 			if err != nil {
-				zap.S().Errorf("Error arose reading secret from csv file")
+				zap.S().Errorf("Error arose reading the public key of %s/%s: %v", secret.RepositoryName, secret.EnvironmentName, err)
+				tracker.Failed()
+				failed++
+				continue
 			}
 			var responsePublicKey data.PublicKey
 			err = json.Unmarshal(publicKey, &responsePublicKey)
@@ -150,15 +131,24 @@ func runCmdCreate(owner string, cmdFlags *cmdFlags, g *utils.APIGetter) error {
 
 			reader := bytes.NewReader(createSecret)
 			zap.S().Debugf("Creating secret %s under %s/%s for env %s", secret.Name, owner, secret.RepositoryName, secret.EnvironmentName)
-			err = g.CreateEnvironmentSecret(owner, secret.RepositoryName, secret.EnvironmentName, secret.Name, reader)
+			err = g.CreateEnvironmentSecret(ctx, owner, secret.RepositoryName, secret.EnvironmentName, secret.Name, reader)
 			if err != nil {
-				zap.S().Errorf("Error arose creating variable with %s", secret.Name)
+				zap.S().Errorf("Error arose creating secret %s: %v", secret.Name, err)
+				tracker.Failed()
+				failed++
+				continue
 			}
+			// Secrets are written whether or not they exist, so every
+			// write is counted as created
+			tracker.Created()
 		}
 	} else {
 		zap.S().Errorf("Error arose identifying secrets")
 	}
+	if failed > 0 {
+		return exitcode.Failures(failed, len(secretList), fmt.Errorf("failed to create %d secret(s)", failed))
+	}
 
-	fmt.Printf("Successfully created secrets from file: %s.", cmdFlags.fileName)
+	fmt.Fprintf(os.Stderr, "Successfully created secrets from file: %s\n", cmdFlags.fileName)
 	return nil
 }
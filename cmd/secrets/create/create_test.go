@@ -2,6 +2,7 @@ package createsecrets
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
@@ -32,29 +33,16 @@ func TestNewCmdCreate(t *testing.T) {
 	if fromFileFlag == nil {
 		t.Error("from-file flag not found")
 	}
-
-	// Test other flags exist
-	if cmd.Flag("token") == nil {
-		t.Error("token flag not found")
-	}
-
-	if cmd.Flag("hostname") == nil {
-		t.Error("hostname flag not found")
-	}
-
-	if cmd.Flag("debug") == nil {
-		t.Error("debug flag not found")
-	}
 }
 
 // This is only used for testing purposes
-func runCmdCreateTest(owner string, cmdFlags *cmdFlags, g interface{}) error {
+func runCmdCreateTest(ctx context.Context, owner string, cmdFlags *cmdFlags, g interface{}) error {
 	// Type assertion to the interface methods we need
 	getter, ok := g.(interface {
-		CreateSecretList(data [][]string) []data.ImportedSecret
-		GetEnvironmentPublicKey(owner string, repo string, env string) ([]byte, error)
+		CreateSecretList(data [][]string) ([]data.ImportedSecret, error)
+		GetEnvironmentPublicKey(ctx context.Context, owner string, repo string, env string) ([]byte, error)
 		EncryptSecret(publickey string, secret string) (string, error)
-		CreateEnvironmentSecret(owner string, repo string, env string, secret string, data io.Reader) error
+		CreateEnvironmentSecret(ctx context.Context, owner string, repo string, env string, secret string, data io.Reader) error
 	})
 
 	if !ok {
@@ -83,10 +71,13 @@ func runCmdCreateTest(owner string, cmdFlags *cmdFlags, g interface{}) error {
 		if err != nil {
 			return err
 		}
-		secretList = getter.CreateSecretList(secretData)
+		secretList, err = getter.CreateSecretList(secretData)
+		if err != nil {
+			return err
+		}
 
 		for _, secret := range secretList {
-			publicKey, err := getter.GetEnvironmentPublicKey(owner, secret.RepositoryName, secret.EnvironmentName)
+			publicKey, err := getter.GetEnvironmentPublicKey(ctx, owner, secret.RepositoryName, secret.EnvironmentName)
 			if err != nil {
 				return err
 			}
@@ -108,7 +99,7 @@ func runCmdCreateTest(owner string, cmdFlags *cmdFlags, g interface{}) error {
 			}
 
 			reader := bytes.NewReader(createSecret)
-			err = getter.CreateEnvironmentSecret(owner, secret.RepositoryName, secret.EnvironmentName, secret.Name, reader)
+			err = getter.CreateEnvironmentSecret(ctx, owner, secret.RepositoryName, secret.EnvironmentName, secret.Name, reader)
 			if err != nil {
 				return err
 			}
@@ -124,20 +115,20 @@ type testAPIGetter struct {
 }
 
 // Implement the necessary methods from APIGetter
-func (t *testAPIGetter) CreateSecretList(data [][]string) []data.ImportedSecret {
+func (t *testAPIGetter) CreateSecretList(data [][]string) ([]data.ImportedSecret, error) {
 	return t.mock.CreateSecretList(data)
 }
 
-func (t *testAPIGetter) GetEnvironmentPublicKey(owner string, repo string, env string) ([]byte, error) {
-	return t.mock.GetEnvironmentPublicKey(owner, repo, env)
+func (t *testAPIGetter) GetEnvironmentPublicKey(ctx context.Context, owner string, repo string, env string) ([]byte, error) {
+	return t.mock.GetEnvironmentPublicKey(ctx, owner, repo, env)
 }
 
 func (t *testAPIGetter) EncryptSecret(publickey string, secret string) (string, error) {
 	return t.mock.EncryptSecret(publickey, secret)
 }
 
-func (t *testAPIGetter) CreateEnvironmentSecret(owner string, repo string, env string, secret string, data io.Reader) error {
-	return t.mock.CreateEnvironmentSecret(owner, repo, env, secret, data)
+func (t *testAPIGetter) CreateEnvironmentSecret(ctx context.Context, owner string, repo string, env string, secret string, data io.Reader) error {
+	return t.mock.CreateEnvironmentSecret(ctx, owner, repo, env, secret, data)
 }
 
 func setupMockGetter() (*utils.MockAPIGetter, *testAPIGetter) {
@@ -161,6 +152,7 @@ func setupMockGetter() (*utils.MockAPIGetter, *testAPIGetter) {
 }
 
 func TestRunCmdCreate(t *testing.T) {
+	ctx := context.Background()
 	// Create a temporary CSV file
 	tmpDir := t.TempDir()
 	csvFile := filepath.Join(tmpDir, "test-secrets.csv")
@@ -178,12 +170,10 @@ testrepo,12345,production,TEST_SECRET,test-value,,`
 	// Create command flags
 	flags := &cmdFlags{
 		fileName: csvFile,
-		hostname: "github.com",
-		debug:    false,
 	}
 
 	// Execute with our adapter, using the test version that accepts an interface
-	err = runCmdCreateTest("testorg", flags, testGetter)
+	err = runCmdCreateTest(ctx, "testorg", flags, testGetter)
 
 	// Verify
 	if err != nil {
@@ -192,18 +182,17 @@ testrepo,12345,production,TEST_SECRET,test-value,,`
 }
 
 func TestRunCmdCreateFileError(t *testing.T) {
+	ctx := context.Background()
 	// Create mock API getter
 	_, testGetter := setupMockGetter()
 
 	// Create command flags with non-existent file
 	flags := &cmdFlags{
 		fileName: "non-existent-file.csv",
-		hostname: "github.com",
-		debug:    false,
 	}
 
 	// Execute with our adapter, using the test version that accepts an interface
-	err := runCmdCreateTest("testorg", flags, testGetter)
+	err := runCmdCreateTest(ctx, "testorg", flags, testGetter)
 
 	// Verify error is returned
 	if err == nil {
@@ -0,0 +1,99 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/snapshot"
+	"github.com/katiem0/gh-environments/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type cmdFlags struct {
+	hostname   string
+	outputFile string
+	repoFilter utils.RepoFilterFlags
+	envFilter  utils.EnvFilterFlags
+}
+
+func NewCmdSnapshot() *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	snapshotCmd := cobra.Command{
+		Use:   "snapshot [flags] <organization> [repo ...]",
+		Short: "Save a snapshot of environments for drift detection.",
+		Long:  "Save a normalized, versioned JSON snapshot of the environments, reviewers, branch policies, variables and secret metadata of an organization, to compare against later with drift.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(snapshotCmd *cobra.Command, args []string) error {
+			ctx := snapshotCmd.Context()
+			var err error
+
+			repos, err := cmdFlags.repoFilter.Repos(args[1:])
+			if err != nil {
+				return err
+			}
+			filter, err := cmdFlags.repoFilter.Filter()
+			if err != nil {
+				return err
+			}
+			envs, err := cmdFlags.envFilter.Filter()
+			if err != nil {
+				return err
+			}
+
+			clientConfig := utils.ClientConfigFromFlags(snapshotCmd.Flags())
+			clientConfig.Owner = args[0]
+			cmdFlags.hostname = clientConfig.Hostname
+			g, err := utils.NewGetter(clientConfig)
+			if err != nil {
+				return err
+			}
+
+			f, err := os.Create(cmdFlags.outputFile)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if closeErr := f.Close(); closeErr != nil {
+					zap.S().Warnf("Error closing file: %v", closeErr)
+				}
+			}()
+
+			err = runCmdSnapshot(ctx, args[0], repos, filter, envs, &cmdFlags, g, f)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(snapshotCmd.OutOrStdout(), "Successfully saved environment snapshot to file: %s\n", cmdFlags.outputFile)
+			return nil
+		},
+	}
+
+	outputFileDefault := fmt.Sprintf("snapshot-environments-%s.json", time.Now().Format("20060102150405"))
+
+	// Configure flags for command
+	snapshotCmd.Flags().StringVarP(&cmdFlags.outputFile, "output-file", "o", outputFileDefault, "Name of file to write the JSON snapshot to")
+	cmdFlags.envFilter.AddFlags(snapshotCmd.Flags())
+	cmdFlags.repoFilter.AddFlags(snapshotCmd.Flags())
+
+	return &snapshotCmd
+}
+
+func runCmdSnapshot(ctx context.Context, owner string, repos []string, filter utils.RepoFilter, envs utils.EnvFilter, cmdFlags *cmdFlags, g *utils.APIGetter, out io.Writer) error {
+	// An interrupted snapshot still saves the environments gathered so far
+	environments, err := utils.GatherOrgEnvironments(ctx, g, owner, repos, filter, envs, true)
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+	zap.S().Debugf("Writing snapshot of %d environment(s)", len(environments))
+	if err := snapshot.New(cmdFlags.hostname, owner, environments, time.Now()).Write(out); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return utils.PartialOutputError(ctx, cmdFlags.outputFile)
+	}
+	return nil
+}
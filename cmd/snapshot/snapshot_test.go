@@ -0,0 +1,99 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/snapshot"
+	"github.com/katiem0/gh-environments/internal/utils"
+)
+
+func TestNewCmdSnapshot(t *testing.T) {
+	cmd := NewCmdSnapshot()
+
+	if cmd.Use != "snapshot [flags] <organization> [repo ...]" {
+		t.Errorf("Unexpected Use %q", cmd.Use)
+	}
+	for _, flag := range []string{"output-file", "env", "visibility"} {
+		if cmd.Flag(flag) == nil {
+			t.Errorf("%s flag not found", flag)
+		}
+	}
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("Expected error when no organization is given")
+	}
+}
+
+func TestRunCmdSnapshot(t *testing.T) {
+	ctx := context.Background()
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "app").Environments["production"] = &utils.MockEnvironment{
+		Name:      "production",
+		WaitTimer: 5,
+		Variables: []data.Variable{{Name: "URL", Value: "https://example.com"}},
+		Secrets:   []data.Secret{{Name: "TOKEN"}},
+	}
+	server.AddRepo(2, "web").Environments["staging"] = &utils.MockEnvironment{Name: "staging"}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err = runCmdSnapshot(ctx, "testorg", []string{"app"}, utils.RepoFilter{}, utils.EnvFilter{}, &cmdFlags{hostname: "github.com"}, g, &out)
+	if err != nil {
+		t.Fatalf("runCmdSnapshot() error = %v", err)
+	}
+
+	var s snapshot.Snapshot
+	if err := json.Unmarshal(out.Bytes(), &s); err != nil {
+		t.Fatalf("Invalid snapshot: %v\n%s", err, out.String())
+	}
+	if s.Version != snapshot.Version || s.Organization != "testorg" || s.Host != "github.com" {
+		t.Errorf("Unexpected snapshot header %+v", s)
+	}
+	if len(s.Environments) != 1 {
+		t.Fatalf("Expected 1 environment, got %+v", s.Environments)
+	}
+	env := s.Environments[0]
+	if env.WaitTimer != 5 || len(env.Variables) != 1 || env.Variables[0].Value != "https://example.com" || len(env.Secrets) != 1 {
+		t.Errorf("Unexpected environment %+v", env)
+	}
+}
+
+func TestRunCmdSnapshotInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := utils.NewMockGitHubServer()
+	server.AddRepo(1, "app").Environments["production"] = &utils.MockEnvironment{Name: "production"}
+	server.AddRepo(2, "web").Environments["staging"] = &utils.MockEnvironment{Name: "staging"}
+	// The run is interrupted while the environments of web are read
+	server.BeforeRequest = func(req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "repos/testorg/web/environments") {
+			cancel()
+		}
+	}
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err = runCmdSnapshot(ctx, "testorg", []string{"app", "web"}, utils.RepoFilter{}, utils.EnvFilter{}, &cmdFlags{outputFile: "snapshot.json"}, g, &out)
+	if code := exitcode.Code(err); code != exitcode.PartialFailure {
+		t.Fatalf("Expected a partial failure, got %v", err)
+	}
+	var s snapshot.Snapshot
+	if err := json.Unmarshal(out.Bytes(), &s); err != nil {
+		t.Fatalf("Invalid snapshot: %v\n%s", err, out.String())
+	}
+	if len(s.Environments) != 1 || s.Environments[0].Repository != "app" {
+		t.Errorf("Expected the environments gathered before the interrupt, got %+v", s.Environments)
+	}
+}
@@ -0,0 +1,59 @@
+package validate
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type cmdFlags struct {
+	fileName string
+}
+
+func NewCmdValidate() *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	validateCmd := cobra.Command{
+		Use:   "validate [flags]",
+		Short: "Validate an environments file.",
+		Long:  "Validate an environments CSV file against the format expected by the create command, reporting every error found.",
+		Args:  cobra.NoArgs,
+		RunE: func(validateCmd *cobra.Command, args []string) error {
+			return runCmdValidate(&cmdFlags, os.Stdout)
+		},
+	}
+
+	// Configure flags for command
+	validateCmd.Flags().StringVarP(&cmdFlags.fileName, "from-file", "f", "", "Path and Name of CSV file to validate")
+	if err := validateCmd.MarkFlagRequired("from-file"); err != nil {
+		zap.S().Errorf("Error marking flag 'from-file' as required: %v", err)
+		return nil
+	}
+
+	return &validateCmd
+}
+
+func runCmdValidate(cmdFlags *cmdFlags, out io.Writer) error {
+	zap.S().Debugf("Reading in all lines from csv file %s", cmdFlags.fileName)
+	environmentData, err := utils.ReadCSVFile(cmdFlags.fileName)
+	if err != nil {
+		zap.S().Errorf("Error arose reading environments from csv file")
+		return err
+	}
+
+	validationErrors := utils.ValidateEnvironmentList(environmentData)
+	for _, validationError := range validationErrors {
+		fmt.Fprintln(out, validationError.Error())
+	}
+	if len(validationErrors) > 0 {
+		return exitcode.New(exitcode.ValidationFailure, fmt.Errorf("%s failed validation with %d error(s)", cmdFlags.fileName, len(validationErrors)))
+	}
+
+	fmt.Fprintf(out, "Successfully validated environments file: %s\n", cmdFlags.fileName)
+	return nil
+}
@@ -0,0 +1,76 @@
+package validate
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/exitcode"
+)
+
+func TestNewCmdValidate(t *testing.T) {
+	cmd := NewCmdValidate()
+
+	if cmd == nil {
+		t.Fatal("NewCmdValidate() returned nil")
+	}
+
+	// Test basic properties
+	if cmd.Use != "validate [flags]" {
+		t.Errorf("Expected Use to be 'validate [flags]', got %s", cmd.Use)
+	}
+
+	// Test flags
+	if cmd.Flag("from-file") == nil {
+		t.Error("from-file flag not found")
+	}
+
+	// Test short description
+	if cmd.Short == "" {
+		t.Error("Command should have a short description")
+	}
+}
+
+func TestRunCmdValidate(t *testing.T) {
+	tempDir := t.TempDir()
+	header := "RepositoryName,RepositoryID,EnvironmentName,AdminBypass,WaitTimer,Reviewers,PreventSelfReview,BranchPolicyType,Branches\n"
+
+	validFile := filepath.Join(tempDir, "valid.csv")
+	if err := os.WriteFile(validFile, []byte(header+"repo1,1,production,false,5,User;user1;1,true,protected,\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := runCmdValidate(&cmdFlags{fileName: validFile}, &buf); err != nil {
+		t.Errorf("runCmdValidate() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "Successfully validated") {
+		t.Errorf("Expected success message, got %s", buf.String())
+	}
+
+	invalidFile := filepath.Join(tempDir, "invalid.csv")
+	if err := os.WriteFile(invalidFile, []byte(header+"repo1,1,production,false,abc,,true,protected,\nrepo2,2\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	buf.Reset()
+	err := runCmdValidate(&cmdFlags{fileName: invalidFile}, &buf)
+	if err == nil {
+		t.Fatal("Expected validation error, got nil")
+	}
+	if exitcode.Code(err) != exitcode.ValidationFailure {
+		t.Errorf("Expected a validation failure, got exit code %d", exitcode.Code(err))
+	}
+	if !strings.Contains(err.Error(), "2 error(s)") {
+		t.Errorf("Expected 2 errors to be reported, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "line 2, column 5 (WaitTimer)") || !strings.Contains(buf.String(), "line 3: expected 9 columns, got 2") {
+		t.Errorf("Expected line-numbered errors, got %s", buf.String())
+	}
+
+	if err := runCmdValidate(&cmdFlags{fileName: filepath.Join(tempDir, "missing.csv")}, &buf); err == nil {
+		t.Error("Expected error for missing file, got nil")
+	}
+}
@@ -2,6 +2,7 @@ package createvariables
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
@@ -31,25 +32,13 @@ func TestNewCmdCreate(t *testing.T) {
 	if cmd.Flag("from-file") == nil {
 		t.Error("from-file flag not found")
 	}
-
-	if cmd.Flag("token") == nil {
-		t.Error("token flag not found")
-	}
-
-	if cmd.Flag("hostname") == nil {
-		t.Error("hostname flag not found")
-	}
-
-	if cmd.Flag("debug") == nil {
-		t.Error("debug flag not found")
-	}
 }
 
-func runCmdCreateTest(owner string, cmdFlags *cmdFlags, g interface{}) error {
+func runCmdCreateTest(ctx context.Context, owner string, cmdFlags *cmdFlags, g interface{}) error {
 	// Type assertion to the interface methods we need
 	getter, ok := g.(interface {
-		CreateVariableList(data [][]string) []data.ImportedVariable
-		CreateEnvironmentVariables(owner string, repo string, env string, data io.Reader) error
+		CreateVariableList(data [][]string) ([]data.ImportedVariable, error)
+		CreateEnvironmentVariables(ctx context.Context, owner string, repo string, env string, data io.Reader) error
 	})
 
 	if !ok {
@@ -78,7 +67,10 @@ func runCmdCreateTest(owner string, cmdFlags *cmdFlags, g interface{}) error {
 		if err != nil {
 			return err
 		}
-		variablesList = getter.CreateVariableList(variableData)
+		variablesList, err = getter.CreateVariableList(variableData)
+		if err != nil {
+			return err
+		}
 
 		for _, variable := range variablesList {
 			importVar := utils.CreateVariableData(variable)
@@ -87,7 +79,7 @@ func runCmdCreateTest(owner string, cmdFlags *cmdFlags, g interface{}) error {
 				return err
 			}
 			reader := bytes.NewReader(createVariable)
-			err = getter.CreateEnvironmentVariables(owner, variable.RepositoryName, variable.EnvironmentName, reader)
+			err = getter.CreateEnvironmentVariables(ctx, owner, variable.RepositoryName, variable.EnvironmentName, reader)
 			if err != nil {
 				return err
 			}
@@ -103,12 +95,12 @@ type testAPIGetter struct {
 }
 
 // Implement the necessary methods from APIGetter
-func (t *testAPIGetter) CreateVariableList(data [][]string) []data.ImportedVariable {
+func (t *testAPIGetter) CreateVariableList(data [][]string) ([]data.ImportedVariable, error) {
 	return t.mock.CreateVariableList(data)
 }
 
-func (t *testAPIGetter) CreateEnvironmentVariables(owner string, repo string, env string, data io.Reader) error {
-	return t.mock.CreateEnvironmentVariables(owner, repo, env, data)
+func (t *testAPIGetter) CreateEnvironmentVariables(ctx context.Context, owner string, repo string, env string, data io.Reader) error {
+	return t.mock.CreateEnvironmentVariables(ctx, owner, repo, env, data)
 }
 
 func setupMockGetter() (*utils.MockAPIGetter, *testAPIGetter) {
@@ -123,6 +115,7 @@ func setupMockGetter() (*utils.MockAPIGetter, *testAPIGetter) {
 }
 
 func TestRunCmdCreate(t *testing.T) {
+	ctx := context.Background()
 	// Create a temporary CSV file
 	tmpDir := t.TempDir()
 	csvFile := filepath.Join(tmpDir, "test-variables.csv")
@@ -140,12 +133,10 @@ testrepo,12345,production,TEST_VAR,test-value,,`
 	// Create command flags
 	flags := &cmdFlags{
 		fileName: csvFile,
-		hostname: "github.com",
-		debug:    false,
 	}
 
 	// Execute with our adapter, using the test version that accepts an interface
-	err = runCmdCreateTest("testorg", flags, testGetter)
+	err = runCmdCreateTest(ctx, "testorg", flags, testGetter)
 
 	// Verify
 	if err != nil {
@@ -154,18 +145,17 @@ testrepo,12345,production,TEST_VAR,test-value,,`
 }
 
 func TestRunCmdCreateFileError(t *testing.T) {
+	ctx := context.Background()
 	// Create mock API getter
 	_, testGetter := setupMockGetter()
 
 	// Create command flags with non-existent file
 	flags := &cmdFlags{
 		fileName: "non-existent-file.csv",
-		hostname: "github.com",
-		debug:    false,
 	}
 
 	// Execute with our adapter, using the test version that accepts an interface
-	err := runCmdCreateTest("testorg", flags, testGetter)
+	err := runCmdCreateTest(ctx, "testorg", flags, testGetter)
 
 	// Verify error is returned
 	if err == nil {
@@ -1,6 +1,7 @@
 package variableslist
 
 import (
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
@@ -8,81 +9,71 @@ import (
 	"io"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/cli/go-gh/v2/pkg/api"
-	"github.com/cli/go-gh/v2/pkg/auth"
 	"github.com/katiem0/gh-environments/internal/data"
-	"github.com/katiem0/gh-environments/internal/log"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/progress"
 	"github.com/katiem0/gh-environments/internal/utils"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
 type cmdFlags struct {
-	hostname   string
-	token      string
 	reportFile string
-	debug      bool
+	repoFilter utils.RepoFilterFlags
+	envFilter  utils.EnvFilterFlags
+	enterprise string
 }
 
 func NewCmdList() *cobra.Command {
 	//var repository string
 	cmdFlags := cmdFlags{}
-	var authToken string
 
 	exportCmd := cobra.Command{
 		Use:   "list [flags] <organization> [repo ...] ",
 		Short: "Generate a report of Environment variable.",
 		Long:  "Generate a report of variables for each environment per repository in an organization.",
-		Args:  cobra.MinimumNArgs(1),
+		Args:  cobra.ArbitraryArgs,
 		RunE: func(exportCmd *cobra.Command, args []string) error {
+			ctx := exportCmd.Context()
+			if cmdFlags.enterprise == "" && len(args) == 0 {
+				return exitcode.New(exitcode.ValidationFailure, errors.New("requires an organization or --enterprise"))
+			}
+			if cmdFlags.enterprise != "" && len(args) > 0 {
+				return exitcode.New(exitcode.ValidationFailure, errors.New("--enterprise cannot be combined with an organization or repositories"))
+			}
 			var err error
-			var gqlClient *api.GraphQLClient
-			var restClient *api.RESTClient
 
-			// Reinitialize logging if debugging was enabled
-			if cmdFlags.debug {
-				logger, _ := log.NewLogger(cmdFlags.debug)
-				defer logger.Sync() // nolint:errcheck
-				zap.ReplaceGlobals(logger)
+			// Requests for an enterprise scan name their organization
+			owner := ""
+			if len(args) > 0 {
+				owner = args[0]
 			}
-
-			if cmdFlags.token != "" {
-				authToken = cmdFlags.token
-			} else {
-				t, _ := auth.TokenForHost(cmdFlags.hostname)
-				authToken = t
+			clientConfig := utils.ClientConfigFromFlags(exportCmd.Flags())
+			clientConfig.Owner = owner
+			g, err := utils.NewGetter(clientConfig)
+			if err != nil {
+				return err
 			}
 
-			gqlClient, err = api.NewGraphQLClient(api.ClientOptions{
-				Headers: map[string]string{
-					"Accept": "application/vnd.github.hawkgirl-preview+json",
-				},
-				Host:      cmdFlags.hostname,
-				AuthToken: authToken,
-			})
-
+			var repos []string
+			if len(args) > 0 {
+				repos = args[1:]
+			}
+			repos, err = cmdFlags.repoFilter.Repos(repos)
 			if err != nil {
-				zap.S().Errorf("Error arose retrieving graphql client")
 				return err
 			}
-
-			restClient, err = api.NewRESTClient(api.ClientOptions{
-				Headers: map[string]string{
-					"Accept": "application/vnd.github+json",
-				},
-				Host:      cmdFlags.hostname,
-				AuthToken: authToken,
-			})
-
+			filter, err := cmdFlags.repoFilter.Filter()
+			if err != nil {
+				return err
+			}
+			envs, err := cmdFlags.envFilter.Filter()
 			if err != nil {
-				zap.S().Errorf("Error arose retrieving rest client")
 				return err
 			}
-
-			owner := args[0]
-			repos := args[1:]
 
 			if _, err := os.Stat(cmdFlags.reportFile); errors.Is(err, os.ErrExist) {
 				return err
@@ -94,29 +85,35 @@ func NewCmdList() *cobra.Command {
 				return err
 			}
 
-			return runCmdList(owner, repos, &cmdFlags, utils.NewAPIGetter(gqlClient, restClient), reportWriter)
+			owners := args[:1]
+			if cmdFlags.enterprise != "" {
+				owners, err = utils.GatherEnterpriseOrgs(ctx, g, cmdFlags.enterprise)
+				if err != nil {
+					zap.S().Error("Error raised in gathering enterprise organizations", zap.Error(err))
+					return err
+				}
+			}
+
+			return runCmdList(ctx, owners, repos, filter, envs, &cmdFlags, g, reportWriter)
 		},
 	}
 
 	// Determine default report file based on current timestamp; for more info see https://pkg.go.dev/time#pkg-constants
 	reportFileDefault := fmt.Sprintf("report-variables-%s.csv", time.Now().Format("20060102150405"))
 	// Configure flags for command
-	exportCmd.PersistentFlags().StringVarP(&cmdFlags.token, "token", "t", "", `GitHub Personal Access Token (default "gh auth token")`)
-	exportCmd.PersistentFlags().StringVarP(&cmdFlags.hostname, "hostname", "", "github.com", "GitHub Enterprise Server hostname")
 	exportCmd.Flags().StringVarP(&cmdFlags.reportFile, "output-file", "o", reportFileDefault, "Name of file to write CSV report")
-	exportCmd.PersistentFlags().BoolVarP(&cmdFlags.debug, "debug", "d", false, "To debug logging")
+	exportCmd.Flags().StringVar(&cmdFlags.enterprise, "enterprise", "", "Enterprise slug to scan every organization of, instead of a single organization")
+	cmdFlags.envFilter.AddFlags(exportCmd.Flags())
+	cmdFlags.repoFilter.AddFlags(exportCmd.Flags())
 	//cmd.MarkPersistentFlagRequired("app")
 
 	return &exportCmd
 }
 
-func runCmdList(owner string, repos []string, cmdFlags *cmdFlags, g *utils.APIGetter, reportWriter io.Writer) error {
-	var reposCursor *string
-	var allRepos []data.RepoInfo
-
+func runCmdList(ctx context.Context, owners []string, repos []string, filter utils.RepoFilter, envs utils.EnvFilter, cmdFlags *cmdFlags, g *utils.APIGetter, reportWriter io.Writer) error {
 	csvWriter := csv.NewWriter(reportWriter)
 
-	err := csvWriter.Write([]string{
+	header := []string{
 		"RepositoryID",
 		"RepositoryName",
 		"EnvironmentName",
@@ -124,75 +121,93 @@ func runCmdList(owner string, repos []string, cmdFlags *cmdFlags, g *utils.APIGe
 		"VariableValue",
 		"VariableCreatedAt",
 		"VariableUpdatedAt",
-	})
+	}
+	if cmdFlags.enterprise != "" {
+		header = append([]string{"Organization"}, header...)
+	}
+	err := csvWriter.Write(header)
 	if err != nil {
 		zap.S().Error("Error raised in writing to csv", zap.Error(err))
 	}
-	if len(repos) > 0 {
-		zap.S().Infof("Processing repos: %s", repos)
-
-		for _, repo := range repos {
-
-			zap.S().Debugf("Processing %s/%s", owner, repo)
-
-			repoQuery, err := g.GetRepo(owner, repo)
-			if err != nil {
-				zap.S().Error("Error raised in getting repos", zap.Error(err))
-				return err
+	var summaries []utils.OrgSummary
+	for _, owner := range owners {
+		summary, err := listOrganization(ctx, owner, repos, filter, envs, cmdFlags, g, csvWriter)
+		if err != nil {
+			if ctx.Err() != nil {
+				return utils.WritePartialReport(ctx, csvWriter, cmdFlags.reportFile)
 			}
-			allRepos = append(allRepos, repoQuery.Repository)
-		}
-
-	} else {
-		// Prepare writer for outputting report
-		for {
-			zap.S().Debugf("Processing list of repositories for %s", owner)
-			reposQuery, err := g.GetReposList(owner, reposCursor)
-
-			if err != nil {
-				zap.S().Error("Error raised in processing list of repos", zap.Error(err))
+			if cmdFlags.enterprise == "" {
 				return err
 			}
+			zap.S().Errorf("Error raised in processing organization %s: %v", owner, err)
+			summary.Err = err
+		}
+		summaries = append(summaries, summary)
+	}
 
-			allRepos = append(allRepos, reposQuery.Organization.Repositories.Nodes...)
-
-			reposCursor = &reposQuery.Organization.Repositories.PageInfo.EndCursor
+	csvWriter.Flush()
+	fmt.Fprintf(os.Stderr, "Successfully exported variables for %s to file: %s\n", strings.Join(owners, ", "), cmdFlags.reportFile)
+	if cmdFlags.enterprise != "" {
+		if err := utils.WriteOrgSummary(os.Stderr, summaries, "Variables"); err != nil {
+			return err
+		}
+	}
+	return utils.OrgFailures(ctx, summaries)
+}
 
-			if !reposQuery.Organization.Repositories.PageInfo.HasNextPage {
-				break
-			}
+// listOrganization writes the report rows for a single organization,
+// prefixing each with the organization name during an enterprise scan.
+func listOrganization(ctx context.Context, owner string, repos []string, filter utils.RepoFilter, envs utils.EnvFilter, cmdFlags *cmdFlags, g *utils.APIGetter, csvWriter *csv.Writer) (utils.OrgSummary, error) {
+	summary := utils.OrgSummary{Organization: owner}
+	writeRow := func(row []string) error {
+		if cmdFlags.enterprise != "" {
+			row = append([]string{owner}, row...)
 		}
+		summary.Rows++
+		return csvWriter.Write(row)
+	}
+
+	allRepos, err := utils.GatherRepos(ctx, g, owner, repos, filter)
+	if err != nil {
+		zap.S().Error("Error raised in gathering repos", zap.Error(err))
+		return summary, err
+	}
+	allRepos, _, err = utils.SelectReposByProperties(ctx, g, owner, allRepos, filter.Properties)
+	if err != nil {
+		zap.S().Error("Error raised in gathering repository custom properties", zap.Error(err))
+		return summary, err
 	}
 
 	// Writing to CSV environment Variables
+	tracker := progress.FromContext(ctx)
 	for _, singleRepo := range allRepos {
 		// Writing to CSV repository level Actions Variables
 		zap.S().Debugf("Gathering environments for %s", singleRepo.Name)
-		envListResp, err := g.GetRepoEnvironments(owner, singleRepo.Name)
+		envListResp, err := g.GetRepoEnvironments(ctx, owner, singleRepo.Name)
 		if err != nil {
 			zap.S().Error("Error raised in getting repo environments", zap.Error(err))
 		}
 		var envList data.EnvResponse
 		err = json.Unmarshal(envListResp, &envList)
 		if err != nil {
-			return err
+			return summary, err
 		}
 
 		for _, env := range envList.Environments {
+			if !envs.Match(env.Name) {
+				zap.S().Debugf("Skipping environment %s as it does not match the environment filters", env.Name)
+				continue
+			}
+			tracker.EnvironmentsFound(1)
 			zap.S().Debugf("Gathering environment %s variables for %s", env.Name, singleRepo.Name)
-			envVarsResp, err := g.GetEnvironmentVariables(owner, singleRepo.Name, env.Name)
+			variables, err := utils.GatherEnvironmentVariables(ctx, g, owner, singleRepo.Name, env.Name)
 			if err != nil {
 				zap.S().Error("Error raised in getting environment variables", zap.Error(err))
+				return summary, err
 			}
 
-			var envVars data.EnvVariables
-			err = json.Unmarshal(envVarsResp, &envVars)
-			if err != nil {
-				return err
-			}
-
-			for _, evar := range envVars.Variables {
-				err = csvWriter.Write([]string{
+			for _, evar := range variables {
+				err = writeRow([]string{
 					strconv.Itoa(singleRepo.DatabaseId),
 					singleRepo.Name,
 					env.Name,
@@ -203,14 +218,13 @@ func runCmdList(owner string, repos []string, cmdFlags *cmdFlags, g *utils.APIGe
 				})
 				if err != nil {
 					zap.S().Error("Error raised in writing output", zap.Error(err))
-					return err
+					return summary, err
 				}
 			}
 
 		}
+		tracker.RepositoryProcessed()
 	}
-
-	csvWriter.Flush()
-	fmt.Printf("Successfully exported variables for %s to file: %s\n", owner, cmdFlags.reportFile)
-	return nil
+	summary.Repositories = len(allRepos)
+	return summary, nil
 }
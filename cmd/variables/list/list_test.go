@@ -2,6 +2,7 @@ package variableslist
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
@@ -44,23 +45,23 @@ type testAPIGetter struct {
 }
 
 // Implement the necessary methods from APIGetter for variables functionality
-func (t *testAPIGetter) GetReposList(owner string, endCursor *string) (*data.ReposQuery, error) {
+func (t *testAPIGetter) GetReposList(ctx context.Context, owner string, endCursor *string) (*data.ReposQuery, error) {
 	return t.mock.ReposResponse, nil
 }
 
-func (t *testAPIGetter) GetRepo(owner string, name string) (*data.RepoSingleQuery, error) {
+func (t *testAPIGetter) GetRepo(ctx context.Context, owner string, name string) (*data.RepoSingleQuery, error) {
 	return t.mock.RepoResponse, nil
 }
 
-func (t *testAPIGetter) GetRepoEnvironments(owner string, repo string) ([]byte, error) {
-	return t.mock.GetRepoEnvironments(owner, repo)
+func (t *testAPIGetter) GetRepoEnvironments(ctx context.Context, owner string, repo string) ([]byte, error) {
+	return t.mock.GetRepoEnvironments(ctx, owner, repo)
 }
 
-func (t *testAPIGetter) GetEnvironmentVariables(owner string, repo string, env string) ([]byte, error) {
-	return t.mock.GetEnvironmentVariables(owner, repo, env)
+func (t *testAPIGetter) GetEnvironmentVariables(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error) {
+	return t.mock.GetEnvironmentVariables(ctx, owner, repo, env, page)
 }
 
-func testRunCmdList(owner string, repos []string, cmdFlags *cmdFlags, getter *testAPIGetter, reportWriter io.Writer) error {
+func testRunCmdList(ctx context.Context, owner string, repos []string, cmdFlags *cmdFlags, getter *testAPIGetter, reportWriter io.Writer) error {
 	csvWriter := csv.NewWriter(reportWriter)
 
 	err := csvWriter.Write([]string{
@@ -82,14 +83,14 @@ func testRunCmdList(owner string, repos []string, cmdFlags *cmdFlags, getter *te
 	// Process specific repos or all repos in organization
 	if len(repos) > 0 {
 		for _, repo := range repos {
-			repoQuery, err := getter.GetRepo(owner, repo)
+			repoQuery, err := getter.GetRepo(ctx, owner, repo)
 			if err != nil {
 				return err
 			}
 			allRepos = append(allRepos, repoQuery.Repository)
 		}
 	} else {
-		reposQuery, err := getter.GetReposList(owner, nil)
+		reposQuery, err := getter.GetReposList(ctx, owner, nil)
 		if err != nil {
 			return err
 		}
@@ -98,7 +99,7 @@ func testRunCmdList(owner string, repos []string, cmdFlags *cmdFlags, getter *te
 
 	// Process variables for each repo's environments
 	for _, singleRepo := range allRepos {
-		envListResp, err := getter.GetRepoEnvironments(owner, singleRepo.Name)
+		envListResp, err := getter.GetRepoEnvironments(ctx, owner, singleRepo.Name)
 		if err != nil {
 			continue
 		}
@@ -110,7 +111,7 @@ func testRunCmdList(owner string, repos []string, cmdFlags *cmdFlags, getter *te
 		}
 
 		for _, env := range envList.Environments {
-			envVarsResp, err := getter.GetEnvironmentVariables(owner, singleRepo.Name, env.Name)
+			envVarsResp, err := getter.GetEnvironmentVariables(ctx, owner, singleRepo.Name, env.Name, 1)
 			if err != nil {
 				continue
 			}
@@ -144,13 +145,12 @@ func testRunCmdList(owner string, repos []string, cmdFlags *cmdFlags, getter *te
 
 // TestRunCmdListWithSpecificRepository tests listing variables for a specific repository
 func TestRunCmdListWithSpecificRepository(t *testing.T) {
+	ctx := context.Background()
 	// Setup
 	owner := "testorg"
 	repos := []string{"testrepo"}
 	flags := &cmdFlags{
 		reportFile: "test-variables.csv",
-		hostname:   "github.com",
-		debug:      false,
 	}
 
 	// Create mock API getter
@@ -206,7 +206,7 @@ func TestRunCmdListWithSpecificRepository(t *testing.T) {
 	}
 
 	// Execute
-	err := testRunCmdList(owner, repos, flags, testGetter, &buf)
+	err := testRunCmdList(ctx, owner, repos, flags, testGetter, &buf)
 
 	// Verify
 	if err != nil {
@@ -0,0 +1,168 @@
+package workflows
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/progress"
+	"github.com/katiem0/gh-environments/internal/utils"
+	"github.com/katiem0/gh-environments/internal/workflows"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type cmdFlags struct {
+	reportFile string
+	repoFilter utils.RepoFilterFlags
+	envFilter  utils.EnvFilterFlags
+}
+
+func NewCmdWorkflows() *cobra.Command {
+	cmdFlags := cmdFlags{}
+
+	workflowsCmd := cobra.Command{
+		Use:   "workflows [flags] <organization> [repo ...]",
+		Short: "Generate a report of the workflow jobs targeting each environment.",
+		Long:  "Generate a report mapping each environment to the workflow jobs that target it, and list the environments referenced by workflows that do not exist.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(workflowsCmd *cobra.Command, args []string) error {
+			ctx := workflowsCmd.Context()
+			var err error
+
+			repos, err := cmdFlags.repoFilter.Repos(args[1:])
+			if err != nil {
+				return err
+			}
+			filter, err := cmdFlags.repoFilter.Filter()
+			if err != nil {
+				return err
+			}
+			envs, err := cmdFlags.envFilter.Filter()
+			if err != nil {
+				return err
+			}
+
+			clientConfig := utils.ClientConfigFromFlags(workflowsCmd.Flags())
+			clientConfig.Owner = args[0]
+			g, err := utils.NewGetter(clientConfig)
+			if err != nil {
+				return err
+			}
+
+			reportWriter, err := os.Create(cmdFlags.reportFile)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if closeErr := reportWriter.Close(); closeErr != nil {
+					zap.S().Warnf("Error closing file: %v", closeErr)
+				}
+			}()
+
+			return runCmdWorkflows(ctx, args[0], repos, filter, envs, &cmdFlags, g, reportWriter, workflowsCmd.OutOrStdout())
+		},
+	}
+
+	// Determine default report file based on current timestamp; for more info see https://pkg.go.dev/time#pkg-constants
+	reportFileDefault := fmt.Sprintf("report-workflows-%s.csv", time.Now().Format("20060102150405"))
+
+	// Configure flags for command
+	workflowsCmd.Flags().StringVarP(&cmdFlags.reportFile, "output-file", "o", reportFileDefault, "Name of file to write CSV report")
+	cmdFlags.envFilter.AddFlags(workflowsCmd.Flags())
+	cmdFlags.repoFilter.AddFlags(workflowsCmd.Flags())
+
+	return &workflowsCmd
+}
+
+func runCmdWorkflows(ctx context.Context, owner string, repos []string, filter utils.RepoFilter, envs utils.EnvFilter, cmdFlags *cmdFlags, g *utils.APIGetter, reportWriter io.Writer, out io.Writer) error {
+	csvWriter := csv.NewWriter(reportWriter)
+	if err := csvWriter.Write(workflows.TargetReportColumns); err != nil {
+		return err
+	}
+
+	allRepos, err := utils.GatherRepos(ctx, g, owner, repos, filter)
+	if err != nil {
+		if ctx.Err() != nil {
+			return utils.WritePartialReport(ctx, csvWriter, cmdFlags.reportFile)
+		}
+		zap.S().Error("Error raised in gathering repos", zap.Error(err))
+		return err
+	}
+	allRepos, _, err = utils.SelectReposByProperties(ctx, g, owner, allRepos, filter.Properties)
+	if err != nil {
+		if ctx.Err() != nil {
+			return utils.WritePartialReport(ctx, csvWriter, cmdFlags.reportFile)
+		}
+		zap.S().Error("Error raised in gathering repository custom properties", zap.Error(err))
+		return err
+	}
+
+	var missing []workflows.Target
+	var invalid []error
+	tracker := progress.FromContext(ctx)
+	for _, repo := range allRepos {
+		names, err := utils.GatherEnvironmentNames(ctx, g, owner, repo.Name, envs)
+		if err != nil {
+			if ctx.Err() != nil {
+				return utils.WritePartialReport(ctx, csvWriter, cmdFlags.reportFile)
+			}
+			zap.S().Error("Error raised in gathering environments", zap.Error(err))
+			return err
+		}
+		refs, repoInvalid, err := utils.GatherWorkflowReferences(ctx, g, owner, repo.Name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return utils.WritePartialReport(ctx, csvWriter, cmdFlags.reportFile)
+			}
+			zap.S().Error("Error raised in gathering workflows", zap.Error(err))
+			return err
+		}
+		for _, err := range repoInvalid {
+			invalid = append(invalid, fmt.Errorf("%s: %w", repo.Name, err))
+			tracker.Warn("could not parse a workflow in %s: %v", repo.Name, err)
+		}
+
+		for _, target := range workflows.CrossReference(repo.Name, names, refs) {
+			if !target.Exists && !envs.Match(target.Environment) {
+				continue
+			}
+			if target.Missing() {
+				missing = append(missing, target)
+			}
+			if err := csvWriter.Write(target.Row()); err != nil {
+				return err
+			}
+		}
+		tracker.RepositoryProcessed()
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Successfully exported workflow data to csv file: %s\n", cmdFlags.reportFile)
+	return writeMissing(out, missing, invalid)
+}
+
+func writeMissing(out io.Writer, missing []workflows.Target, invalid []error) error {
+	for _, err := range invalid {
+		fmt.Fprintf(out, "Unable to parse workflow in %v\n", err)
+	}
+	if len(missing) == 0 {
+		_, err := fmt.Fprintln(out, "Every environment referenced by a workflow exists")
+		return err
+	}
+
+	fmt.Fprintf(out, "\n%d workflow job(s) target an environment that does not exist and would be created without protection rules:\n\n", len(missing))
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Repository\tEnvironment\tWorkflow\tJob")
+	for _, target := range missing {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", target.Repository, target.Environment, target.Workflow, target.Job)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,97 @@
+package workflows
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/utils"
+)
+
+func TestNewCmdWorkflows(t *testing.T) {
+	cmd := NewCmdWorkflows()
+
+	if cmd.Use != "workflows [flags] <organization> [repo ...]" {
+		t.Errorf("Unexpected Use %q", cmd.Use)
+	}
+	for _, flag := range []string{"output-file", "env", "visibility"} {
+		if cmd.Flag(flag) == nil {
+			t.Errorf("%s flag not found", flag)
+		}
+	}
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("Expected error when no organization is given")
+	}
+}
+
+func TestRunCmdWorkflows(t *testing.T) {
+	ctx := context.Background()
+	server := utils.NewMockGitHubServer()
+	app := server.AddRepo(1, "app")
+	app.Environments["production"] = &utils.MockEnvironment{Name: "production"}
+	app.Environments["staging"] = &utils.MockEnvironment{Name: "staging"}
+	app.Workflows = map[string]string{
+		"deploy.yml": `jobs:
+  build:
+    runs-on: ubuntu-latest
+  production:
+    environment:
+      name: production
+      url: https://example.com
+  qa:
+    environment: qa
+`,
+		"broken.yml": "jobs: [",
+	}
+	server.AddRepo(2, "web")
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report, out bytes.Buffer
+	err = runCmdWorkflows(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{}, &cmdFlags{reportFile: "report.csv"}, g, &report, &out)
+	if err != nil {
+		t.Fatalf("runCmdWorkflows() error = %v", err)
+	}
+
+	records, err := csv.NewReader(&report).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []string
+	for _, record := range records[1:] {
+		rows = append(rows, strings.Join(record, ","))
+	}
+	want := []string{
+		"app,production,true,.github/workflows/deploy.yml,production,false",
+		"app,staging,true,,,false",
+		"app,qa,false,.github/workflows/deploy.yml,qa,false",
+	}
+	if strings.Join(rows, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected report rows:\n%s", strings.Join(rows, "\n"))
+	}
+
+	output := out.String()
+	for _, want := range []string{
+		"Unable to parse workflow in app: .github/workflows/broken.yml",
+		"1 workflow job(s) target an environment that does not exist",
+		"app         qa           .github/workflows/deploy.yml  qa",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	report.Reset()
+	out.Reset()
+	err = runCmdWorkflows(ctx, "testorg", nil, utils.RepoFilter{}, utils.EnvFilter{Globs: []string{"prod*"}}, &cmdFlags{reportFile: "report.csv"}, g, &report, &out)
+	if err != nil {
+		t.Fatalf("runCmdWorkflows() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Every environment referenced by a workflow exists") {
+		t.Errorf("Expected qa to be filtered out, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,207 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"go.uber.org/zap"
+)
+
+// FormatVersion is the archive format version written to the manifest.
+const FormatVersion = 1
+
+const manifestFile = "manifest.json"
+
+// Manifest describes where and when a backup was taken.
+type Manifest struct {
+	FormatVersion int       `json:"format_version"`
+	Host          string    `json:"host"`
+	Organization  string    `json:"organization"`
+	CreatedAt     time.Time `json:"created_at"`
+	ToolVersion   string    `json:"tool_version"`
+	Repositories  []string  `json:"repositories"`
+	Environments  int       `json:"environments"`
+}
+
+// Repository is the content of one repositories/<name>.json file.
+type Repository struct {
+	Name         string                    `json:"name"`
+	ID           int                       `json:"id"`
+	Environments []data.EnvironmentDetails `json:"environments"`
+}
+
+// Archive is a backup of an organization's environments: a gzipped tar of
+// manifest.json and a JSON file per repository.
+type Archive struct {
+	Manifest     Manifest
+	Repositories []Repository
+}
+
+// New groups environments by repository into an archive.
+func New(host string, owner string, toolVersion string, environments []data.EnvironmentDetails, createdAt time.Time) Archive {
+	archive := Archive{Manifest: Manifest{
+		FormatVersion: FormatVersion,
+		Host:          host,
+		Organization:  owner,
+		CreatedAt:     createdAt.UTC(),
+		ToolVersion:   toolVersion,
+		Repositories:  []string{},
+		Environments:  len(environments),
+	}}
+
+	index := make(map[string]int)
+	for _, env := range environments {
+		i, ok := index[env.Repository]
+		if !ok {
+			i = len(archive.Repositories)
+			index[env.Repository] = i
+			archive.Repositories = append(archive.Repositories, Repository{Name: env.Repository, ID: env.RepositoryID})
+		}
+		archive.Repositories[i].Environments = append(archive.Repositories[i].Environments, env)
+	}
+	sort.Slice(archive.Repositories, func(i, j int) bool { return archive.Repositories[i].Name < archive.Repositories[j].Name })
+	for _, repo := range archive.Repositories {
+		archive.Manifest.Repositories = append(archive.Manifest.Repositories, repo.Name)
+	}
+	return archive
+}
+
+// Write stores the archive as a gzipped tar.
+func (a Archive) Write(out io.Writer) error {
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	writeFile := func(name string, v interface{}) error {
+		content, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: a.Manifest.CreatedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err = tw.Write(content)
+		return err
+	}
+
+	if err := writeFile(manifestFile, a.Manifest); err != nil {
+		return err
+	}
+	for _, repo := range a.Repositories {
+		if err := writeFile(path.Join("repositories", repo.Name+".json"), repo); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// WriteFile writes the archive to a temporary file next to fileName and
+// renames it to fileName once it is complete, so a failed backup never leaves
+// a truncated archive behind.
+func (a Archive) WriteFile(fileName string) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(fileName), filepath.Base(fileName)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if removeErr := os.Remove(f.Name()); removeErr != nil && !os.IsNotExist(removeErr) {
+			zap.S().Warnf("Error removing file: %v", removeErr)
+		}
+	}()
+
+	if err := a.Write(f); err != nil {
+		if closeErr := f.Close(); closeErr != nil {
+			zap.S().Warnf("Error closing file: %v", closeErr)
+		}
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), fileName)
+}
+
+// Read loads an archive written by Write. Every repository listed in the
+// manifest must be present.
+func Read(fileName string) (Archive, error) {
+	var archive Archive
+	f, err := os.Open(fileName)
+	if err != nil {
+		return archive, err
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil {
+			zap.S().Warnf("Error closing file: %v", closeErr)
+		}
+	}()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return archive, fmt.Errorf("%s: %w", fileName, err)
+	}
+	tr := tar.NewReader(gz)
+
+	foundManifest := false
+	repos := make(map[string]Repository)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return archive, fmt.Errorf("%s: %w", fileName, err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return archive, fmt.Errorf("%s: %w", fileName, err)
+		}
+		switch {
+		case header.Name == manifestFile:
+			if err := json.Unmarshal(content, &archive.Manifest); err != nil {
+				return archive, fmt.Errorf("%s: %s: %w", fileName, header.Name, err)
+			}
+			foundManifest = true
+		case path.Dir(header.Name) == "repositories" && path.Ext(header.Name) == ".json":
+			var repo Repository
+			if err := json.Unmarshal(content, &repo); err != nil {
+				return archive, fmt.Errorf("%s: %s: %w", fileName, header.Name, err)
+			}
+			repos[repo.Name] = repo
+		}
+	}
+
+	if !foundManifest {
+		return archive, fmt.Errorf("%s: no %s found, not an environments backup", fileName, manifestFile)
+	}
+	if archive.Manifest.FormatVersion < 1 || archive.Manifest.FormatVersion > FormatVersion {
+		return archive, fmt.Errorf("%s: unsupported backup format version %d, expected %d", fileName, archive.Manifest.FormatVersion, FormatVersion)
+	}
+	for _, name := range archive.Manifest.Repositories {
+		repo, ok := repos[name]
+		if !ok {
+			return archive, fmt.Errorf("%s: repository %s is listed in the manifest but missing", fileName, name)
+		}
+		archive.Repositories = append(archive.Repositories, repo)
+	}
+	return archive, nil
+}
@@ -0,0 +1,131 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+var taken = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func sampleArchive() Archive {
+	return New("github.com", "testorg", "v1.2.3", []data.EnvironmentDetails{
+		{Repository: "web", RepositoryID: 2, Name: "staging"},
+		{Repository: "app", RepositoryID: 1, Name: "production", WaitTimer: 5,
+			Variables: []data.Variable{{Name: "REGION", Value: "eu"}},
+			Secrets:   []data.Secret{{Name: "TOKEN"}}},
+		{Repository: "app", RepositoryID: 1, Name: "dev"},
+	}, taken)
+}
+
+func TestNew(t *testing.T) {
+	archive := sampleArchive()
+
+	manifest := archive.Manifest
+	if manifest.FormatVersion != FormatVersion || manifest.Host != "github.com" || manifest.Organization != "testorg" || manifest.ToolVersion != "v1.2.3" || !manifest.CreatedAt.Equal(taken) {
+		t.Errorf("Unexpected manifest %+v", manifest)
+	}
+	if !reflect.DeepEqual(manifest.Repositories, []string{"app", "web"}) || manifest.Environments != 3 {
+		t.Errorf("Unexpected manifest contents %+v", manifest)
+	}
+	if len(archive.Repositories[0].Environments) != 2 || archive.Repositories[0].ID != 1 {
+		t.Errorf("Expected app to hold 2 environments, got %+v", archive.Repositories[0])
+	}
+}
+
+func writeArchive(t *testing.T, archive Archive) string {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), "backup.tar.gz")
+	var buf bytes.Buffer
+	if err := archive.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fileName, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return fileName
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "backup.tar.gz")
+	if err := sampleArchive().WriteFile(fileName); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 1 {
+		t.Errorf("Expected only the archive to be written, got %v", entries)
+	}
+	if _, err := Read(fileName); err != nil {
+		t.Errorf("Expected a complete archive, got %v", err)
+	}
+
+	if err := sampleArchive().WriteFile(filepath.Join(dir, "missing", "backup.tar.gz")); err == nil {
+		t.Error("Expected an error writing to a missing directory")
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	archive := sampleArchive()
+	fileName := writeArchive(t, archive)
+
+	read, err := Read(fileName)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if !reflect.DeepEqual(read, archive) {
+		t.Errorf("Archive changed on round trip:\n%+v\n%+v", read, archive)
+	}
+
+	// The archive holds a manifest and a file per repository
+	f, err := os.Open(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, header.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"manifest.json", "repositories/app.json", "repositories/web.json"}) {
+		t.Errorf("Unexpected archive files %v", names)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	archive := sampleArchive()
+	archive.Manifest.Repositories = append(archive.Manifest.Repositories, "missing")
+	if _, err := Read(writeArchive(t, archive)); err == nil || !strings.Contains(err.Error(), "repository missing is listed in the manifest but missing") {
+		t.Errorf("Expected missing repository error, got %v", err)
+	}
+
+	archive = sampleArchive()
+	archive.Manifest.FormatVersion = FormatVersion + 1
+	if _, err := Read(writeArchive(t, archive)); err == nil || !strings.Contains(err.Error(), "unsupported backup format version") {
+		t.Errorf("Expected version error, got %v", err)
+	}
+
+	notArchive := filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(notArchive, []byte("RepositoryName\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(notArchive); err == nil {
+		t.Error("Expected error for a file that is not an archive")
+	}
+}
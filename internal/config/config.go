@@ -0,0 +1,357 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// RepoFileName is the configuration file looked for in the current directory
+// and its parents, up to the root of the git repository.
+const RepoFileName = ".gh-environments.yml"
+
+// profileAnnotation marks flags whose value was set from a profile.
+const profileAnnotation = "gh-environments/profile"
+
+// tokenSourceAnnotation holds the token source of the profile on the --token
+// flag, read only once a client needs the token.
+const tokenSourceAnnotation = "gh-environments/token-source"
+
+// File is a configuration file holding named profiles.
+type File struct {
+	DefaultProfile string             `yaml:"default_profile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+// Profile holds default values for the flags of every command. Flags given
+// on the command line take precedence.
+type Profile struct {
+	Hostname string `yaml:"hostname,omitempty"`
+	// Org is the organization used when a command is given none
+	Org string `yaml:"org,omitempty"`
+	// Token is where the token is read from: gh, env:NAME or file:PATH
+	Token       string  `yaml:"token,omitempty"`
+	Format      string  `yaml:"format,omitempty"`
+	Concurrency int     `yaml:"concurrency,omitempty"`
+	Filters     Filters `yaml:"filters,omitempty"`
+}
+
+// Filters are the repository and environment filters applied by default.
+type Filters struct {
+	Env             []string `yaml:"env,omitempty"`
+	EnvRegex        string   `yaml:"env_regex,omitempty"`
+	Visibility      string   `yaml:"visibility,omitempty"`
+	Topics          []string `yaml:"topics,omitempty"`
+	NameRegex       string   `yaml:"name_regex,omitempty"`
+	ExcludeArchived bool     `yaml:"exclude_archived,omitempty"`
+	ExcludeForks    bool     `yaml:"exclude_forks,omitempty"`
+	Properties      []string `yaml:"properties,omitempty"`
+}
+
+// Selection is the profile chosen for a run and the files it was read from.
+type Selection struct {
+	Name    string
+	Profile Profile
+	Paths   []string
+}
+
+// UserPath returns the path of the user configuration file,
+// $XDG_CONFIG_HOME/gh-environments/config.yml or
+// ~/.config/gh-environments/config.yml.
+func UserPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "gh-environments", "config.yml"), nil
+}
+
+// RepoPath returns the path of the repository configuration file found in
+// dir or its parents, stopping at the root of the git repository, or "" when
+// there is none.
+func RepoPath(dir string) string {
+	for {
+		candidate := filepath.Join(dir, RepoFileName)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// LoadFile reads and validates a configuration file.
+func LoadFile(fileName string) (File, error) {
+	var file File
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return file, err
+	}
+	decoder := yaml.NewDecoder(strings.NewReader(string(content)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return file, fmt.Errorf("%s: %w", fileName, err)
+	}
+	for name, profile := range file.Profiles {
+		if err := profile.Validate(); err != nil {
+			return file, fmt.Errorf("%s: profile %s: %w", fileName, name, err)
+		}
+	}
+	return file, nil
+}
+
+// Load reads the given configuration files that exist, later files
+// overriding the profile settings of earlier ones, and selects the profile
+// called name, or the default profile when name is empty. Without a default
+// profile, the one called "default" is used if it exists.
+func Load(name string, fileNames ...string) (Selection, error) {
+	var selection Selection
+	merged := File{Profiles: make(map[string]Profile)}
+	for _, fileName := range fileNames {
+		if fileName == "" {
+			continue
+		}
+		file, err := LoadFile(fileName)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return selection, err
+		}
+		selection.Paths = append(selection.Paths, fileName)
+		if file.DefaultProfile != "" {
+			merged.DefaultProfile = file.DefaultProfile
+		}
+		for profileName, profile := range file.Profiles {
+			merged.Profiles[profileName] = merged.Profiles[profileName].merge(profile)
+		}
+	}
+
+	selection.Name = name
+	if selection.Name == "" {
+		selection.Name = merged.DefaultProfile
+	}
+	if selection.Name == "" {
+		if _, ok := merged.Profiles["default"]; !ok {
+			return selection, nil
+		}
+		selection.Name = "default"
+	}
+	profile, ok := merged.Profiles[selection.Name]
+	if !ok {
+		if len(selection.Paths) == 0 {
+			return selection, fmt.Errorf("profile %q not found, no configuration file exists", selection.Name)
+		}
+		return selection, fmt.Errorf("profile %q not found in %s", selection.Name, strings.Join(selection.Paths, ", "))
+	}
+	selection.Profile = profile
+	return selection, nil
+}
+
+// Select loads the user configuration file and the repository one found from
+// the current directory, and selects the profile called name.
+func Select(name string) (Selection, error) {
+	userPath, err := UserPath()
+	if err != nil {
+		return Selection{}, err
+	}
+	var repoPath string
+	if dir, err := os.Getwd(); err == nil {
+		repoPath = RepoPath(dir)
+	}
+	return Load(name, userPath, repoPath)
+}
+
+// Validate checks the token source and concurrency of the profile.
+func (p Profile) Validate() error {
+	if p.Concurrency < 0 {
+		return errors.New("concurrency cannot be negative")
+	}
+	if _, _, err := parseTokenSource(p.Token); err != nil {
+		return err
+	}
+	return nil
+}
+
+// merge returns p with the settings of override that are set.
+func (p Profile) merge(override Profile) Profile {
+	if override.Hostname != "" {
+		p.Hostname = override.Hostname
+	}
+	if override.Org != "" {
+		p.Org = override.Org
+	}
+	if override.Token != "" {
+		p.Token = override.Token
+	}
+	if override.Format != "" {
+		p.Format = override.Format
+	}
+	if override.Concurrency != 0 {
+		p.Concurrency = override.Concurrency
+	}
+	if len(override.Filters.Env) > 0 {
+		p.Filters.Env = override.Filters.Env
+	}
+	if override.Filters.EnvRegex != "" {
+		p.Filters.EnvRegex = override.Filters.EnvRegex
+	}
+	if override.Filters.Visibility != "" {
+		p.Filters.Visibility = override.Filters.Visibility
+	}
+	if len(override.Filters.Topics) > 0 {
+		p.Filters.Topics = override.Filters.Topics
+	}
+	if override.Filters.NameRegex != "" {
+		p.Filters.NameRegex = override.Filters.NameRegex
+	}
+	if override.Filters.ExcludeArchived {
+		p.Filters.ExcludeArchived = true
+	}
+	if override.Filters.ExcludeForks {
+		p.Filters.ExcludeForks = true
+	}
+	if len(override.Filters.Properties) > 0 {
+		p.Filters.Properties = override.Filters.Properties
+	}
+	return p
+}
+
+// parseTokenSource splits a token source into its kind, gh, env or file, and
+// its argument.
+func parseTokenSource(source string) (string, string, error) {
+	if source == "" || source == "gh" {
+		return "gh", "", nil
+	}
+	kind, arg, ok := strings.Cut(source, ":")
+	if !ok || arg == "" || (kind != "env" && kind != "file") {
+		return "", "", fmt.Errorf("invalid token source %q, expected gh, env:NAME or file:PATH", source)
+	}
+	return kind, arg, nil
+}
+
+// ResolveToken reads the token from the source of the profile. It returns ""
+// for gh, leaving the token to the gh CLI.
+func (p Profile) ResolveToken() (string, error) {
+	kind, arg, err := parseTokenSource(p.Token)
+	if err != nil {
+		return "", err
+	}
+	switch kind {
+	case "env":
+		token := os.Getenv(arg)
+		if token == "" {
+			return "", fmt.Errorf("token source %s: environment variable %s is not set", p.Token, arg)
+		}
+		return token, nil
+	case "file":
+		content, err := os.ReadFile(arg)
+		if err != nil {
+			return "", fmt.Errorf("token source %s: %w", p.Token, err)
+		}
+		return strings.TrimSpace(string(content)), nil
+	}
+	return "", nil
+}
+
+// Apply sets the flags of the profile's settings that exist in flags and
+// were not given on the command line. The token source is recorded on the
+// --token flag for TokenSource rather than read, so commands that connect to
+// no API run without it.
+func (p Profile) Apply(flags *pflag.FlagSet) error {
+	values := map[string][]string{
+		"hostname":         nonEmpty(p.Hostname),
+		"format":           nonEmpty(p.Format),
+		"env":              p.Filters.Env,
+		"env-regex":        nonEmpty(p.Filters.EnvRegex),
+		"visibility":       nonEmpty(p.Filters.Visibility),
+		"name-regex":       nonEmpty(p.Filters.NameRegex),
+		"property":         p.Filters.Properties,
+		"exclude-archived": nonFalse(p.Filters.ExcludeArchived),
+		"exclude-forks":    nonFalse(p.Filters.ExcludeForks),
+	}
+	if len(p.Filters.Topics) > 0 {
+		values["topic"] = []string{strings.Join(p.Filters.Topics, ",")}
+	}
+	if p.Concurrency > 0 {
+		values["concurrency"] = []string{strconv.Itoa(p.Concurrency)}
+	}
+	// A token would conflict with GitHub App authentication
+	if flag := flags.Lookup("token"); flag != nil && !flag.Changed && !flags.Changed("app-id") && p.Token != "" {
+		if err := flags.SetAnnotation("token", tokenSourceAnnotation, []string{p.Token}); err != nil {
+			return err
+		}
+	}
+
+	for name, settings := range values {
+		flag := flags.Lookup(name)
+		if flag == nil || flag.Changed || len(settings) == 0 {
+			continue
+		}
+		for _, setting := range settings {
+			if err := flags.Set(name, setting); err != nil {
+				return fmt.Errorf("profile setting for --%s: %w", name, err)
+			}
+		}
+		if err := flags.SetAnnotation(name, profileAnnotation, []string{"true"}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromProfile reports whether the flag was set by Apply rather than on the
+// command line.
+func FromProfile(flag *pflag.Flag) bool {
+	_, ok := flag.Annotations[profileAnnotation]
+	return ok
+}
+
+// Explicit reports whether the flag called name was given on the command
+// line. Flags set by Apply are marked Changed as well, so Changed alone does
+// not tell them apart.
+func Explicit(flags *pflag.FlagSet, name string) bool {
+	flag := flags.Lookup(name)
+	return flag != nil && flag.Changed && !FromProfile(flag)
+}
+
+// TokenSource returns the token source Apply recorded on the --token flag,
+// or "" when there is none.
+func TokenSource(flags *pflag.FlagSet) string {
+	flag := flags.Lookup("token")
+	if flag == nil || len(flag.Annotations[tokenSourceAnnotation]) == 0 {
+		return ""
+	}
+	return flag.Annotations[tokenSourceAnnotation][0]
+}
+
+func nonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+func nonFalse(value bool) []string {
+	if !value {
+		return nil
+	}
+	return []string{"true"}
+}
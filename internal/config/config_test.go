@@ -0,0 +1,217 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	fileName := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fileName, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return fileName
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	user := writeFile(t, dir, "config.yml", `
+default_profile: work
+profiles:
+  work:
+    hostname: github.example.com
+    org: acme
+    token: env:GHE_TOKEN
+    concurrency: 4
+    filters:
+      env: ["prod*"]
+      exclude_archived: true
+  personal:
+    org: octocat
+`)
+	repo := writeFile(t, dir, RepoFileName, `
+profiles:
+  work:
+    format: json
+    filters:
+      env: ["staging"]
+`)
+
+	selection, err := Load("", user, repo, filepath.Join(dir, "missing.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Profile{
+		Hostname:    "github.example.com",
+		Org:         "acme",
+		Token:       "env:GHE_TOKEN",
+		Format:      "json",
+		Concurrency: 4,
+		Filters:     Filters{Env: []string{"staging"}, ExcludeArchived: true},
+	}
+	if selection.Name != "work" || !reflect.DeepEqual(selection.Profile, want) {
+		t.Errorf("Load() = %s %+v, want work %+v", selection.Name, selection.Profile, want)
+	}
+	if !reflect.DeepEqual(selection.Paths, []string{user, repo}) {
+		t.Errorf("Expected the existing files to be read, got %v", selection.Paths)
+	}
+
+	selection, err = Load("personal", user, repo)
+	if err != nil || selection.Profile.Org != "octocat" {
+		t.Errorf("Load(personal) = %+v, %v", selection, err)
+	}
+
+	if _, err := Load("missing", user); err == nil {
+		t.Error("Expected error for an unknown profile")
+	}
+	if selection, err := Load("", filepath.Join(dir, "missing.yml")); err != nil || selection.Name != "" {
+		t.Errorf("Expected no profile without configuration files, got %+v, %v", selection, err)
+	}
+}
+
+func TestLoadDefaultProfile(t *testing.T) {
+	fileName := writeFile(t, t.TempDir(), "config.yml", "profiles:\n  default:\n    org: acme\n")
+	selection, err := Load("", fileName)
+	if err != nil || selection.Name != "default" || selection.Profile.Org != "acme" {
+		t.Errorf("Expected the profile called default, got %+v, %v", selection, err)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"unknown.yml":     "profiles:\n  work:\n    organisation: acme\n",
+		"token.yml":       "profiles:\n  work:\n    token: vault:secret\n",
+		"concurrency.yml": "profiles:\n  work:\n    concurrency: -1\n",
+		"default.yml":     "default_profile: work\n",
+	}
+	for name, content := range tests {
+		fileName := writeFile(t, dir, name, content)
+		if _, err := Load("", fileName); err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+}
+
+func TestRepoPath(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got := RepoPath(nested); got != "" {
+		t.Errorf("Expected no repository file, got %s", got)
+	}
+
+	fileName := writeFile(t, root, RepoFileName, "")
+	if got := RepoPath(nested); got != fileName {
+		t.Errorf("RepoPath() = %s, want %s", got, fileName)
+	}
+
+	// The search stops at the root of the git repository
+	if err := os.Mkdir(filepath.Join(root, "a", ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got := RepoPath(nested); got != "" {
+		t.Errorf("Expected the search to stop at the git repository, got %s", got)
+	}
+}
+
+func TestUserPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/config")
+	if got, err := UserPath(); err != nil || got != filepath.Join("/config", "gh-environments", "config.yml") {
+		t.Errorf("UserPath() = %s, %v", got, err)
+	}
+}
+
+func TestResolveToken(t *testing.T) {
+	t.Setenv("PROFILE_TOKEN", "from-env")
+	tokenFile := writeFile(t, t.TempDir(), "token", "from-file\n")
+
+	tests := []struct {
+		source  string
+		want    string
+		wantErr bool
+	}{
+		{source: "", want: ""},
+		{source: "gh", want: ""},
+		{source: "env:PROFILE_TOKEN", want: "from-env"},
+		{source: "env:UNSET_PROFILE_TOKEN", wantErr: true},
+		{source: "file:" + tokenFile, want: "from-file"},
+		{source: "file:" + tokenFile + ".missing", wantErr: true},
+		{source: "env:", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Profile{Token: tt.source}.ResolveToken()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ResolveToken(%q) = %q, %v", tt.source, got, err)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	profile := Profile{
+		Hostname:    "github.example.com",
+		Token:       "env:PROFILE_TOKEN",
+		Format:      "json",
+		Concurrency: 4,
+		Filters:     Filters{Env: []string{"prod*", "staging"}, Topics: []string{"a", "b"}, ExcludeForks: true},
+	}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	hostname := flags.String("hostname", "github.com", "")
+	token := flags.String("token", "", "")
+	format := flags.String("format", "table", "")
+	concurrency := flags.Int("concurrency", 1, "")
+	envs := flags.StringArray("env", nil, "")
+	topics := flags.StringSlice("topic", nil, "")
+	excludeForks := flags.Bool("exclude-forks", false, "")
+	if err := flags.Parse([]string{"--format", "table"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := profile.Apply(flags); err != nil {
+		t.Fatal(err)
+	}
+	if *hostname != "github.example.com" || *concurrency != 4 || !*excludeForks {
+		t.Errorf("Expected the profile settings, got %s %d %v", *hostname, *concurrency, *excludeForks)
+	}
+	// The token source is recorded, not read, so PROFILE_TOKEN need not be set
+	if *token != "" || TokenSource(flags) != "env:PROFILE_TOKEN" {
+		t.Errorf("Expected the token source to be recorded, got %q %q", *token, TokenSource(flags))
+	}
+	if !reflect.DeepEqual(*envs, []string{"prod*", "staging"}) || !reflect.DeepEqual(*topics, []string{"a", "b"}) {
+		t.Errorf("Expected the profile filters, got %v %v", *envs, *topics)
+	}
+	if *format != "table" {
+		t.Errorf("Expected --format to override the profile, got %s", *format)
+	}
+	if !FromProfile(flags.Lookup("hostname")) || FromProfile(flags.Lookup("format")) {
+		t.Error("Expected only flags set from the profile to be marked")
+	}
+	// Flags set from the profile are Changed, but not given explicitly
+	if !flags.Changed("hostname") || Explicit(flags, "hostname") || !Explicit(flags, "format") || Explicit(flags, "missing") {
+		t.Error("Expected only flags given on the command line to be explicit")
+	}
+}
+
+func TestApplyWithApp(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	token := flags.String("token", "", "")
+	flags.Int64("app-id", 0, "")
+	if err := flags.Parse([]string{"--app-id", "12"}); err != nil {
+		t.Fatal(err)
+	}
+	// The token source is not read when authenticating as a GitHub App
+	if err := (Profile{Token: "env:UNSET_PROFILE_TOKEN"}).Apply(flags); err != nil || *token != "" || TokenSource(flags) != "" {
+		t.Errorf("Apply() = %v, token %q from %q", err, *token, TokenSource(flags))
+	}
+}
@@ -0,0 +1,37 @@
+package data
+
+import "time"
+
+type WorkflowRun struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	RunNumber  int       `json:"run_number"`
+	HeadBranch string    `json:"head_branch"`
+	HeadSHA    string    `json:"head_sha"`
+	Event      string    `json:"event"`
+	Actor      User      `json:"actor"`
+	CreatedAt  time.Time `json:"created_at"`
+	HTMLURL    string    `json:"html_url"`
+}
+
+type WorkflowRunsResponse struct {
+	TotalCount   int           `json:"total_count"`
+	WorkflowRuns []WorkflowRun `json:"workflow_runs"`
+}
+
+type PendingDeployment struct {
+	Environment struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"environment"`
+	WaitTimer             int         `json:"wait_timer"`
+	WaitTimerStartedAt    *time.Time  `json:"wait_timer_started_at"`
+	CurrentUserCanApprove bool        `json:"current_user_can_approve"`
+	Reviewers             []Reviewers `json:"reviewers"`
+}
+
+type ReviewPendingDeployments struct {
+	EnvironmentIDs []int  `json:"environment_ids"`
+	State          string `json:"state"`
+	Comment        string `json:"comment"`
+}
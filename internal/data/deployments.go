@@ -0,0 +1,19 @@
+package data
+
+import "time"
+
+type Deployment struct {
+	ID          int       `json:"id"`
+	SHA         string    `json:"sha"`
+	Ref         string    `json:"ref"`
+	Environment string    `json:"environment"`
+	Creator     User      `json:"creator"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type DeploymentStatus struct {
+	ID        int       `json:"id"`
+	State     string    `json:"state"`
+	Creator   User      `json:"creator"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -5,6 +5,18 @@ type BranchPolicies struct {
 	BranchPolicies []BranchPolicy `json:"branch_policies"`
 }
 
+type AvailableDeploymentApp struct {
+	ID             int    `json:"id"`
+	Slug           string `json:"slug"`
+	IntegrationURL string `json:"integration_url"`
+	NodeID         string `json:"node_id"`
+}
+
+type AvailableDeploymentApps struct {
+	TotalCount int                      `json:"total_count"`
+	Apps       []AvailableDeploymentApp `json:"available_custom_deployment_protection_rule_integrations"`
+}
+
 type BranchPolicy struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
@@ -12,12 +24,17 @@ type BranchPolicy struct {
 }
 
 type CreateEnvironment struct {
+	CanAdminsBypass        *bool             `json:"can_admins_bypass,omitempty"`
 	WaitTimer              int               `json:"wait_timer"`
 	PreventSelfReview      bool              `json:"prevent_self_review"`
 	Reviewers              []CreateReviewer  `json:"reviewers"`
 	DeploymentBranchPolicy *DeploymentPolicy `json:"deployment_branch_policy"`
 }
 
+type CreateDeploymentProtectionRule struct {
+	IntegrationID int `json:"integration_id"`
+}
+
 type CreateReviewer struct {
 	Type string `json:"type"`
 	ID   int    `json:"id"`
@@ -71,6 +88,7 @@ type ImportedEnvironment struct {
 	PreventSelfReview bool
 	DeploymentPolicy  string
 	Branches          []CreateDeploymentBranch
+	ProtectionRules   []DeploymentProtectionPolicyApp
 }
 
 type Rules struct {
@@ -87,5 +105,27 @@ type Reviewers struct {
 
 type Reviewer struct {
 	Login string `json:"login"`
-	ID    int    `json:"id"`
+	// Slug is only set for team reviewers
+	Slug string `json:"slug,omitempty"`
+	ID   int    `json:"id"`
+}
+
+// EnvironmentDetails combines an environment with the settings that are
+// fetched separately for it, as gathered by the list pipeline.
+type EnvironmentDetails struct {
+	Organization      string                          `json:"organization"`
+	Repository        string                          `json:"repository"`
+	RepositoryID      int                             `json:"repository_id"`
+	Name              string                          `json:"name"`
+	AdminBypass       bool                            `json:"admin_bypass"`
+	WaitTimer         int                             `json:"wait_timer"`
+	PreventSelfReview bool                            `json:"prevent_self_review"`
+	Reviewers         []Reviewers                     `json:"reviewers"`
+	BranchPolicyType  string                          `json:"branch_policy_type"`
+	BranchPolicies    []BranchPolicy                  `json:"branch_policies"`
+	ProtectionRules   []DeploymentProtectionPolicyApp `json:"protection_rules"`
+	SecretsCount      int                             `json:"secrets_count"`
+	VariablesCount    int                             `json:"variables_count"`
+	Secrets           []Secret                        `json:"secrets,omitempty"`
+	Variables         []Variable                      `json:"variables,omitempty"`
 }
@@ -2,23 +2,42 @@ package data
 
 import "time"
 
+type PageInfo struct {
+	EndCursor   string
+	HasNextPage bool
+}
+
+type RepoConnection struct {
+	TotalCount int
+	Nodes      []RepoInfo
+	PageInfo   PageInfo
+}
+
 type RepoInfo struct {
-	DatabaseId int       `json:"databaseId"`
-	Name       string    `json:"name"`
-	UpdatedAt  time.Time `json:"updatedAt"`
-	Visibility string    `json:"visibility"`
+	DatabaseId       int              `json:"databaseId"`
+	Name             string           `json:"name"`
+	UpdatedAt        time.Time        `json:"updatedAt"`
+	Visibility       string           `json:"visibility"`
+	IsArchived       bool             `json:"isArchived"`
+	IsFork           bool             `json:"isFork"`
+	RepositoryTopics RepositoryTopics `json:"repositoryTopics" graphql:"repositoryTopics(first: 20)"`
+}
+
+// RepositoryPrivacy is named to match the GraphQL enum used to filter
+// repositories by privacy.
+type RepositoryPrivacy string
+
+type RepositoryTopics struct {
+	Nodes []struct {
+		Topic struct {
+			Name string `json:"name"`
+		} `json:"topic"`
+	} `json:"nodes"`
 }
 
 type ReposQuery struct {
 	Organization struct {
-		Repositories struct {
-			TotalCount int
-			Nodes      []RepoInfo
-			PageInfo   struct {
-				EndCursor   string
-				HasNextPage bool
-			}
-		} `graphql:"repositories(first: 100, after: $endCursor)"`
+		Repositories RepoConnection `graphql:"repositories(first: 100, after: $endCursor, isFork: $isFork, isArchived: $isArchived, privacy: $privacy)"`
 	} `graphql:"organization(login: $owner)"`
 }
 
@@ -35,3 +54,26 @@ type ScopedResponse struct {
 	TotalCount   int                `json:"total_count"`
 	Repositories []ScopedRepository `json:"repositories"`
 }
+
+type CustomPropertyValue struct {
+	PropertyName string      `json:"property_name"`
+	Value        interface{} `json:"value"`
+}
+
+type RepoCustomPropertyValues struct {
+	RepositoryID       int                   `json:"repository_id"`
+	RepositoryName     string                `json:"repository_name"`
+	RepositoryFullName string                `json:"repository_full_name"`
+	Properties         []CustomPropertyValue `json:"properties"`
+}
+
+type EnterpriseOrgsQuery struct {
+	Enterprise struct {
+		Organizations struct {
+			Nodes []struct {
+				Login string
+			}
+			PageInfo PageInfo
+		} `graphql:"organizations(first: 100, after: $endCursor)"`
+	} `graphql:"enterprise(slug: $slug)"`
+}
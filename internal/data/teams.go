@@ -0,0 +1,16 @@
+package data
+
+import "time"
+
+type Team struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type User struct {
+	ID    int    `json:"id"`
+	Login string `json:"login"`
+	// SuspendedAt is only returned by GitHub Enterprise Server
+	SuspendedAt *time.Time `json:"suspended_at,omitempty"`
+}
@@ -0,0 +1,81 @@
+// Package exitcode defines the exit status of each kind of failure, so
+// scripts running the extension can tell them apart.
+package exitcode
+
+import (
+	"errors"
+)
+
+const (
+	// Success is returned when the run completed without errors
+	Success = 0
+	// Failure is returned when the run failed without completing any work
+	Failure = 1
+	// PartialFailure is returned when some of the work failed or was cut
+	// short by an interrupt or --timeout
+	PartialFailure = 2
+	// ValidationFailure is returned for invalid flags, arguments or input files
+	ValidationFailure = 3
+	// PolicyViolation is returned when lint or drift detection finds
+	// violations
+	PolicyViolation = 4
+)
+
+// Error is an error returned with a specific exit code.
+type Error struct {
+	Code int
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns err to be exited with code, or nil when err is nil.
+func New(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Failures returns err to be exited with PartialFailure when only some of
+// total items failed, and with Failure when all of them did.
+func Failures(failed int, total int, err error) error {
+	if failed < total {
+		return New(PartialFailure, err)
+	}
+	return New(Failure, err)
+}
+
+// Code returns the exit code for err: Success when it is nil, the code it was
+// returned with, or Failure.
+func Code(err error) int {
+	if err == nil {
+		return Success
+	}
+	var exitErr *Error
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return Failure
+}
+
+// Status names the outcome of a run exiting with code.
+func Status(code int) string {
+	switch code {
+	case Success:
+		return "success"
+	case PartialFailure:
+		return "partial_failure"
+	case ValidationFailure:
+		return "validation_failure"
+	case PolicyViolation:
+		return "policy_violation"
+	}
+	return "failure"
+}
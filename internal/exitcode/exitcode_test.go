@@ -0,0 +1,51 @@
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCode(t *testing.T) {
+	failed := errors.New("failed")
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, Success},
+		{"plain error", failed, Failure},
+		{"validation", New(ValidationFailure, failed), ValidationFailure},
+		{"wrapped", fmt.Errorf("context: %w", New(PolicyViolation, failed)), PolicyViolation},
+		{"some failed", Failures(1, 3, failed), PartialFailure},
+		{"all failed", Failures(3, 3, failed), Failure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Code(tt.err); got != tt.want {
+				t.Errorf("Code() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if New(PartialFailure, nil) != nil {
+		t.Error("Expected no error for a nil error")
+	}
+	if err := New(ValidationFailure, failed); !errors.Is(err, failed) || err.Error() != "failed" {
+		t.Errorf("Expected the error to be wrapped unchanged, got %v", err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	for code, want := range map[int]string{
+		Success:           "success",
+		Failure:           "failure",
+		PartialFailure:    "partial_failure",
+		ValidationFailure: "validation_failure",
+		PolicyViolation:   "policy_violation",
+	} {
+		if got := Status(code); got != want {
+			t.Errorf("Status(%d) = %q, want %q", code, got, want)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+// Package githubapp authenticates as a GitHub App installation. It signs JWTs
+// with the app's private key, exchanges them for installation tokens, and
+// refreshes those tokens before they expire.
+package githubapp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// jwtLifetime stays under the ten minute maximum GitHub accepts
+	jwtLifetime = 9 * time.Minute
+	// jwtClockSkew backdates iat so clocks running ahead of GitHub's are
+	// accepted
+	jwtClockSkew = 60 * time.Second
+	// refreshBefore is how long before expiry a token is replaced, so that
+	// requests in flight never use an expired token
+	refreshBefore = 5 * time.Minute
+)
+
+// Config selects the app and, optionally, the installation to authenticate
+// as. Without an installation ID, the installation is looked up for each
+// organization.
+type Config struct {
+	AppID          int64
+	PrivateKeyPath string
+	InstallationID int64
+}
+
+// Enabled reports whether any app setting was given.
+func (c Config) Enabled() bool {
+	return c.AppID != 0 || c.PrivateKeyPath != "" || c.InstallationID != 0
+}
+
+// Validate checks that the app ID and private key are given together.
+func (c Config) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.AppID == 0 {
+		return errors.New("--app-id is required for GitHub App authentication")
+	}
+	if c.PrivateKeyPath == "" {
+		return errors.New("--private-key is required with --app-id")
+	}
+	return nil
+}
+
+// LoadPrivateKey reads a PEM encoded RSA private key, as downloaded from the
+// app's settings, in PKCS #1 or PKCS #8 form.
+func LoadPrivateKey(path string) (*rsa.PrivateKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM encoded private key found", path)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: private key is not an RSA key", path)
+	}
+	return key, nil
+}
+
+// NewJWT returns a token that authenticates as the app itself.
+func NewJWT(appID int64, key *rsa.PrivateKey, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-jwtClockSkew).Unix(),
+		"exp": now.Add(jwtLifetime).Unix(),
+		"iss": strconv.FormatInt(appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// APIURL returns the REST API root of hostname.
+func APIURL(hostname string) string {
+	hostname = strings.ToLower(hostname)
+	if hostname == "github.com" || strings.HasSuffix(hostname, ".ghe.com") {
+		return "https://api." + hostname
+	}
+	return "https://" + hostname + "/api/v3"
+}
+
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// TokenSource mints installation tokens, caching each until shortly before it
+// expires. It is safe for concurrent use.
+type TokenSource struct {
+	appID          int64
+	key            *rsa.PrivateKey
+	installationID int64
+	apiURL         string
+	client         *http.Client
+	now            func() time.Time
+
+	mu            sync.Mutex
+	installations map[string]int64
+	tokens        map[int64]installationToken
+}
+
+// NewTokenSource loads the app's private key. Requests for new tokens are
+// sent through transport, or http.DefaultTransport when it is nil.
+func NewTokenSource(cfg Config, hostname string, transport http.RoundTripper) (*TokenSource, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	key, err := LoadPrivateKey(cfg.PrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &TokenSource{
+		appID:          cfg.AppID,
+		key:            key,
+		installationID: cfg.InstallationID,
+		apiURL:         APIURL(hostname),
+		client:         &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		now:            time.Now,
+		installations:  make(map[string]int64),
+		tokens:         make(map[int64]installationToken),
+	}, nil
+}
+
+// Token returns an installation token for owner. The configured installation
+// is used when there is one; otherwise the app's installation on owner is
+// looked up once and remembered.
+func (s *TokenSource) Token(ctx context.Context, owner string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	installationID, err := s.installation(ctx, owner)
+	if err != nil {
+		return "", err
+	}
+	if token, ok := s.tokens[installationID]; ok && s.now().Add(refreshBefore).Before(token.ExpiresAt) {
+		return token.Token, nil
+	}
+
+	zap.S().Debugf("Requesting a token for GitHub App installation %d", installationID)
+	var token installationToken
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", s.apiURL, installationID)
+	if err := s.appRequest(ctx, "POST", url, &token); err != nil {
+		return "", err
+	}
+	s.tokens[installationID] = token
+	return token.Token, nil
+}
+
+func (s *TokenSource) installation(ctx context.Context, owner string) (int64, error) {
+	if s.installationID != 0 {
+		return s.installationID, nil
+	}
+	if owner == "" {
+		return 0, errors.New("--installation-id is required when no organization is given, such as with --enterprise")
+	}
+	key := strings.ToLower(owner)
+	if id, ok := s.installations[key]; ok {
+		return id, nil
+	}
+	var installation struct {
+		ID int64 `json:"id"`
+	}
+	url := fmt.Sprintf("%s/orgs/%s/installation", s.apiURL, owner)
+	if err := s.appRequest(ctx, "GET", url, &installation); err != nil {
+		return 0, fmt.Errorf("looking up the GitHub App installation for %s: %w", owner, err)
+	}
+	zap.S().Debugf("Found GitHub App installation %d for %s", installation.ID, owner)
+	s.installations[key] = installation.ID
+	return installation.ID, nil
+}
+
+// appRequest sends a request authenticated as the app and decodes the JSON
+// response into v.
+func (s *TokenSource) appRequest(ctx context.Context, method string, url string, v interface{}) error {
+	jwt, err := NewJWT(s.appID, s.key, s.now())
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(nil))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			zap.S().Warnf("Error closing response body: %v", closeErr)
+		}
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d from %s: %s", resp.StatusCode, url, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, v)
+}
+
+type ownerKey struct{}
+
+// WithOwner returns a copy of ctx naming the organization that requests sent
+// with it are for, for requests such as /graphql whose path does not.
+func WithOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+// Transport authenticates each request with an installation token. The
+// token is for the organization in the request path, such as
+// /repos/{owner}/... or /orgs/{owner}/..., then for the organization given
+// with WithOwner, or else for the default owner.
+type Transport struct {
+	Source *TokenSource
+	Owner  string
+	Base   http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.Source.Token(req.Context(), t.owner(req))
+	if err != nil {
+		return nil, err
+	}
+	// A RoundTripper must not modify the request it was given
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+token)
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+func (t *Transport) owner(req *http.Request) string {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, "/api/v3"), "/"), "/")
+	if len(parts) >= 2 && (parts[0] == "repos" || parts[0] == "orgs") {
+		return parts[1]
+	}
+	if owner, ok := req.Context().Value(ownerKey{}).(string); ok && owner != "" {
+		return owner
+	}
+	return t.Owner
+}
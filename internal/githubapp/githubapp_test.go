@@ -0,0 +1,319 @@
+package githubapp
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func jsonResponse(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func writeKey(t *testing.T, pkcs8 bool) (*rsa.PrivateKey, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if pkcs8 {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	path := filepath.Join(t.TempDir(), "app.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return key, path
+}
+
+func TestLoadPrivateKey(t *testing.T) {
+	for _, pkcs8 := range []bool{false, true} {
+		key, path := writeKey(t, pkcs8)
+		loaded, err := LoadPrivateKey(path)
+		if err != nil {
+			t.Fatalf("LoadPrivateKey() error = %v", err)
+		}
+		if !loaded.Equal(key) {
+			t.Errorf("Loaded key does not match (pkcs8 %v)", pkcs8)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "invalid.pem")
+	if err := os.WriteFile(path, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPrivateKey(path); err == nil {
+		t.Error("Expected error for a file without a PEM block")
+	}
+}
+
+func TestNewJWT(t *testing.T) {
+	key, _ := writeKey(t, false)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	jwt, err := NewJWT(12345, key, now)
+	if err != nil {
+		t.Fatalf("NewJWT() error = %v", err)
+	}
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("Expected 3 JWT segments, got %q", jwt)
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("Invalid signature: %v", err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims struct {
+		IAT int64  `json:"iat"`
+		EXP int64  `json:"exp"`
+		ISS string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.ISS != "12345" || claims.IAT != now.Add(-time.Minute).Unix() || claims.EXP != now.Add(9*time.Minute).Unix() {
+		t.Errorf("Unexpected claims %+v", claims)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		cfg     Config
+		wantErr bool
+	}{
+		{Config{}, false},
+		{Config{AppID: 1, PrivateKeyPath: "app.pem"}, false},
+		{Config{AppID: 1, PrivateKeyPath: "app.pem", InstallationID: 2}, false},
+		{Config{PrivateKeyPath: "app.pem"}, true},
+		{Config{InstallationID: 2}, true},
+		{Config{AppID: 1}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) error = %v, wantErr %v", tt.cfg, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAPIURL(t *testing.T) {
+	tests := map[string]string{
+		"github.com":         "https://api.github.com",
+		"octocorp.ghe.com":   "https://api.octocorp.ghe.com",
+		"github.example.com": "https://github.example.com/api/v3",
+	}
+	for host, want := range tests {
+		if got := APIURL(host); got != want {
+			t.Errorf("APIURL(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+// fakeGitHub issues tokens that expire an hour after they are requested and
+// knows the installations of two organizations.
+type fakeGitHub struct {
+	mu       sync.Mutex
+	now      time.Time
+	requests []string
+	issued   int
+}
+
+func (f *fakeGitHub) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "Bearer ") {
+		return jsonResponse(req, http.StatusUnauthorized, `{"message":"Bad credentials"}`), nil
+	}
+	f.requests = append(f.requests, req.Method+" "+req.URL.Path)
+	switch strings.TrimPrefix(req.URL.Path, "/api/v3") {
+	case "/orgs/octo-org/installation":
+		return jsonResponse(req, http.StatusOK, `{"id": 11}`), nil
+	case "/orgs/other-org/installation":
+		return jsonResponse(req, http.StatusOK, `{"id": 22}`), nil
+	case "/app/installations/11/access_tokens", "/app/installations/22/access_tokens", "/app/installations/33/access_tokens":
+		f.issued++
+		expires := f.now.Add(time.Hour).Format(time.RFC3339)
+		return jsonResponse(req, http.StatusCreated, fmt.Sprintf(`{"token": "ghs_%d", "expires_at": %q}`, f.issued, expires)), nil
+	}
+	return jsonResponse(req, http.StatusNotFound, `{"message":"Not Found"}`), nil
+}
+
+func TestTokenSource(t *testing.T) {
+	_, path := writeKey(t, false)
+	github := &fakeGitHub{now: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)}
+	source, err := NewTokenSource(Config{AppID: 1, PrivateKeyPath: path}, "github.com", github)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := github.now
+	source.now = func() time.Time { return now }
+
+	token, err := source.Token(context.Background(), "octo-org")
+	if err != nil || token != "ghs_1" {
+		t.Fatalf("Token() = %q, %v", token, err)
+	}
+	if token, _ := source.Token(context.Background(), "Octo-Org"); token != "ghs_1" {
+		t.Errorf("Expected the cached token, got %q", token)
+	}
+	if token, _ := source.Token(context.Background(), "other-org"); token != "ghs_2" {
+		t.Errorf("Expected a token for the other installation, got %q", token)
+	}
+
+	// Refreshed once within five minutes of expiry
+	now = now.Add(54 * time.Minute)
+	if token, _ := source.Token(context.Background(), "octo-org"); token != "ghs_1" {
+		t.Errorf("Expected the token to still be used, got %q", token)
+	}
+	now = now.Add(2 * time.Minute)
+	github.now = now
+	if token, _ := source.Token(context.Background(), "octo-org"); token != "ghs_3" {
+		t.Errorf("Expected a refreshed token, got %q", token)
+	}
+
+	want := []string{
+		"GET /orgs/octo-org/installation",
+		"POST /app/installations/11/access_tokens",
+		"GET /orgs/other-org/installation",
+		"POST /app/installations/22/access_tokens",
+		"POST /app/installations/11/access_tokens",
+	}
+	if strings.Join(github.requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected requests:\n%s", strings.Join(github.requests, "\n"))
+	}
+
+	if _, err := source.Token(context.Background(), "missing-org"); err == nil || !strings.Contains(err.Error(), "missing-org") {
+		t.Errorf("Expected error for an organization without the app, got %v", err)
+	}
+	if _, err := source.Token(context.Background(), ""); err == nil {
+		t.Error("Expected error without an organization or installation ID")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	_, path := writeKey(t, false)
+	github := &fakeGitHub{now: time.Now()}
+	source, err := NewTokenSource(Config{AppID: 1, PrivateKeyPath: path, InstallationID: 33}, "github.example.com", github)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var authorization []string
+	transport := &Transport{Source: source, Owner: "octo-org", Base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		authorization = append(authorization, req.Header.Get("Authorization"))
+		return jsonResponse(req, http.StatusOK, `{}`), nil
+	})}
+
+	req, _ := http.NewRequest("GET", "https://github.example.com/api/v3/repos/octo-org/app", nil)
+	req.Header.Set("Authorization", "token github-app")
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if len(authorization) != 1 || authorization[0] != "token ghs_1" {
+		t.Errorf("Unexpected Authorization headers %v", authorization)
+	}
+	if req.Header.Get("Authorization") != "token github-app" {
+		t.Error("Expected the original request not to be modified")
+	}
+	if github.requests[0] != "POST /api/v3/app/installations/33/access_tokens" {
+		t.Errorf("Expected the configured installation to be used, got %v", github.requests)
+	}
+}
+
+func TestTransportOwner(t *testing.T) {
+	transport := &Transport{Owner: "default-org"}
+	tests := map[string]string{
+		"https://api.github.com/repos/octo-org/app/environments":      "octo-org",
+		"https://ghes.example.com/api/v3/orgs/other-org/teams/admins": "other-org",
+		"https://api.github.com/graphql":                              "default-org",
+		"https://api.github.com/users/octocat":                        "default-org",
+	}
+	for url, want := range tests {
+		req, _ := http.NewRequest("GET", url, nil)
+		if got := transport.owner(req); got != want {
+			t.Errorf("owner(%s) = %q, want %q", url, got, want)
+		}
+	}
+
+	// Requests whose path names no organization use the one they were sent for
+	ctx := WithOwner(context.Background(), "octo-org")
+	req, _ := http.NewRequestWithContext(ctx, "POST", "https://api.github.com/graphql", nil)
+	if got := transport.owner(req); got != "octo-org" {
+		t.Errorf("owner(graphql) = %q, want %q", got, "octo-org")
+	}
+	req, _ = http.NewRequestWithContext(ctx, "GET", "https://api.github.com/repos/other-org/app", nil)
+	if got := transport.owner(req); got != "other-org" {
+		t.Errorf("Expected the path to take precedence, got %q", got)
+	}
+	transport.Owner = ""
+	req, _ = http.NewRequest("POST", "https://api.github.com/graphql", nil)
+	if got := transport.owner(req); got != "" {
+		t.Errorf("Expected no owner, got %q", got)
+	}
+}
+
+func TestTokenSourceCancelled(t *testing.T) {
+	_, path := writeKey(t, false)
+	github := &fakeGitHub{now: time.Now()}
+	source, err := NewTokenSource(Config{AppID: 1, PrivateKeyPath: path}, "github.com", github)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Installation lookups are cancelled with the request they are made for
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var sent *http.Request
+	source.client.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = req
+		return nil, req.Context().Err()
+	})
+	if _, err := source.Token(ctx, "octo-org"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the lookup to be cancelled, got %v", err)
+	}
+	if sent != nil && sent.Context().Err() == nil {
+		t.Error("Expected the lookup to use the caller's context")
+	}
+}
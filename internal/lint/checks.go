@@ -0,0 +1,185 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+type check struct {
+	description string
+	validate    func(params Params) error
+	// run returns a message for each way env fails the check
+	run func(env data.EnvironmentDetails, params Params) []string
+	// fix, if set, updates the planned change so env passes the check and
+	// returns a description of each change
+	fix func(env data.EnvironmentDetails, params Params, f *Fix) []string
+	// fixable, if set, reports whether fix has any effect on env once every
+	// other fix planned for it is; if not, the violation is fixed manually
+	fixable func(env data.EnvironmentDetails, f *Fix) bool
+}
+
+// checks are the built-in checks that rules can reference by name.
+var checks = map[string]check{
+	"min-reviewers": {
+		description: "Environment must require at least `min` reviewers",
+		validate: func(params Params) error {
+			if params.Min < 1 {
+				return fmt.Errorf("min-reviewers requires with.min of at least 1")
+			}
+			return nil
+		},
+		run: func(env data.EnvironmentDetails, params Params) []string {
+			if len(env.Reviewers) < params.Min {
+				return []string{fmt.Sprintf("has %d required reviewer(s), at least %d required", len(env.Reviewers), params.Min)}
+			}
+			return nil
+		},
+	},
+	"required-reviewers": {
+		description: "Environment must list the given teams and users as reviewers",
+		validate: func(params Params) error {
+			if len(params.Teams) == 0 && len(params.Users) == 0 {
+				return fmt.Errorf("required-reviewers requires with.teams or with.users")
+			}
+			return nil
+		},
+		run: func(env data.EnvironmentDetails, params Params) []string {
+			var messages []string
+			for _, team := range MissingReviewers(env, "Team", params.Teams) {
+				messages = append(messages, fmt.Sprintf("team %s is not a required reviewer", team))
+			}
+			for _, user := range MissingReviewers(env, "User", params.Users) {
+				messages = append(messages, fmt.Sprintf("user %s is not a required reviewer", user))
+			}
+			return messages
+		},
+		fix: func(env data.EnvironmentDetails, params Params, f *Fix) []string {
+			var changes []string
+			for _, team := range MissingReviewers(env, "Team", params.Teams) {
+				if !containsFold(f.AddTeams, team) {
+					f.AddTeams = append(f.AddTeams, team)
+					changes = append(changes, fmt.Sprintf("add team %s as a required reviewer", team))
+				}
+			}
+			for _, user := range MissingReviewers(env, "User", params.Users) {
+				if !containsFold(f.AddUsers, user) {
+					f.AddUsers = append(f.AddUsers, user)
+					changes = append(changes, fmt.Sprintf("add user %s as a required reviewer", user))
+				}
+			}
+			return changes
+		},
+	},
+	"prevent-self-review": {
+		description: "Environment must prevent users from approving their own deployments",
+		run: func(env data.EnvironmentDetails, params Params) []string {
+			if !env.PreventSelfReview {
+				return []string{"does not prevent self review"}
+			}
+			return nil
+		},
+		fix: func(env data.EnvironmentDetails, params Params, f *Fix) []string {
+			if f.Payload.PreventSelfReview {
+				return nil
+			}
+			f.Payload.PreventSelfReview = true
+			return []string{"prevent self review"}
+		},
+		// Self review can only be prevented with required reviewers
+		fixable: func(env data.EnvironmentDetails, f *Fix) bool {
+			return len(env.Reviewers) > 0 || len(f.AddTeams) > 0 || len(f.AddUsers) > 0
+		},
+	},
+	"protected-branches": {
+		description: "Environment must only allow deployments from protected branches",
+		run: func(env data.EnvironmentDetails, params Params) []string {
+			switch env.BranchPolicyType {
+			case "protected":
+				return nil
+			case "custom":
+				return []string{"uses custom branch policies instead of protected branches"}
+			default:
+				return []string{"allows deployments from any branch"}
+			}
+		},
+		fix: func(env data.EnvironmentDetails, params Params, f *Fix) []string {
+			if policy := f.Payload.DeploymentBranchPolicy; policy != nil && policy.ProtectedBranches {
+				return nil
+			}
+			f.Payload.DeploymentBranchPolicy = &data.DeploymentPolicy{ProtectedBranches: true}
+			if env.BranchPolicyType == "custom" {
+				return []string{fmt.Sprintf("switch to protected branches, removing %d custom branch policies", len(env.BranchPolicies))}
+			}
+			return []string{"only allow deployments from protected branches"}
+		},
+	},
+	"no-admin-bypass": {
+		description: "Administrators must not be able to bypass the environment's protection rules",
+		run: func(env data.EnvironmentDetails, params Params) []string {
+			if env.AdminBypass {
+				return []string{"allows administrators to bypass protection rules"}
+			}
+			return nil
+		},
+		fix: func(env data.EnvironmentDetails, params Params, f *Fix) []string {
+			if f.Payload.CanAdminsBypass != nil && !*f.Payload.CanAdminsBypass {
+				return nil
+			}
+			bypass := false
+			f.Payload.CanAdminsBypass = &bypass
+			return []string{"disable administrator bypass"}
+		},
+	},
+	"min-wait-timer": {
+		description: "Environment must have a wait timer of at least `minutes`",
+		validate: func(params Params) error {
+			if params.Minutes < 1 {
+				return fmt.Errorf("min-wait-timer requires with.minutes of at least 1")
+			}
+			return nil
+		},
+		run: func(env data.EnvironmentDetails, params Params) []string {
+			if env.WaitTimer < params.Minutes {
+				return []string{fmt.Sprintf("has a wait timer of %d minute(s), at least %d required", env.WaitTimer, params.Minutes)}
+			}
+			return nil
+		},
+		fix: func(env data.EnvironmentDetails, params Params, f *Fix) []string {
+			if f.Payload.WaitTimer >= params.Minutes {
+				return nil
+			}
+			f.Payload.WaitTimer = params.Minutes
+			return []string{fmt.Sprintf("set wait timer to %d minute(s)", params.Minutes)}
+		},
+	},
+}
+
+// MissingReviewers returns the names in want that are not reviewers of env
+// with the given type (User or Team).
+func MissingReviewers(env data.EnvironmentDetails, reviewerType string, want []string) []string {
+	var missing []string
+	for _, name := range want {
+		found := false
+		for _, reviewer := range env.Reviewers {
+			if reviewer.Type == reviewerType && (strings.EqualFold(reviewer.Reviewer.Login, name) || strings.EqualFold(reviewer.Reviewer.Slug, name)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/expr-lang/expr/vm"
+	"gopkg.in/yaml.v3"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityNote    Severity = "note"
+)
+
+// Config is the lint configuration file. Each rule applies one check, or a
+// custom expression, to the environments selected by its match block.
+type Config struct {
+	Rules []RuleConfig `yaml:"rules"`
+}
+
+type RuleConfig struct {
+	ID          string   `yaml:"id"`
+	Check       string   `yaml:"check"`
+	Expr        string   `yaml:"expr"`
+	Message     string   `yaml:"message"`
+	Description string   `yaml:"description"`
+	Severity    Severity `yaml:"severity"`
+	Match       Selector `yaml:"match"`
+	With        Params   `yaml:"with"`
+
+	program *vm.Program
+}
+
+// Selector chooses environments by repository and environment name. Globs
+// are matched case-insensitively and an empty include list matches all.
+type Selector struct {
+	Repositories        []string `yaml:"repositories"`
+	Environments        []string `yaml:"environments"`
+	ExcludeRepositories []string `yaml:"exclude_repositories"`
+	ExcludeEnvironments []string `yaml:"exclude_environments"`
+	RepositoryRegex     string   `yaml:"repository_regex"`
+	EnvironmentRegex    string   `yaml:"environment_regex"`
+
+	repositoryRegex  *regexp.Regexp
+	environmentRegex *regexp.Regexp
+}
+
+// Params holds the settings used by checks; each check documents which of
+// them it reads.
+type Params struct {
+	Min     int      `yaml:"min"`
+	Minutes int      `yaml:"minutes"`
+	Teams   []string `yaml:"teams"`
+	Users   []string `yaml:"users"`
+}
+
+// DefaultConfig is used when no configuration file is given. It encodes a
+// common baseline for production environments.
+func DefaultConfig() *Config {
+	production := Selector{Environments: []string{"prod*"}}
+	cfg := &Config{Rules: []RuleConfig{
+		{ID: "production-min-reviewers", Check: "min-reviewers", Severity: SeverityError, Match: production, With: Params{Min: 2}},
+		{ID: "production-prevent-self-review", Check: "prevent-self-review", Severity: SeverityError, Match: production},
+		{ID: "production-protected-branches", Check: "protected-branches", Severity: SeverityError, Match: production},
+		{ID: "production-no-admin-bypass", Check: "no-admin-bypass", Severity: SeverityError, Match: production},
+		{ID: "production-min-wait-timer", Check: "min-wait-timer", Severity: SeverityWarning, Match: production, With: Params{Minutes: 5}},
+	}}
+	// The default rules are known to be valid
+	_ = cfg.Validate()
+	return cfg
+}
+
+// LoadConfig reads and validates a YAML configuration file.
+func LoadConfig(fileName string) (*Config, error) {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	decoder := yaml.NewDecoder(strings.NewReader(string(content)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+	return &cfg, nil
+}
+
+// Validate checks every rule and fills in defaults. Severity defaults to
+// error and the ID to the check name. Expression rules need an ID and are
+// compiled here so type errors are reported before any API call.
+func (c *Config) Validate() error {
+	if len(c.Rules) == 0 {
+		return fmt.Errorf("no rules defined")
+	}
+	seen := make(map[string]bool)
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if err := rule.validate(i); err != nil {
+			return err
+		}
+		if seen[rule.ID] {
+			return fmt.Errorf("rule %s: duplicate rule id", rule.ID)
+		}
+		seen[rule.ID] = true
+		switch rule.Severity {
+		case "":
+			rule.Severity = SeverityError
+		case SeverityError, SeverityWarning, SeverityNote:
+		default:
+			return fmt.Errorf("rule %s: invalid severity %q, expected error, warning or note", rule.ID, rule.Severity)
+		}
+		if err := rule.Match.compile(); err != nil {
+			return fmt.Errorf("rule %s: %w", rule.ID, err)
+		}
+	}
+	return nil
+}
+
+func (r *RuleConfig) validate(index int) error {
+	if r.Expr != "" {
+		if r.Check != "" {
+			return fmt.Errorf("rule %d: check and expr are mutually exclusive", index+1)
+		}
+		if r.ID == "" {
+			return fmt.Errorf("rule %d: expression rules require an id", index+1)
+		}
+		program, err := compileExpression(r.Expr)
+		if err != nil {
+			return fmt.Errorf("rule %s: invalid expr: %w", r.ID, err)
+		}
+		r.program = program
+		if r.Description == "" {
+			r.Description = r.Expr
+		}
+		return nil
+	}
+
+	check, ok := checks[r.Check]
+	if !ok {
+		return fmt.Errorf("rule %d: unknown check %q", index+1, r.Check)
+	}
+	if r.Message != "" {
+		return fmt.Errorf("rule %d: message is only supported for expr rules", index+1)
+	}
+	if r.ID == "" {
+		r.ID = r.Check
+	}
+	if r.Description == "" {
+		r.Description = check.description
+	}
+	if check.validate != nil {
+		if err := check.validate(r.With); err != nil {
+			return fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Selector) compile() error {
+	for _, globs := range [][]string{s.Repositories, s.Environments, s.ExcludeRepositories, s.ExcludeEnvironments} {
+		for _, glob := range globs {
+			if _, err := path.Match(glob, ""); err != nil {
+				return fmt.Errorf("invalid glob %q: %w", glob, err)
+			}
+		}
+	}
+	var err error
+	if s.RepositoryRegex != "" {
+		if s.repositoryRegex, err = regexp.Compile(s.RepositoryRegex); err != nil {
+			return fmt.Errorf("invalid repository_regex: %w", err)
+		}
+	}
+	if s.EnvironmentRegex != "" {
+		if s.environmentRegex, err = regexp.Compile(s.EnvironmentRegex); err != nil {
+			return fmt.Errorf("invalid environment_regex: %w", err)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the selector applies to env in repo.
+func (s Selector) Matches(repo string, env string) bool {
+	if len(s.Repositories) > 0 && !matchAny(s.Repositories, repo) {
+		return false
+	}
+	if len(s.Environments) > 0 && !matchAny(s.Environments, env) {
+		return false
+	}
+	if matchAny(s.ExcludeRepositories, repo) || matchAny(s.ExcludeEnvironments, env) {
+		return false
+	}
+	if s.repositoryRegex != nil && !s.repositoryRegex.MatchString(repo) {
+		return false
+	}
+	if s.environmentRegex != nil && !s.environmentRegex.MatchString(env) {
+		return false
+	}
+	return true
+}
+
+func matchAny(globs []string, name string) bool {
+	for _, glob := range globs {
+		if ok, _ := path.Match(strings.ToLower(glob), strings.ToLower(name)); ok {
+			return true
+		}
+	}
+	return false
+}
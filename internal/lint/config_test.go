@@ -0,0 +1,110 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), "lint.yml")
+	if err := os.WriteFile(fileName, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return fileName
+}
+
+func TestLoadConfig(t *testing.T) {
+	fileName := writeConfig(t, `
+rules:
+  - id: prod-reviewers
+    check: min-reviewers
+    severity: warning
+    match:
+      environments: ["prod*"]
+      exclude_repositories: ["sandbox-*"]
+    with:
+      min: 2
+  - check: no-admin-bypass
+`)
+	cfg, err := LoadConfig(fileName)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.Rules) != 2 {
+		t.Fatalf("Expected 2 rules, got %d", len(cfg.Rules))
+	}
+	if cfg.Rules[0].Severity != SeverityWarning || cfg.Rules[0].With.Min != 2 {
+		t.Errorf("Unexpected first rule %+v", cfg.Rules[0])
+	}
+	if cfg.Rules[1].ID != "no-admin-bypass" || cfg.Rules[1].Severity != SeverityError {
+		t.Errorf("Expected defaults for ID and severity, got %+v", cfg.Rules[1])
+	}
+	if cfg.Rules[1].Description == "" {
+		t.Error("Expected description to default to the check description")
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	tests := map[string]string{
+		"no rules":        "rules: []",
+		"unknown check":   "rules: [{check: nope}]",
+		"unknown field":   "rules: [{check: no-admin-bypass, severty: error}]",
+		"bad severity":    "rules: [{check: no-admin-bypass, severity: fatal}]",
+		"duplicate id":    "rules: [{check: no-admin-bypass}, {check: no-admin-bypass}]",
+		"missing min":     "rules: [{check: min-reviewers}]",
+		"missing minutes": "rules: [{check: min-wait-timer}]",
+		"missing teams":   "rules: [{check: required-reviewers}]",
+		"bad glob":        `rules: [{check: no-admin-bypass, match: {environments: ["prod["]}}]`,
+		"bad regex":       `rules: [{check: no-admin-bypass, match: {repository_regex: "("}}]`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadConfig(writeConfig(t, content)); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yml")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	selector := Selector{
+		Repositories:        []string{"svc-*"},
+		Environments:        []string{"Prod*"},
+		ExcludeEnvironments: []string{"production-test"},
+		EnvironmentRegex:    "^prod",
+	}
+	if err := selector.compile(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		repo, env string
+		want      bool
+	}{
+		{"svc-api", "production", true},
+		{"web", "production", false},
+		{"svc-api", "staging", false},
+		{"svc-api", "production-test", false},
+		{"svc-api", "Production", false},
+	}
+	for _, tt := range tests {
+		if got := selector.Matches(tt.repo, tt.env); got != tt.want {
+			t.Errorf("Matches(%s, %s) = %v, want %v", tt.repo, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	for _, rule := range cfg.Rules {
+		if !strings.HasPrefix(rule.ID, "production-") || !rule.Match.Matches("app", "production") || rule.Match.Matches("app", "staging") {
+			t.Errorf("Expected default rule %s to apply to production environments only", rule.ID)
+		}
+	}
+}
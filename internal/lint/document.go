@@ -0,0 +1,82 @@
+package lint
+
+import (
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+// Document is the normalized view of an environment that custom rule
+// expressions are evaluated against. Field names are the same in
+// expressions and in rule test fixtures.
+type Document struct {
+	Organization      string          `expr:"organization" yaml:"organization"`
+	Repository        string          `expr:"repository" yaml:"repository"`
+	Name              string          `expr:"name" yaml:"name"`
+	AdminBypass       bool            `expr:"admin_bypass" yaml:"admin_bypass"`
+	WaitTimer         int             `expr:"wait_timer" yaml:"wait_timer"`
+	PreventSelfReview bool            `expr:"prevent_self_review" yaml:"prevent_self_review"`
+	Reviewers         []ReviewerDoc   `expr:"reviewers" yaml:"reviewers"`
+	Teams             []string        `expr:"teams" yaml:"teams"`
+	Users             []string        `expr:"users" yaml:"users"`
+	BranchPolicyType  string          `expr:"branch_policy_type" yaml:"branch_policy_type"`
+	BranchPolicies    []BranchDoc     `expr:"branch_policies" yaml:"branch_policies"`
+	ProtectionRules   []ProtectionDoc `expr:"protection_rules" yaml:"protection_rules"`
+	SecretsCount      int             `expr:"secrets_count" yaml:"secrets_count"`
+	VariablesCount    int             `expr:"variables_count" yaml:"variables_count"`
+}
+
+type ReviewerDoc struct {
+	Type string `expr:"type" yaml:"type"`
+	Name string `expr:"name" yaml:"name"`
+	ID   int    `expr:"id" yaml:"id"`
+}
+
+type BranchDoc struct {
+	Name string `expr:"name" yaml:"name"`
+	Type string `expr:"type" yaml:"type"`
+}
+
+type ProtectionDoc struct {
+	App     string `expr:"app" yaml:"app"`
+	AppID   int    `expr:"app_id" yaml:"app_id"`
+	Enabled bool   `expr:"enabled" yaml:"enabled"`
+}
+
+// NewDocument normalizes env. Team reviewers are named by slug and users by
+// login, and both are also listed in Teams and Users.
+func NewDocument(env data.EnvironmentDetails) Document {
+	doc := Document{
+		Organization:      env.Organization,
+		Repository:        env.Repository,
+		Name:              env.Name,
+		AdminBypass:       env.AdminBypass,
+		WaitTimer:         env.WaitTimer,
+		PreventSelfReview: env.PreventSelfReview,
+		BranchPolicyType:  env.BranchPolicyType,
+		SecretsCount:      env.SecretsCount,
+		VariablesCount:    env.VariablesCount,
+		Reviewers:         []ReviewerDoc{},
+		Teams:             []string{},
+		Users:             []string{},
+		BranchPolicies:    []BranchDoc{},
+		ProtectionRules:   []ProtectionDoc{},
+	}
+	for _, reviewer := range env.Reviewers {
+		name := reviewer.Reviewer.Login
+		if reviewer.Type == "Team" {
+			if reviewer.Reviewer.Slug != "" {
+				name = reviewer.Reviewer.Slug
+			}
+			doc.Teams = append(doc.Teams, name)
+		} else {
+			doc.Users = append(doc.Users, name)
+		}
+		doc.Reviewers = append(doc.Reviewers, ReviewerDoc{Type: reviewer.Type, Name: name, ID: reviewer.Reviewer.ID})
+	}
+	for _, branch := range env.BranchPolicies {
+		doc.BranchPolicies = append(doc.BranchPolicies, BranchDoc{Name: branch.Name, Type: branch.Type})
+	}
+	for _, rule := range env.ProtectionRules {
+		doc.ProtectionRules = append(doc.ProtectionRules, ProtectionDoc{App: rule.App.Slug, AppID: rule.App.IntegrationID, Enabled: rule.Enabled})
+	}
+	return doc
+}
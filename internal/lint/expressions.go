@@ -0,0 +1,146 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"gopkg.in/yaml.v3"
+)
+
+// RulesFile is a file in a rules directory. It defines custom rules and the
+// fixtures that test them.
+type RulesFile struct {
+	Rules []RuleConfig `yaml:"rules"`
+	Tests []RuleTest   `yaml:"tests"`
+}
+
+// RuleTest is a fixture: the rule is expected to pass or fail for the given
+// environment document.
+type RuleTest struct {
+	Name        string   `yaml:"name"`
+	Rule        string   `yaml:"rule"`
+	Environment Document `yaml:"environment"`
+	Pass        bool     `yaml:"pass"`
+
+	file string
+}
+
+// compileExpression type checks source against Document. The expression must
+// evaluate to true for a compliant environment.
+func compileExpression(source string) (*vm.Program, error) {
+	return expr.Compile(source, expr.Env(Document{}), expr.AsBool())
+}
+
+// evaluate runs an expression rule against doc and returns its message when
+// the expression is false.
+func (r RuleConfig) evaluate(doc Document) ([]string, error) {
+	if r.program == nil {
+		return nil, fmt.Errorf("rule %s is not an expression rule", r.ID)
+	}
+	output, err := expr.Run(r.program, doc)
+	if err != nil {
+		return nil, err
+	}
+	if output.(bool) {
+		return nil, nil
+	}
+	if r.Message != "" {
+		return []string{r.Message}, nil
+	}
+	return []string{fmt.Sprintf("does not satisfy %s", r.Expr)}, nil
+}
+
+// LoadRulesDir reads every .yml and .yaml file in dir, in name order.
+func LoadRulesDir(dir string) ([]RuleConfig, []RuleTest, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".yml" || ext == ".yaml") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var rules []RuleConfig
+	var tests []RuleTest
+	for _, name := range names {
+		fileName := filepath.Join(dir, name)
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil, nil, err
+		}
+		var file RulesFile
+		decoder := yaml.NewDecoder(strings.NewReader(string(content)))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&file); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", fileName, err)
+		}
+		for _, rule := range file.Rules {
+			if rule.Expr == "" {
+				return nil, nil, fmt.Errorf("%s: rule %s: rules directory rules must define expr", fileName, rule.ID)
+			}
+			rules = append(rules, rule)
+		}
+		for _, test := range file.Tests {
+			test.file = fileName
+			tests = append(tests, test)
+		}
+	}
+	return rules, tests, nil
+}
+
+// AddRules appends rules to the configuration and validates the result.
+func (c *Config) AddRules(rules []RuleConfig) error {
+	c.Rules = append(c.Rules, rules...)
+	return c.Validate()
+}
+
+// RuleTestFailure describes a fixture whose outcome did not match.
+type RuleTestFailure struct {
+	Test    RuleTest
+	Message string
+}
+
+func (f RuleTestFailure) Error() string {
+	return fmt.Sprintf("%s: %s: %s", f.Test.file, f.Test.Name, f.Message)
+}
+
+// RunTests evaluates each fixture against its rule in cfg. The rule's match
+// block is ignored so fixtures only need the fields the expression reads.
+func RunTests(cfg *Config, tests []RuleTest) []RuleTestFailure {
+	rules := make(map[string]RuleConfig)
+	for _, rule := range cfg.Rules {
+		rules[rule.ID] = rule
+	}
+
+	var failures []RuleTestFailure
+	for _, test := range tests {
+		rule, ok := rules[test.Rule]
+		if !ok {
+			failures = append(failures, RuleTestFailure{test, fmt.Sprintf("unknown rule %q", test.Rule)})
+			continue
+		}
+		messages, err := rule.evaluate(test.Environment)
+		if err != nil {
+			failures = append(failures, RuleTestFailure{test, err.Error()})
+			continue
+		}
+		if passed := len(messages) == 0; passed != test.Pass {
+			want := "pass"
+			if !test.Pass {
+				want = "fail"
+			}
+			failures = append(failures, RuleTestFailure{test, fmt.Sprintf("expected rule %s to %s", rule.ID, want)})
+		}
+	}
+	return failures
+}
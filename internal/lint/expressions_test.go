@@ -0,0 +1,148 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+func TestNewDocument(t *testing.T) {
+	doc := NewDocument(compliantEnvironment("app", "production"))
+
+	if !reflect.DeepEqual(doc.Teams, []string{"release-managers"}) || !reflect.DeepEqual(doc.Users, []string{"octocat"}) {
+		t.Errorf("Unexpected teams %v and users %v", doc.Teams, doc.Users)
+	}
+	if len(doc.Reviewers) != 2 || doc.Reviewers[0] != (ReviewerDoc{Type: "Team", Name: "release-managers", ID: 1}) {
+		t.Errorf("Unexpected reviewers %+v", doc.Reviewers)
+	}
+	if doc.BranchPolicies == nil || doc.ProtectionRules == nil {
+		t.Error("Expected empty lists rather than nil so expressions can use len()")
+	}
+}
+
+func TestExpressionRules(t *testing.T) {
+	cfg := &Config{Rules: []RuleConfig{
+		{ID: "wait-timer", Expr: "wait_timer >= 15", Message: "waits less than 15 minutes", Severity: SeverityWarning},
+		{ID: "no-bypass", Expr: "!admin_bypass", Match: Selector{Environments: []string{"prod*"}}},
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if cfg.Rules[1].Description != "!admin_bypass" {
+		t.Errorf("Expected description to default to the expression, got %q", cfg.Rules[1].Description)
+	}
+
+	env := compliantEnvironment("app", "production")
+	env.AdminBypass = true
+	result := Lint([]data.EnvironmentDetails{env}, cfg)
+	if len(result.Violations) != 2 {
+		t.Fatalf("Expected 2 violations, got %+v", result.Violations)
+	}
+	if v := result.Violations[1]; v.Rule != "wait-timer" || v.Check != "expr" || v.Message != "waits less than 15 minutes" {
+		t.Errorf("Unexpected violation %+v", v)
+	}
+	if v := result.Violations[0]; v.Message != "does not satisfy !admin_bypass" {
+		t.Errorf("Expected default message, got %q", v.Message)
+	}
+}
+
+func TestExpressionRulesInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rule RuleConfig
+		want string
+	}{
+		{"missing id", RuleConfig{Expr: "true"}, "require an id"},
+		{"check and expr", RuleConfig{ID: "x", Check: "no-admin-bypass", Expr: "true"}, "mutually exclusive"},
+		{"unknown field", RuleConfig{ID: "x", Expr: "reviewer_count > 1"}, "invalid expr"},
+		{"not boolean", RuleConfig{ID: "x", Expr: "wait_timer"}, "invalid expr"},
+		{"message on check", RuleConfig{Check: "no-admin-bypass", Message: "bypass"}, "only supported for expr"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Rules: []RuleConfig{tt.rule}}
+			if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadRulesDir(t *testing.T) {
+	dir := t.TempDir()
+	content := `rules:
+  - id: min-wait
+    expr: wait_timer >= 5
+tests:
+  - name: long wait passes
+    rule: min-wait
+    environment: {wait_timer: 10}
+    pass: true
+  - name: wrong expectation
+    rule: min-wait
+    environment: {wait_timer: 1}
+    pass: true
+  - name: unknown rule
+    rule: missing
+    pass: true
+`
+	if err := os.WriteFile(filepath.Join(dir, "rules.yml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, tests, err := LoadRulesDir(dir)
+	if err != nil {
+		t.Fatalf("LoadRulesDir() error = %v", err)
+	}
+	if len(rules) != 1 || len(tests) != 3 {
+		t.Fatalf("Expected 1 rule and 3 tests, got %d and %d", len(rules), len(tests))
+	}
+	cfg := &Config{}
+	if err := cfg.AddRules(rules); err != nil {
+		t.Fatalf("AddRules() error = %v", err)
+	}
+
+	failures := RunTests(cfg, tests)
+	if len(failures) != 2 {
+		t.Fatalf("Expected 2 failures, got %v", failures)
+	}
+	if !strings.Contains(failures[0].Error(), "wrong expectation: expected rule min-wait to pass") {
+		t.Errorf("Unexpected failure %q", failures[0].Error())
+	}
+	if !strings.Contains(failures[1].Error(), `unknown rule "missing"`) {
+		t.Errorf("Unexpected failure %q", failures[1].Error())
+	}
+
+	checkRule := "rules:\n  - check: no-admin-bypass\n"
+	if err := os.WriteFile(filepath.Join(dir, "rules.yml"), []byte(checkRule), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := LoadRulesDir(dir); err == nil {
+		t.Error("Expected error for a rule without expr")
+	}
+}
+
+// The example rules shipped with the repository must pass their own tests.
+func TestExampleRules(t *testing.T) {
+	rules, tests, err := LoadRulesDir(filepath.Join("..", "..", "examples", "rules"))
+	if err != nil {
+		t.Fatalf("LoadRulesDir() error = %v", err)
+	}
+	cfg := &Config{}
+	if err := cfg.AddRules(rules); err != nil {
+		t.Fatalf("AddRules() error = %v", err)
+	}
+	if len(tests) == 0 {
+		t.Fatal("Expected example rule tests")
+	}
+	for _, failure := range RunTests(cfg, tests) {
+		t.Error(failure.Error())
+	}
+}
@@ -0,0 +1,152 @@
+package lint
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/utils"
+)
+
+// Fix is the planned update of one environment. Payload holds the complete
+// settings to send with CreateEnvironment; the IDs of AddTeams and AddUsers
+// must be resolved and added with AddReviewers before it is applied.
+type Fix struct {
+	Organization string
+	Repository   string
+	Environment  string
+	Changes      []string
+	AddTeams     []string
+	AddUsers     []string
+	Payload      data.CreateEnvironment
+}
+
+// Plan lists the fixes for a lint result and the violations that have no
+// automatic remediation.
+type Plan struct {
+	Fixes  []Fix
+	Manual []Violation
+}
+
+// NewEnvironmentPayload returns the CreateEnvironment payload that keeps the
+// current settings of env.
+func NewEnvironmentPayload(env data.EnvironmentDetails) data.CreateEnvironment {
+	bypass := env.AdminBypass
+	payload := data.CreateEnvironment{
+		CanAdminsBypass:   &bypass,
+		WaitTimer:         env.WaitTimer,
+		PreventSelfReview: env.PreventSelfReview,
+		Reviewers:         []data.CreateReviewer{},
+	}
+	for _, reviewer := range env.Reviewers {
+		payload.Reviewers = append(payload.Reviewers, data.CreateReviewer{Type: reviewer.Type, ID: reviewer.Reviewer.ID})
+	}
+	switch env.BranchPolicyType {
+	case "protected":
+		payload.DeploymentBranchPolicy = &data.DeploymentPolicy{ProtectedBranches: true}
+	case "custom":
+		payload.DeploymentBranchPolicy = &data.DeploymentPolicy{CustomPolicies: true}
+	}
+	return payload
+}
+
+// PlanFixes builds a fix for every environment in result with a violation
+// whose check has a remediation. Environments are matched to violations by
+// organization, repository and name.
+func PlanFixes(environments []data.EnvironmentDetails, cfg *Config, result Result) Plan {
+	rules := make(map[string]RuleConfig)
+	for _, rule := range cfg.Rules {
+		rules[rule.ID] = rule
+	}
+	byName := make(map[string]data.EnvironmentDetails)
+	for _, env := range environments {
+		byName[env.Organization+"/"+env.Repository+"/"+env.Name] = env
+	}
+
+	plan := Plan{}
+	fixes := make(map[string]int)
+	fixed := make(map[string]bool)
+	planFix := func(violation Violation) {
+		key := violation.Organization + "/" + violation.Repository + "/" + violation.Environment
+		rule := rules[violation.Rule]
+		env, ok := byName[key]
+		if !ok || rule.program != nil || checks[rule.Check].fix == nil {
+			plan.Manual = append(plan.Manual, violation)
+			return
+		}
+		// A rule reports one violation per problem but fixes all of them
+		if fixed[key+"/"+rule.ID] {
+			return
+		}
+
+		index, ok := fixes[key]
+		var fix Fix
+		if ok {
+			fix = plan.Fixes[index]
+		} else {
+			fix = Fix{
+				Organization: env.Organization,
+				Repository:   env.Repository,
+				Environment:  env.Name,
+				Payload:      NewEnvironmentPayload(env),
+			}
+		}
+		if fixable := checks[rule.Check].fixable; fixable != nil && !fixable(env, &fix) {
+			plan.Manual = append(plan.Manual, violation)
+			return
+		}
+		fixed[key+"/"+rule.ID] = true
+		for _, change := range checks[rule.Check].fix(env, rule.With, &fix) {
+			fix.Changes = append(fix.Changes, fmt.Sprintf("%s (%s)", change, rule.ID))
+		}
+		if ok {
+			plan.Fixes[index] = fix
+		} else {
+			fixes[key] = len(plan.Fixes)
+			plan.Fixes = append(plan.Fixes, fix)
+		}
+	}
+
+	// Fixes that depend on other fixes are planned once those are
+	var deferred []Violation
+	for _, violation := range result.Violations {
+		if checks[rules[violation.Rule].Check].fixable != nil {
+			deferred = append(deferred, violation)
+			continue
+		}
+		planFix(violation)
+	}
+	for _, violation := range deferred {
+		planFix(violation)
+	}
+	return plan
+}
+
+// AddReviewers adds the resolved IDs of AddTeams and AddUsers to the payload.
+func (f *Fix) AddReviewers(reviewers []data.CreateReviewer) {
+	f.Payload.Reviewers = append(f.Payload.Reviewers, reviewers...)
+}
+
+// WritePlan describes the planned changes for review before they are applied.
+func WritePlan(out io.Writer, plan Plan) error {
+	p := utils.NewPrinter(out)
+	if len(plan.Fixes) == 0 {
+		p.Println("No violations can be fixed automatically.")
+	} else {
+		p.Printf("%d environment(s) will be updated:\n", len(plan.Fixes))
+		for _, fix := range plan.Fixes {
+			p.Printf("\n%s/%s environment %s:\n", fix.Organization, fix.Repository, fix.Environment)
+			for _, change := range fix.Changes {
+				p.Printf("  - %s\n", change)
+			}
+		}
+	}
+	if len(plan.Manual) > 0 {
+		p.Printf("\n%d violation(s) must be fixed manually:\n", len(plan.Manual))
+		for _, v := range plan.Manual {
+			p.Printf("  - %s/%s environment %s %s (%s)\n", v.Organization, v.Repository, v.Environment, v.Message, v.Rule)
+		}
+	}
+	p.Println()
+	return p.Err()
+}
@@ -0,0 +1,160 @@
+package lint
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+func TestNewEnvironmentPayload(t *testing.T) {
+	env := compliantEnvironment("app", "production")
+	payload := NewEnvironmentPayload(env)
+
+	if payload.CanAdminsBypass == nil || *payload.CanAdminsBypass {
+		t.Errorf("Expected admin bypass to be kept as false, got %v", payload.CanAdminsBypass)
+	}
+	want := []data.CreateReviewer{{Type: "Team", ID: 1}, {Type: "User", ID: 2}}
+	if !reflect.DeepEqual(payload.Reviewers, want) {
+		t.Errorf("Expected reviewers %+v, got %+v", want, payload.Reviewers)
+	}
+	if payload.WaitTimer != 10 || !payload.PreventSelfReview {
+		t.Errorf("Unexpected payload %+v", payload)
+	}
+	if payload.DeploymentBranchPolicy == nil || !payload.DeploymentBranchPolicy.ProtectedBranches {
+		t.Errorf("Expected protected branches, got %+v", payload.DeploymentBranchPolicy)
+	}
+
+	env.BranchPolicyType = ""
+	if payload := NewEnvironmentPayload(env); payload.DeploymentBranchPolicy != nil {
+		t.Errorf("Expected no branch policy, got %+v", payload.DeploymentBranchPolicy)
+	}
+}
+
+func TestPlanFixes(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Rules = append(cfg.Rules,
+		RuleConfig{ID: "release-team", Check: "required-reviewers", Match: Selector{Repositories: []string{"app"}}, With: Params{Teams: []string{"release-managers", "platform"}, Users: []string{"hubot"}}},
+		RuleConfig{ID: "has-secrets", Expr: "secrets_count > 0"},
+	)
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	env := data.EnvironmentDetails{
+		Organization:     "testorg",
+		Repository:       "app",
+		Name:             "production",
+		AdminBypass:      true,
+		BranchPolicyType: "custom",
+		BranchPolicies:   []data.BranchPolicy{{ID: 1, Name: "main", Type: "branch"}},
+		Reviewers: []data.Reviewers{
+			{Type: "Team", Reviewer: data.Reviewer{Slug: "release-managers", ID: 1}},
+		},
+	}
+	environments := []data.EnvironmentDetails{env, compliantEnvironment("api", "production")}
+	result := Lint(environments, cfg)
+	plan := PlanFixes(environments, cfg, result)
+
+	if len(plan.Fixes) != 1 {
+		t.Fatalf("Expected 1 fix, got %+v", plan.Fixes)
+	}
+	fix := plan.Fixes[0]
+	if fix.Repository != "app" || fix.Environment != "production" {
+		t.Errorf("Unexpected fix target %s/%s", fix.Repository, fix.Environment)
+	}
+	if !reflect.DeepEqual(fix.AddTeams, []string{"platform"}) || !reflect.DeepEqual(fix.AddUsers, []string{"hubot"}) {
+		t.Errorf("Unexpected reviewers to add: teams %v, users %v", fix.AddTeams, fix.AddUsers)
+	}
+	payload := fix.Payload
+	if *payload.CanAdminsBypass || !payload.PreventSelfReview || payload.WaitTimer != 5 {
+		t.Errorf("Unexpected payload %+v", payload)
+	}
+	if !reflect.DeepEqual(payload.DeploymentBranchPolicy, &data.DeploymentPolicy{ProtectedBranches: true}) {
+		t.Errorf("Expected protected branches, got %+v", payload.DeploymentBranchPolicy)
+	}
+	if len(fix.Changes) != 6 {
+		t.Errorf("Expected 6 changes, got %v", fix.Changes)
+	}
+
+	// min-reviewers and expression rules have no remediation
+	var manual []string
+	for _, v := range plan.Manual {
+		manual = append(manual, v.Repository+":"+v.Rule)
+	}
+	want := []string{"api:has-secrets", "app:has-secrets", "app:production-min-reviewers"}
+	if !reflect.DeepEqual(manual, want) {
+		t.Errorf("Expected manual violations %v, got %v", want, manual)
+	}
+
+	fix.AddReviewers([]data.CreateReviewer{{Type: "Team", ID: 7}})
+	if len(fix.Payload.Reviewers) != 2 {
+		t.Errorf("Expected reviewer to be added, got %+v", fix.Payload.Reviewers)
+	}
+}
+
+func TestPlanFixesSelfReview(t *testing.T) {
+	cfg := &Config{Rules: []RuleConfig{{ID: "no-self-review", Check: "prevent-self-review"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without required reviewers, preventing self review has no effect
+	environments := []data.EnvironmentDetails{{Organization: "testorg", Repository: "app", Name: "production"}}
+	plan := PlanFixes(environments, cfg, Lint(environments, cfg))
+	if len(plan.Fixes) != 0 || len(plan.Manual) != 1 || plan.Manual[0].Rule != "no-self-review" {
+		t.Errorf("Expected self review to be fixed manually, got %+v", plan)
+	}
+
+	// A fix adding required reviewers also prevents self review
+	cfg.Rules = append(cfg.Rules, RuleConfig{ID: "release-team", Check: "required-reviewers", With: Params{Teams: []string{"release-managers"}}})
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	plan = PlanFixes(environments, cfg, Lint(environments, cfg))
+	if len(plan.Manual) != 0 || len(plan.Fixes) != 1 || !plan.Fixes[0].Payload.PreventSelfReview {
+		t.Errorf("Expected self review to be prevented with the added reviewers, got %+v", plan)
+	}
+}
+
+func TestWritePlan(t *testing.T) {
+	var out bytes.Buffer
+	plan := Plan{
+		Fixes:  []Fix{{Organization: "testorg", Repository: "app", Environment: "production", Changes: []string{"disable administrator bypass (no-admin-bypass)"}}},
+		Manual: []Violation{{Rule: "min-reviewers", Organization: "testorg", Repository: "api", Environment: "production", Message: "has 0 required reviewer(s)"}},
+	}
+	if err := WritePlan(&out, plan); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"1 environment(s) will be updated:",
+		"testorg/app environment production:\n  - disable administrator bypass (no-admin-bypass)",
+		"1 violation(s) must be fixed manually:",
+		"testorg/api environment production has 0 required reviewer(s) (min-reviewers)",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected plan to contain %q, got:\n%s", want, out.String())
+		}
+	}
+
+	if err := WritePlan(failingWriter{}, plan); err == nil {
+		t.Error("Expected the write error to be returned")
+	}
+
+	out.Reset()
+	if err := WritePlan(&out, Plan{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "No violations can be fixed automatically.") {
+		t.Errorf("Unexpected plan %q", out.String())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
@@ -0,0 +1,93 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+// Violation is a single failed check for one environment.
+type Violation struct {
+	Rule         string   `json:"rule"`
+	Check        string   `json:"check"`
+	Severity     Severity `json:"severity"`
+	Organization string   `json:"organization"`
+	Repository   string   `json:"repository"`
+	Environment  string   `json:"environment"`
+	Message      string   `json:"message"`
+}
+
+// Result holds the outcome of linting a set of environments.
+type Result struct {
+	Rules        []RuleConfig `json:"-"`
+	Environments int          `json:"environments"`
+	Violations   []Violation  `json:"violations"`
+}
+
+// Lint evaluates every rule in cfg against each environment it selects.
+// Violations are sorted by repository, environment and rule.
+func Lint(environments []data.EnvironmentDetails, cfg *Config) Result {
+	result := Result{Rules: cfg.Rules, Environments: len(environments), Violations: []Violation{}}
+	for _, env := range environments {
+		for _, rule := range cfg.Rules {
+			if !rule.Match.Matches(env.Repository, env.Name) {
+				continue
+			}
+			for _, message := range rule.run(env) {
+				result.Violations = append(result.Violations, Violation{
+					Rule:         rule.ID,
+					Check:        rule.kind(),
+					Severity:     rule.Severity,
+					Organization: env.Organization,
+					Repository:   env.Repository,
+					Environment:  env.Name,
+					Message:      message,
+				})
+			}
+		}
+	}
+	sort.SliceStable(result.Violations, func(i, j int) bool {
+		a, b := result.Violations[i], result.Violations[j]
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+		if a.Environment != b.Environment {
+			return a.Environment < b.Environment
+		}
+		return a.Rule < b.Rule
+	})
+	return result
+}
+
+// Count returns the number of violations with the given severity.
+func (r Result) Count(severity Severity) int {
+	count := 0
+	for _, violation := range r.Violations {
+		if violation.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// run returns the violation messages for env. An expression that fails at
+// run time is reported as a violation rather than aborting the lint.
+func (r RuleConfig) run(env data.EnvironmentDetails) []string {
+	if r.program == nil {
+		return checks[r.Check].run(env, r.With)
+	}
+	messages, err := r.evaluate(NewDocument(env))
+	if err != nil {
+		return []string{fmt.Sprintf("failed to evaluate expression: %v", err)}
+	}
+	return messages
+}
+
+// kind is the check name reported for a rule, or "expr" for expression rules.
+func (r RuleConfig) kind() string {
+	if r.program != nil {
+		return "expr"
+	}
+	return r.Check
+}
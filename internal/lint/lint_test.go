@@ -0,0 +1,80 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+func compliantEnvironment(repo string, name string) data.EnvironmentDetails {
+	return data.EnvironmentDetails{
+		Organization:      "testorg",
+		Repository:        repo,
+		Name:              name,
+		WaitTimer:         10,
+		PreventSelfReview: true,
+		Reviewers: []data.Reviewers{
+			{Type: "Team", Reviewer: data.Reviewer{Slug: "release-managers", ID: 1}},
+			{Type: "User", Reviewer: data.Reviewer{Login: "octocat", ID: 2}},
+		},
+		BranchPolicyType: "protected",
+	}
+}
+
+func TestLintDefaultConfig(t *testing.T) {
+	noncompliant := data.EnvironmentDetails{Organization: "testorg", Repository: "app", Name: "production", AdminBypass: true}
+	environments := []data.EnvironmentDetails{
+		compliantEnvironment("app", "production-eu"),
+		noncompliant,
+		// Only production environments are checked by default
+		{Repository: "app", Name: "staging", AdminBypass: true},
+	}
+
+	result := Lint(environments, DefaultConfig())
+	if result.Environments != 3 {
+		t.Errorf("Expected 3 environments checked, got %d", result.Environments)
+	}
+	if len(result.Violations) != 5 {
+		t.Fatalf("Expected 5 violations, got %+v", result.Violations)
+	}
+	for _, v := range result.Violations {
+		if v.Environment != "production" {
+			t.Errorf("Unexpected violation %+v", v)
+		}
+	}
+	if result.Count(SeverityError) != 4 || result.Count(SeverityWarning) != 1 {
+		t.Errorf("Expected 4 errors and 1 warning, got %d and %d", result.Count(SeverityError), result.Count(SeverityWarning))
+	}
+	// Violations are sorted by rule within an environment
+	if result.Violations[0].Rule != "production-min-reviewers" {
+		t.Errorf("Expected violations to be sorted, got %+v", result.Violations)
+	}
+}
+
+func TestChecks(t *testing.T) {
+	env := compliantEnvironment("app", "production")
+	tests := []struct {
+		check  string
+		params Params
+		modify func(*data.EnvironmentDetails)
+		want   int
+	}{
+		{"min-reviewers", Params{Min: 2}, func(e *data.EnvironmentDetails) {}, 0},
+		{"min-reviewers", Params{Min: 3}, func(e *data.EnvironmentDetails) {}, 1},
+		{"required-reviewers", Params{Teams: []string{"Release-Managers"}, Users: []string{"octocat"}}, func(e *data.EnvironmentDetails) {}, 0},
+		{"required-reviewers", Params{Teams: []string{"security", "ops"}, Users: []string{"hubot"}}, func(e *data.EnvironmentDetails) {}, 3},
+		{"prevent-self-review", Params{}, func(e *data.EnvironmentDetails) { e.PreventSelfReview = false }, 1},
+		{"protected-branches", Params{}, func(e *data.EnvironmentDetails) { e.BranchPolicyType = "custom" }, 1},
+		{"protected-branches", Params{}, func(e *data.EnvironmentDetails) { e.BranchPolicyType = "" }, 1},
+		{"no-admin-bypass", Params{}, func(e *data.EnvironmentDetails) { e.AdminBypass = true }, 1},
+		{"min-wait-timer", Params{Minutes: 10}, func(e *data.EnvironmentDetails) {}, 0},
+		{"min-wait-timer", Params{Minutes: 15}, func(e *data.EnvironmentDetails) {}, 1},
+	}
+	for _, tt := range tests {
+		e := env
+		tt.modify(&e)
+		if got := checks[tt.check].run(e, tt.params); len(got) != tt.want {
+			t.Errorf("%s %+v: expected %d message(s), got %v", tt.check, tt.params, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+// ValidateFormat checks that format is one Write supports.
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatTable, FormatJSON, FormatSARIF:
+		return nil
+	default:
+		return fmt.Errorf("invalid format %q, expected table, json or sarif", format)
+	}
+}
+
+// Write renders result in the given format.
+func Write(out io.Writer, result Result, format string) error {
+	switch format {
+	case FormatTable:
+		return writeTable(out, result)
+	case FormatJSON:
+		return writeJSON(out, result)
+	case FormatSARIF:
+		return writeSARIF(out, result)
+	default:
+		return ValidateFormat(format)
+	}
+}
+
+func writeTable(out io.Writer, result Result) error {
+	if len(result.Violations) > 0 {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Severity\tRule\tRepository\tEnvironment\tMessage")
+		for _, v := range result.Violations {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", v.Severity, v.Rule, v.Repository, v.Environment, v.Message)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(out)
+	}
+	_, err := fmt.Fprintf(out, "%d environment(s) checked: %d error(s), %d warning(s), %d note(s)\n",
+		result.Environments, result.Count(SeverityError), result.Count(SeverityWarning), result.Count(SeverityNote))
+	return err
+}
+
+func writeJSON(out io.Writer, result Result) error {
+	report := struct {
+		Result
+		Errors   int `json:"errors"`
+		Warnings int `json:"warnings"`
+		Notes    int `json:"notes"`
+	}{result, result.Count(SeverityError), result.Count(SeverityWarning), result.Count(SeverityNote)}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+func writeSARIF(out io.Writer, result Result) error {
+	driver := sarifDriver{
+		Name:           "gh-environments",
+		InformationURI: "https://github.com/katiem0/gh-environments",
+		Rules:          []sarifRule{},
+	}
+	ruleIndex := make(map[string]int)
+	for i, rule := range result.Rules {
+		r := sarifRule{ID: rule.ID, ShortDescription: sarifMessage{Text: rule.Description}}
+		r.DefaultConfiguration.Level = string(rule.Severity)
+		driver.Rules = append(driver.Rules, r)
+		ruleIndex[rule.ID] = i
+	}
+
+	results := []sarifResult{}
+	for _, v := range result.Violations {
+		location := fmt.Sprintf("%s/%s/environments/%s", v.Organization, v.Repository, v.Environment)
+		results = append(results, sarifResult{
+			RuleID:    v.Rule,
+			RuleIndex: ruleIndex[v.Rule],
+			Level:     string(v.Severity),
+			Message:   sarifMessage{Text: fmt.Sprintf("%s/%s: environment %s %s", v.Organization, v.Repository, v.Environment, v.Message)},
+			Locations: []sarifLocation{{LogicalLocations: []sarifLogicalLocation{{
+				Name:               v.Environment,
+				FullyQualifiedName: location,
+				Kind:               "resource",
+			}}}},
+		})
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
@@ -0,0 +1,74 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+func sampleResult() Result {
+	return Lint([]data.EnvironmentDetails{{Organization: "testorg", Repository: "app", Name: "production", AdminBypass: true, BranchPolicyType: "protected", PreventSelfReview: true, WaitTimer: 5, Reviewers: make([]data.Reviewers, 2)}}, DefaultConfig())
+}
+
+func TestWriteTable(t *testing.T) {
+	var out bytes.Buffer
+	if err := Write(&out, sampleResult(), FormatTable); err != nil {
+		t.Fatal(err)
+	}
+	output := out.String()
+	if !strings.Contains(output, "production-no-admin-bypass") || !strings.Contains(output, "1 environment(s) checked: 1 error(s), 0 warning(s), 0 note(s)") {
+		t.Errorf("Unexpected table output:\n%s", output)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := Write(&out, sampleResult(), FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Environments int         `json:"environments"`
+		Errors       int         `json:"errors"`
+		Violations   []Violation `json:"violations"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if report.Environments != 1 || report.Errors != 1 || len(report.Violations) != 1 || report.Violations[0].Check != "no-admin-bypass" {
+		t.Errorf("Unexpected JSON report %+v", report)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var out bytes.Buffer
+	if err := Write(&out, sampleResult(), FormatSARIF); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatalf("Invalid SARIF: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Unexpected SARIF log %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != len(DefaultConfig().Rules) || len(run.Results) != 1 {
+		t.Fatalf("Unexpected SARIF run %+v", run)
+	}
+	result := run.Results[0]
+	if run.Tool.Driver.Rules[result.RuleIndex].ID != result.RuleID || result.Level != "error" {
+		t.Errorf("Unexpected SARIF result %+v", result)
+	}
+	if result.Locations[0].LogicalLocations[0].FullyQualifiedName != "testorg/app/environments/production" {
+		t.Errorf("Unexpected location %+v", result.Locations)
+	}
+}
+
+func TestWriteInvalidFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, Result{}, "xml"); err == nil {
+		t.Error("Expected error for invalid format")
+	}
+}
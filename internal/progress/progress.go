@@ -0,0 +1,263 @@
+// Package progress tracks how far a run has got: the repositories processed
+// out of those selected, the environments found and the API calls made, and
+// what the run changed. The tracker travels with the run's context, so the
+// code gathering data and the transport sending requests can update it
+// without it being passed around.
+package progress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Tracker counts the progress of a run. A nil Tracker ignores updates, so
+// code run without one, as in tests, needs no checks.
+type Tracker struct {
+	start        time.Time
+	repositories atomic.Int64
+	processed    atomic.Int64
+	environments atomic.Int64
+	apiCalls     atomic.Int64
+
+	created atomic.Int64
+	updated atomic.Int64
+	deleted atomic.Int64
+	skipped atomic.Int64
+	failed  atomic.Int64
+
+	mu       sync.Mutex
+	warnings []string
+}
+
+// NewTracker returns a tracker for a run starting now.
+func NewTracker() *Tracker {
+	return &Tracker{start: time.Now()}
+}
+
+type contextKey struct{}
+
+// WithTracker returns a copy of ctx carrying t.
+func WithTracker(ctx context.Context, t *Tracker) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the tracker carried by ctx, or nil.
+func FromContext(ctx context.Context) *Tracker {
+	t, _ := ctx.Value(contextKey{}).(*Tracker)
+	return t
+}
+
+// AddRepositories adds n to the number of repositories to process. It is
+// negative when repositories counted from TotalCount are filtered out.
+func (t *Tracker) AddRepositories(n int) {
+	if t != nil {
+		t.repositories.Add(int64(n))
+	}
+}
+
+// RepositoryProcessed counts a repository whose environments were read.
+func (t *Tracker) RepositoryProcessed() {
+	if t != nil {
+		t.processed.Add(1)
+	}
+}
+
+// EnvironmentsFound adds n to the number of environments found.
+func (t *Tracker) EnvironmentsFound(n int) {
+	if t != nil {
+		t.environments.Add(int64(n))
+	}
+}
+
+// APICall counts a request sent to GitHub.
+func (t *Tracker) APICall() {
+	if t != nil {
+		t.apiCalls.Add(1)
+	}
+}
+
+// Created counts an item, such as an environment or secret, the run created.
+func (t *Tracker) Created() {
+	if t != nil {
+		t.created.Add(1)
+	}
+}
+
+// Updated counts an existing item the run updated.
+func (t *Tracker) Updated() {
+	if t != nil {
+		t.updated.Add(1)
+	}
+}
+
+// Deleted counts an existing item the run deleted.
+func (t *Tracker) Deleted() {
+	if t != nil {
+		t.deleted.Add(1)
+	}
+}
+
+// Skipped adds n to the number of items the run left unchanged.
+func (t *Tracker) Skipped(n int) {
+	if t != nil {
+		t.skipped.Add(int64(n))
+	}
+}
+
+// Failed counts an item the run failed to create or update.
+func (t *Tracker) Failed() {
+	if t != nil {
+		t.failed.Add(1)
+	}
+}
+
+// Warn records a problem the run worked around, such as a repository whose
+// environments could not be read.
+func (t *Tracker) Warn(format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.warnings = append(t.warnings, fmt.Sprintf(format, args...))
+}
+
+// Snapshot is the progress of a run at one point in time.
+type Snapshot struct {
+	Repositories int
+	Processed    int
+	Environments int
+	APICalls     int
+	Elapsed      time.Duration
+}
+
+// Snapshot returns the current progress.
+func (t *Tracker) Snapshot() Snapshot {
+	if t == nil {
+		return Snapshot{}
+	}
+	return Snapshot{
+		Repositories: int(t.repositories.Load()),
+		Processed:    int(t.processed.Load()),
+		Environments: int(t.environments.Load()),
+		APICalls:     int(t.apiCalls.Load()),
+		Elapsed:      time.Since(t.start),
+	}
+}
+
+// Started reports whether the run has made any progress to show.
+func (s Snapshot) Started() bool {
+	return s.Repositories > 0 || s.APICalls > 0
+}
+
+// ETA estimates the time left from the rate repositories have been processed
+// at so far. It is false until one has been.
+func (s Snapshot) ETA() (time.Duration, bool) {
+	if s.Processed == 0 || s.Repositories <= s.Processed {
+		return 0, s.Processed > 0
+	}
+	remaining := s.Repositories - s.Processed
+	eta := time.Duration(int64(s.Elapsed) / int64(s.Processed) * int64(remaining))
+	return eta.Round(time.Second), true
+}
+
+// String formats the snapshot as a single line.
+func (s Snapshot) String() string {
+	parts := []string{
+		fmt.Sprintf("%d/%d repositories", s.Processed, s.Repositories),
+		fmt.Sprintf("%d environment(s)", s.Environments),
+		fmt.Sprintf("%d API call(s)", s.APICalls),
+	}
+	if eta, ok := s.ETA(); ok {
+		parts = append(parts, "ETA "+eta.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Transport counts every request it sends in the tracker of the request's
+// context.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	FromContext(req.Context()).APICall()
+	return t.Base.RoundTrip(req)
+}
+
+// Display shows the progress of a tracker until it is stopped.
+type Display struct {
+	tracker     *Tracker
+	out         io.Writer
+	interactive bool
+	interval    time.Duration
+	stop        chan struct{}
+	done        sync.WaitGroup
+}
+
+// RedrawInterval is how often progress is redrawn on a terminal.
+const RedrawInterval = 200 * time.Millisecond
+
+// LogInterval is how often a progress line is written when out is not a
+// terminal.
+const LogInterval = 30 * time.Second
+
+// Start shows the progress of t on out until Stop is called. On a terminal
+// the progress line is redrawn in place; otherwise a line is written every
+// LogInterval, so logs of long non-interactive runs show they are alive.
+func Start(t *Tracker, out io.Writer, interactive bool) *Display {
+	if interactive {
+		return start(t, out, true, RedrawInterval)
+	}
+	return start(t, out, false, LogInterval)
+}
+
+func start(t *Tracker, out io.Writer, interactive bool, interval time.Duration) *Display {
+	d := &Display{tracker: t, out: out, interactive: interactive, interval: interval, stop: make(chan struct{})}
+	d.done.Add(1)
+	go d.run()
+	return d
+}
+
+func (d *Display) run() {
+	defer d.done.Done()
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stop:
+			if d.interactive {
+				// Clear the progress line so it does not mix with the output
+				fmt.Fprint(d.out, "\r\033[K")
+			}
+			return
+		case <-ticker.C:
+			snapshot := d.tracker.Snapshot()
+			if !snapshot.Started() {
+				continue
+			}
+			if d.interactive {
+				// The cursor is left at the start of the line, so output
+				// written next replaces the progress line
+				fmt.Fprintf(d.out, "\r\033[K%s\r", snapshot)
+			} else {
+				fmt.Fprintf(d.out, "Progress: %s\n", snapshot)
+			}
+		}
+	}
+}
+
+// Stop stops the display, clearing the progress line on a terminal.
+func (d *Display) Stop() {
+	if d == nil {
+		return
+	}
+	close(d.stop)
+	d.done.Wait()
+}
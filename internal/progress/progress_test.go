@@ -0,0 +1,127 @@
+package progress
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	// A run without a tracker ignores updates
+	var missing *Tracker
+	missing.AddRepositories(1)
+	missing.APICall()
+	if FromContext(context.Background()) != nil || missing.Snapshot().Started() {
+		t.Error("Expected no tracker in a plain context")
+	}
+
+	tracker := NewTracker()
+	ctx := WithTracker(context.Background(), tracker)
+	FromContext(ctx).AddRepositories(4)
+	FromContext(ctx).AddRepositories(-1)
+	FromContext(ctx).RepositoryProcessed()
+	FromContext(ctx).EnvironmentsFound(2)
+	FromContext(ctx).APICall()
+
+	snapshot := tracker.Snapshot()
+	if snapshot.Repositories != 3 || snapshot.Processed != 1 || snapshot.Environments != 2 || snapshot.APICalls != 1 {
+		t.Errorf("Unexpected snapshot %+v", snapshot)
+	}
+	if !snapshot.Started() {
+		t.Error("Expected the run to have started")
+	}
+}
+
+func TestSnapshotString(t *testing.T) {
+	tests := []struct {
+		name     string
+		snapshot Snapshot
+		want     string
+	}{
+		{"no repository processed", Snapshot{Repositories: 10, APICalls: 1, Elapsed: time.Second}, "0/10 repositories, 0 environment(s), 1 API call(s)"},
+		{"estimate", Snapshot{Repositories: 10, Processed: 2, Environments: 3, APICalls: 12, Elapsed: 10 * time.Second}, "2/10 repositories, 3 environment(s), 12 API call(s), ETA 40s"},
+		{"done", Snapshot{Repositories: 2, Processed: 2, Environments: 2, APICalls: 6, Elapsed: time.Second}, "2/2 repositories, 2 environment(s), 6 API call(s), ETA 0s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.snapshot.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransport(t *testing.T) {
+	tracker := NewTracker()
+	transport := &Transport{Base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})}
+
+	for _, ctx := range []context.Context{WithTracker(context.Background(), tracker), context.Background()} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.github.com/user", nil)
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls := tracker.Snapshot().APICalls; calls != 1 {
+		t.Errorf("Expected the request with a tracker to be counted, got %d", calls)
+	}
+}
+
+// syncBuffer lets the display write while the test reads.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestDisplay(t *testing.T) {
+	tracker := NewTracker()
+	var out syncBuffer
+	display := start(tracker, &out, false, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if out.String() != "" {
+		t.Errorf("Expected nothing before the run has started, got %q", out.String())
+	}
+	tracker.AddRepositories(2)
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(out.String(), "Progress: 0/2 repositories") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	display.Stop()
+	if !strings.HasPrefix(out.String(), "Progress: 0/2 repositories, 0 environment(s), 0 API call(s)\n") {
+		t.Errorf("Expected progress log lines, got %q", out.String())
+	}
+
+	var terminal syncBuffer
+	display = start(tracker, &terminal, true, time.Millisecond)
+	deadline = time.Now().Add(time.Second)
+	for !strings.Contains(terminal.String(), "0/2 repositories") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	display.Stop()
+	if !strings.HasPrefix(terminal.String(), "\r\033[K0/2 repositories") || !strings.HasSuffix(terminal.String(), "\r\033[K") {
+		t.Errorf("Expected the progress line to be redrawn and cleared, got %q", terminal.String())
+	}
+}
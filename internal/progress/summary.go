@@ -0,0 +1,86 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/katiem0/gh-environments/internal/exitcode"
+)
+
+// Summary is what a run did, printed once it ends.
+type Summary struct {
+	RepositoriesScanned   int      `json:"repositories_scanned"`
+	EnvironmentsProcessed int      `json:"environments_processed"`
+	Created               int      `json:"created"`
+	Updated               int      `json:"updated"`
+	Deleted               int      `json:"deleted"`
+	Skipped               int      `json:"skipped"`
+	Failed                int      `json:"failed"`
+	APICalls              int      `json:"api_calls"`
+	Warnings              []string `json:"warnings"`
+	Status                string   `json:"status"`
+	ExitCode              int      `json:"exit_code"`
+	Error                 string   `json:"error,omitempty"`
+}
+
+// Summary returns the summary of a run that ended with err.
+func (t *Tracker) Summary(err error) Summary {
+	code := exitcode.Code(err)
+	summary := Summary{Warnings: []string{}, Status: exitcode.Status(code), ExitCode: code}
+	if err != nil {
+		summary.Error = err.Error()
+	}
+	if t == nil {
+		return summary
+	}
+	summary.RepositoriesScanned = int(t.processed.Load())
+	summary.EnvironmentsProcessed = int(t.environments.Load())
+	summary.Created = int(t.created.Load())
+	summary.Updated = int(t.updated.Load())
+	summary.Deleted = int(t.deleted.Load())
+	summary.Skipped = int(t.skipped.Load())
+	summary.Failed = int(t.failed.Load())
+	summary.APICalls = int(t.apiCalls.Load())
+	t.mu.Lock()
+	summary.Warnings = append(summary.Warnings, t.warnings...)
+	t.mu.Unlock()
+	return summary
+}
+
+// Empty reports whether the run did nothing worth summarizing, as for
+// commands that make no API calls.
+func (s Summary) Empty() bool {
+	return s.APICalls == 0 && s.RepositoriesScanned == 0 && s.Created+s.Updated+s.Deleted+s.Skipped+s.Failed == 0 && len(s.Warnings) == 0
+}
+
+// WriteText writes the summary as a table followed by its warnings.
+func (s Summary) WriteText(out io.Writer) error {
+	fmt.Fprintln(out, "\nSummary:")
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  Repositories scanned\t%d\n", s.RepositoriesScanned)
+	fmt.Fprintf(w, "  Environments processed\t%d\n", s.EnvironmentsProcessed)
+	fmt.Fprintf(w, "  Created\t%d\n", s.Created)
+	fmt.Fprintf(w, "  Updated\t%d\n", s.Updated)
+	fmt.Fprintf(w, "  Deleted\t%d\n", s.Deleted)
+	fmt.Fprintf(w, "  Skipped\t%d\n", s.Skipped)
+	fmt.Fprintf(w, "  Failed\t%d\n", s.Failed)
+	fmt.Fprintf(w, "  API calls\t%d\n", s.APICalls)
+	fmt.Fprintf(w, "  Warnings\t%d\n", len(s.Warnings))
+	fmt.Fprintf(w, "  Status\t%s (exit code %d)\n", s.Status, s.ExitCode)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, warning := range s.Warnings {
+		fmt.Fprintf(out, "Warning: %s\n", warning)
+	}
+	return nil
+}
+
+// WriteJSON writes the summary as a JSON object.
+func (s Summary) WriteJSON(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
@@ -0,0 +1,85 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/exitcode"
+)
+
+func TestSummary(t *testing.T) {
+	// A run without a tracker still reports its outcome
+	var missing *Tracker
+	missing.Created()
+	missing.Warn("ignored")
+	summary := missing.Summary(nil)
+	if !summary.Empty() || summary.Status != "success" || summary.Warnings == nil {
+		t.Errorf("Unexpected summary without a tracker %+v", summary)
+	}
+
+	tracker := NewTracker()
+	tracker.RepositoryProcessed()
+	tracker.EnvironmentsFound(3)
+	tracker.APICall()
+	tracker.Created()
+	tracker.Updated()
+	tracker.Deleted()
+	tracker.Skipped(2)
+	tracker.Failed()
+	tracker.Warn("could not read the environments of %s", "app")
+
+	summary = tracker.Summary(exitcode.Failures(1, 3, errors.New("failed to create 1 environment(s)")))
+	want := Summary{
+		RepositoriesScanned:   1,
+		EnvironmentsProcessed: 3,
+		Created:               1,
+		Updated:               1,
+		Deleted:               1,
+		Skipped:               2,
+		Failed:                1,
+		APICalls:              1,
+		Warnings:              []string{"could not read the environments of app"},
+		Status:                "partial_failure",
+		ExitCode:              exitcode.PartialFailure,
+		Error:                 "failed to create 1 environment(s)",
+	}
+	if summary.Empty() || !reflect.DeepEqual(summary, want) {
+		t.Errorf("Summary() = %+v, want %+v", summary, want)
+	}
+}
+
+func TestSummaryWriteText(t *testing.T) {
+	summary := Summary{RepositoriesScanned: 2, Created: 1, Deleted: 3, Warnings: []string{"could not read the environments of app"}, Status: "success"}
+	var out bytes.Buffer
+	if err := summary.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	for _, want := range []string{"Summary:", "Repositories scanned    2", "Created                 1", "Deleted                 3", "success (exit code 0)", "Warning: could not read the environments of app"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in %q", want, text)
+		}
+	}
+}
+
+func TestSummaryWriteJSON(t *testing.T) {
+	summary := NewTracker().Summary(exitcode.New(exitcode.PolicyViolation, errors.New("drift detected")))
+	var out bytes.Buffer
+	if err := summary.WriteJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("Expected JSON, got %q: %v", out.String(), err)
+	}
+	if decoded["status"] != "policy_violation" || decoded["exit_code"] != float64(exitcode.PolicyViolation) || decoded["error"] != "drift detected" {
+		t.Errorf("Unexpected JSON summary %v", decoded)
+	}
+	if warnings, ok := decoded["warnings"].([]interface{}); !ok || len(warnings) != 0 {
+		t.Errorf("Expected an empty warnings list, got %v", decoded["warnings"])
+	}
+}
@@ -0,0 +1,195 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is a single difference between a snapshot and the live state.
+// Name identifies the item of list fields, such as the reviewer or
+// variable, and is empty for settings and whole environments.
+type Change struct {
+	Repository  string `json:"repository"`
+	Environment string `json:"environment"`
+	Kind        string `json:"kind"`
+	Field       string `json:"field"`
+	Name        string `json:"name,omitempty"`
+	Old         string `json:"old,omitempty"`
+	New         string `json:"new,omitempty"`
+}
+
+// Drift is the result of comparing a snapshot with the current state.
+type Drift struct {
+	Since   time.Time `json:"since"`
+	Changes []Change  `json:"changes"`
+}
+
+// Compare reports the changes from old to current, ordered by repository
+// and environment.
+func Compare(old Snapshot, current Snapshot) Drift {
+	drift := Drift{Since: old.CreatedAt, Changes: []Change{}}
+
+	previous := make(map[string]Environment)
+	for _, env := range old.Environments {
+		previous[env.Repository+"/"+env.Name] = env
+	}
+	seen := make(map[string]bool)
+	for _, env := range current.Environments {
+		key := env.Repository + "/" + env.Name
+		seen[key] = true
+		before, ok := previous[key]
+		if !ok {
+			drift.Changes = append(drift.Changes, Change{Repository: env.Repository, Environment: env.Name, Kind: Added, Field: "environment"})
+			continue
+		}
+		drift.Changes = append(drift.Changes, compareEnvironment(before, env)...)
+	}
+	for _, env := range old.Environments {
+		if !seen[env.Repository+"/"+env.Name] {
+			drift.Changes = append(drift.Changes, Change{Repository: env.Repository, Environment: env.Name, Kind: Removed, Field: "environment"})
+		}
+	}
+	sortChanges(drift.Changes)
+	return drift
+}
+
+func compareEnvironment(old Environment, current Environment) []Change {
+	var changes []Change
+	setting := func(field string, before string, after string) {
+		if before != after {
+			changes = append(changes, Change{Kind: Changed, Field: field, Old: before, New: after})
+		}
+	}
+	setting("admin_bypass", strconv.FormatBool(old.AdminBypass), strconv.FormatBool(current.AdminBypass))
+	setting("wait_timer", strconv.Itoa(old.WaitTimer), strconv.Itoa(current.WaitTimer))
+	setting("prevent_self_review", strconv.FormatBool(old.PreventSelfReview), strconv.FormatBool(current.PreventSelfReview))
+	setting("branch_policy_type", old.BranchPolicyType, current.BranchPolicyType)
+
+	changes = append(changes, compareNames("reviewer", old.Reviewers, current.Reviewers)...)
+	changes = append(changes, compareNames("branch_policy", old.BranchPolicies, current.BranchPolicies)...)
+	changes = append(changes, compareNames("protection_rule", old.ProtectionRules, current.ProtectionRules)...)
+
+	oldVariables := make(map[string]Variable)
+	for _, variable := range old.Variables {
+		oldVariables[variable.Name] = variable
+	}
+	for _, variable := range current.Variables {
+		before, ok := oldVariables[variable.Name]
+		delete(oldVariables, variable.Name)
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: Added, Field: "variable", Name: variable.Name, New: variable.Value})
+		case before.Value != variable.Value:
+			changes = append(changes, Change{Kind: Changed, Field: "variable", Name: variable.Name, Old: before.Value, New: variable.Value})
+		case !before.UpdatedAt.Equal(variable.UpdatedAt):
+			changes = append(changes, Change{Kind: Changed, Field: "variable_updated_at", Name: variable.Name, Old: formatTime(before.UpdatedAt), New: formatTime(variable.UpdatedAt)})
+		}
+	}
+	for _, variable := range old.Variables {
+		if _, ok := oldVariables[variable.Name]; ok {
+			changes = append(changes, Change{Kind: Removed, Field: "variable", Name: variable.Name, Old: variable.Value})
+		}
+	}
+
+	oldSecrets := make(map[string]Secret)
+	for _, secret := range old.Secrets {
+		oldSecrets[secret.Name] = secret
+	}
+	for _, secret := range current.Secrets {
+		before, ok := oldSecrets[secret.Name]
+		delete(oldSecrets, secret.Name)
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: Added, Field: "secret", Name: secret.Name, New: formatTime(secret.UpdatedAt)})
+		case !before.UpdatedAt.Equal(secret.UpdatedAt):
+			changes = append(changes, Change{Kind: Changed, Field: "secret_updated_at", Name: secret.Name, Old: formatTime(before.UpdatedAt), New: formatTime(secret.UpdatedAt)})
+		}
+	}
+	for _, secret := range old.Secrets {
+		if _, ok := oldSecrets[secret.Name]; ok {
+			changes = append(changes, Change{Kind: Removed, Field: "secret", Name: secret.Name, Old: formatTime(secret.UpdatedAt)})
+		}
+	}
+
+	for i := range changes {
+		changes[i].Repository = current.Repository
+		changes[i].Environment = current.Name
+	}
+	return changes
+}
+
+// compareNames reports the items added to and removed from a sorted list.
+func compareNames(field string, old []string, current []string) []Change {
+	var changes []Change
+	before := make(map[string]bool)
+	for _, name := range old {
+		before[name] = true
+	}
+	after := make(map[string]bool)
+	for _, name := range current {
+		after[name] = true
+		if !before[name] {
+			changes = append(changes, Change{Kind: Added, Field: field, Name: name})
+		}
+	}
+	for _, name := range old {
+		if !after[name] {
+			changes = append(changes, Change{Kind: Removed, Field: field, Name: name})
+		}
+	}
+	return changes
+}
+
+// sortChanges orders changes by repository and environment. Changes within
+// an environment keep the order they were found in.
+func sortChanges(changes []Change) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+		return a.Environment < b.Environment
+	})
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// WriteTable prints one line per change followed by a summary.
+func (d Drift) WriteTable(out io.Writer) error {
+	if len(d.Changes) > 0 {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Repository\tEnvironment\tChange\tField\tName\tOld\tNew")
+		for _, c := range d.Changes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", c.Repository, c.Environment, c.Kind, c.Field, c.Name, c.Old, c.New)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(out)
+	}
+	_, err := fmt.Fprintf(out, "%d change(s) since %s\n", len(d.Changes), formatTime(d.Since))
+	return err
+}
+
+// WriteJSON encodes the drift as indented JSON.
+func (d Drift) WriteJSON(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(d)
+}
@@ -0,0 +1,90 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+func TestCompare(t *testing.T) {
+	old := New("github.com", "testorg", sampleDetails(), taken)
+
+	later := taken.Add(24 * time.Hour)
+	details := sampleDetails()
+	app := &details[1]
+	app.AdminBypass = true
+	app.WaitTimer = 0
+	app.Reviewers = app.Reviewers[:1]
+	app.Reviewers = append(app.Reviewers, data.Reviewers{Type: "User", Reviewer: data.Reviewer{Login: "hubot"}})
+	app.Variables = []data.Variable{{Name: "URL", Value: "https://example.org", UpdatedAt: later}, {Name: "REGION", Value: "eu"}}
+	app.Secrets = []data.Secret{{Name: "TOKEN", UpdatedAt: later}}
+	details[0].BranchPolicies = details[0].BranchPolicies[:1]
+	details = append(details, data.EnvironmentDetails{Repository: "api", Name: "dev"})
+	current := New("github.com", "testorg", details, later)
+	old.Environments = append(old.Environments, Environment{Repository: "web", Name: "qa"})
+
+	drift := Compare(old, current)
+	var got []string
+	for _, c := range drift.Changes {
+		got = append(got, strings.Join([]string{c.Repository, c.Environment, c.Kind, c.Field, c.Name, c.Old, c.New}, "|"))
+	}
+	want := []string{
+		"api|dev|added|environment|||",
+		"app|production|changed|admin_bypass||false|true",
+		"app|production|changed|wait_timer||10|0",
+		"app|production|added|reviewer|User:hubot||",
+		"app|production|removed|reviewer|Team:platform||",
+		"app|production|added|variable|REGION||eu",
+		"app|production|changed|variable|URL|https://example.com|https://example.org",
+		"app|production|changed|secret_updated_at|TOKEN|2024-06-01T12:00:00Z|2024-06-02T12:00:00Z",
+		"web|qa|removed|environment|||",
+		"web|staging|removed|branch_policy|tag:v*||",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if !drift.Since.Equal(taken) {
+		t.Errorf("Expected since %v, got %v", taken, drift.Since)
+	}
+
+	if changes := Compare(old, old).Changes; len(changes) != 0 {
+		t.Errorf("Expected no changes comparing a snapshot with itself, got %+v", changes)
+	}
+}
+
+func TestDriftOutput(t *testing.T) {
+	drift := Drift{Since: taken, Changes: []Change{{Repository: "app", Environment: "production", Kind: Changed, Field: "wait_timer", Old: "10", New: "0"}}}
+
+	var out bytes.Buffer
+	if err := drift.WriteTable(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "app         production   changed  wait_timer") || !strings.Contains(out.String(), "1 change(s) since 2024-06-01T12:00:00Z") {
+		t.Errorf("Unexpected table:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := (Drift{Since: taken, Changes: []Change{}}).WriteTable(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "0 change(s) since 2024-06-01T12:00:00Z\n" {
+		t.Errorf("Unexpected table %q", out.String())
+	}
+
+	out.Reset()
+	if err := drift.WriteJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Drift
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, drift) {
+		t.Errorf("Unexpected JSON %s", out.String())
+	}
+}
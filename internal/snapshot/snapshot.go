@@ -0,0 +1,157 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+// Version is the snapshot format version. It is incremented whenever a
+// change to the format would make older snapshots compare incorrectly.
+const Version = 1
+
+// Snapshot is the normalized state of an organization's environments at a
+// point in time. Lists are sorted so that snapshots can be compared and
+// diffed as text.
+type Snapshot struct {
+	Version      int           `json:"version"`
+	Host         string        `json:"host"`
+	Organization string        `json:"organization"`
+	CreatedAt    time.Time     `json:"created_at"`
+	Environments []Environment `json:"environments"`
+}
+
+type Environment struct {
+	Repository        string `json:"repository"`
+	Name              string `json:"name"`
+	AdminBypass       bool   `json:"admin_bypass"`
+	WaitTimer         int    `json:"wait_timer"`
+	PreventSelfReview bool   `json:"prevent_self_review"`
+	// Reviewers are Team:<slug> or User:<login>
+	Reviewers        []string `json:"reviewers"`
+	BranchPolicyType string   `json:"branch_policy_type"`
+	// BranchPolicies are <type>:<name>, such as branch:release/*
+	BranchPolicies []string `json:"branch_policies"`
+	// ProtectionRules are the slugs of the enabled protection rule apps
+	ProtectionRules []string   `json:"protection_rules"`
+	Variables       []Variable `json:"variables"`
+	Secrets         []Secret   `json:"secrets"`
+}
+
+type Variable struct {
+	Name      string    `json:"name"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Secret holds only metadata; secret values cannot be read from the API.
+type Secret struct {
+	Name      string    `json:"name"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// New normalizes environments into a snapshot taken at createdAt.
+func New(host string, owner string, environments []data.EnvironmentDetails, createdAt time.Time) Snapshot {
+	snapshot := Snapshot{
+		Version:      Version,
+		Host:         host,
+		Organization: owner,
+		CreatedAt:    createdAt.UTC(),
+		Environments: []Environment{},
+	}
+	for _, env := range environments {
+		snapshot.Environments = append(snapshot.Environments, NewEnvironment(env))
+	}
+	sort.Slice(snapshot.Environments, func(i, j int) bool {
+		a, b := snapshot.Environments[i], snapshot.Environments[j]
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+		return a.Name < b.Name
+	})
+	return snapshot
+}
+
+// NewEnvironment normalizes a single environment.
+func NewEnvironment(env data.EnvironmentDetails) Environment {
+	normalized := Environment{
+		Repository:        env.Repository,
+		Name:              env.Name,
+		AdminBypass:       env.AdminBypass,
+		WaitTimer:         env.WaitTimer,
+		PreventSelfReview: env.PreventSelfReview,
+		BranchPolicyType:  env.BranchPolicyType,
+		Reviewers:         []string{},
+		BranchPolicies:    []string{},
+		ProtectionRules:   []string{},
+		Variables:         []Variable{},
+		Secrets:           []Secret{},
+	}
+	for _, reviewer := range env.Reviewers {
+		name := reviewer.Reviewer.Login
+		if reviewer.Type == "Team" && reviewer.Reviewer.Slug != "" {
+			name = reviewer.Reviewer.Slug
+		}
+		normalized.Reviewers = append(normalized.Reviewers, reviewer.Type+":"+name)
+	}
+	for _, branch := range env.BranchPolicies {
+		normalized.BranchPolicies = append(normalized.BranchPolicies, branch.Type+":"+branch.Name)
+	}
+	for _, rule := range env.ProtectionRules {
+		if rule.Enabled {
+			normalized.ProtectionRules = append(normalized.ProtectionRules, rule.App.Slug)
+		}
+	}
+	for _, variable := range env.Variables {
+		normalized.Variables = append(normalized.Variables, Variable{Name: variable.Name, Value: variable.Value, UpdatedAt: variable.UpdatedAt.UTC()})
+	}
+	for _, secret := range env.Secrets {
+		normalized.Secrets = append(normalized.Secrets, Secret{Name: secret.Name, UpdatedAt: secret.UpdatedAt.UTC()})
+	}
+	sort.Strings(normalized.Reviewers)
+	sort.Strings(normalized.BranchPolicies)
+	sort.Strings(normalized.ProtectionRules)
+	sort.Slice(normalized.Variables, func(i, j int) bool { return normalized.Variables[i].Name < normalized.Variables[j].Name })
+	sort.Slice(normalized.Secrets, func(i, j int) bool { return normalized.Secrets[i].Name < normalized.Secrets[j].Name })
+	return normalized
+}
+
+// Filter keeps only the environments for which keep returns true, so that a
+// snapshot can be compared with a live state gathered for fewer repositories.
+func (s *Snapshot) Filter(keep func(repo string, env string) bool) {
+	environments := []Environment{}
+	for _, env := range s.Environments {
+		if keep(env.Repository, env.Name) {
+			environments = append(environments, env)
+		}
+	}
+	s.Environments = environments
+}
+
+// Write encodes the snapshot as indented JSON.
+func (s Snapshot) Write(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// Read loads a snapshot file, rejecting versions this build cannot compare.
+func Read(fileName string) (Snapshot, error) {
+	var snapshot Snapshot
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return snapshot, err
+	}
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("%s: %w", fileName, err)
+	}
+	if snapshot.Version < 1 || snapshot.Version > Version {
+		return snapshot, fmt.Errorf("%s: unsupported snapshot version %d, expected %d", fileName, snapshot.Version, Version)
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,101 @@
+package snapshot
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+var taken = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func sampleDetails() []data.EnvironmentDetails {
+	return []data.EnvironmentDetails{
+		{
+			Repository:       "web",
+			Name:             "staging",
+			BranchPolicyType: "custom",
+			BranchPolicies:   []data.BranchPolicy{{Name: "main", Type: "branch"}, {Name: "v*", Type: "tag"}},
+		},
+		{
+			Repository:        "app",
+			Name:              "production",
+			WaitTimer:         10,
+			PreventSelfReview: true,
+			Reviewers: []data.Reviewers{
+				{Type: "User", Reviewer: data.Reviewer{Login: "octocat", ID: 1}},
+				{Type: "Team", Reviewer: data.Reviewer{Slug: "platform", ID: 2}},
+			},
+			ProtectionRules: []data.DeploymentProtectionPolicyApp{
+				{Enabled: true, App: data.DeploymentApp{Slug: "datadog"}},
+				{Enabled: false, App: data.DeploymentApp{Slug: "sentry"}},
+			},
+			Variables: []data.Variable{{Name: "URL", Value: "https://example.com", UpdatedAt: taken}},
+			Secrets:   []data.Secret{{Name: "TOKEN", UpdatedAt: taken}},
+		},
+	}
+}
+
+func TestNew(t *testing.T) {
+	s := New("github.com", "testorg", sampleDetails(), taken)
+
+	if s.Version != Version || s.Host != "github.com" || s.Organization != "testorg" || !s.CreatedAt.Equal(taken) {
+		t.Errorf("Unexpected snapshot header %+v", s)
+	}
+	if len(s.Environments) != 2 || s.Environments[0].Repository != "app" {
+		t.Fatalf("Expected environments sorted by repository, got %+v", s.Environments)
+	}
+	app := s.Environments[0]
+	if !reflect.DeepEqual(app.Reviewers, []string{"Team:platform", "User:octocat"}) {
+		t.Errorf("Unexpected reviewers %v", app.Reviewers)
+	}
+	if !reflect.DeepEqual(app.ProtectionRules, []string{"datadog"}) {
+		t.Errorf("Expected only enabled protection rules, got %v", app.ProtectionRules)
+	}
+	if !reflect.DeepEqual(s.Environments[1].BranchPolicies, []string{"branch:main", "tag:v*"}) {
+		t.Errorf("Unexpected branch policies %v", s.Environments[1].BranchPolicies)
+	}
+	if s.Environments[1].Variables == nil || s.Environments[1].Secrets == nil {
+		t.Error("Expected empty lists rather than nil")
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "snapshot.json")
+	var buf bytes.Buffer
+	s := New("github.com", "testorg", sampleDetails(), taken)
+	if err := s.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fileName, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := Read(fileName)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if !reflect.DeepEqual(read, s) {
+		t.Errorf("Snapshot changed on round trip:\n%+v\n%+v", read, s)
+	}
+
+	if err := os.WriteFile(fileName, []byte(`{"version": 2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(fileName); err == nil || !strings.Contains(err.Error(), "unsupported snapshot version 2") {
+		t.Errorf("Expected version error, got %v", err)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	s := New("github.com", "testorg", sampleDetails(), taken)
+	s.Filter(func(repo string, env string) bool { return repo == "web" })
+	if len(s.Environments) != 1 || s.Environments[0].Name != "staging" {
+		t.Errorf("Unexpected environments %+v", s.Environments)
+	}
+}
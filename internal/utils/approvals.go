@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"go.uber.org/zap"
+)
+
+const workflowRunsPerPage = 100
+
+// GetWaitingRuns returns a page of the workflow runs of repo that are waiting
+// for an environment's protection rules.
+func (g *APIGetter) GetWaitingRuns(ctx context.Context, owner string, repo string, page int) ([]byte, error) {
+	url := fmt.Sprintf("repos/%s/%s/actions/runs?status=waiting&per_page=%d&page=%d", owner, repo, workflowRunsPerPage, page)
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP error for URL %s: %v", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Error closing response body: %v", closeErr)
+		}
+	}()
+	responseData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body from URL %s: %w", url, err)
+	}
+	return responseData, nil
+}
+
+func (g *APIGetter) GetPendingDeployments(ctx context.Context, owner string, repo string, runID int) ([]byte, error) {
+	url := fmt.Sprintf("repos/%s/%s/actions/runs/%d/pending_deployments", owner, repo, runID)
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP error for URL %s: %v", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Error closing response body: %v", closeErr)
+		}
+	}()
+	responseData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body from URL %s: %w", url, err)
+	}
+	return responseData, nil
+}
+
+// ReviewPendingDeployments approves or rejects the environments of a run that
+// are waiting for review.
+func (g *APIGetter) ReviewPendingDeployments(ctx context.Context, owner string, repo string, runID int, data io.Reader) error {
+	url := fmt.Sprintf("repos/%s/%s/actions/runs/%d/pending_deployments", owner, repo, runID)
+	resp, err := g.restClient.RequestWithContext(ctx, "POST", url, data)
+	if err != nil {
+		return fmt.Errorf("HTTP error for URL %s: %v", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Error closing response body: %v", closeErr)
+		}
+	}()
+	return nil
+}
+
+type approvalGetter interface {
+	GetWaitingRuns(ctx context.Context, owner string, repo string, page int) ([]byte, error)
+	GetPendingDeployments(ctx context.Context, owner string, repo string, runID int) ([]byte, error)
+}
+
+// PendingApproval is an environment that a workflow run is waiting to deploy
+// to until a required reviewer approves it.
+type PendingApproval struct {
+	Repository    string    `json:"repository"`
+	RunID         int       `json:"run_id"`
+	RunNumber     int       `json:"run_number"`
+	Workflow      string    `json:"workflow"`
+	Branch        string    `json:"branch"`
+	Actor         string    `json:"actor"`
+	CreatedAt     time.Time `json:"created_at"`
+	URL           string    `json:"url"`
+	Environment   string    `json:"environment"`
+	EnvironmentID int       `json:"environment_id"`
+	CanApprove    bool      `json:"can_approve"`
+	Reviewers     []string  `json:"reviewers"`
+}
+
+// GatherPendingApprovals returns the environments of envs that waiting runs of
+// repo need approval to deploy to, oldest run first.
+func GatherPendingApprovals(ctx context.Context, g approvalGetter, owner string, repo string, envs EnvFilter) ([]PendingApproval, error) {
+	var runs []data.WorkflowRun
+	for page := 1; ; page++ {
+		resp, err := g.GetWaitingRuns(ctx, owner, repo, page)
+		if err != nil {
+			return nil, err
+		}
+		var pageRuns data.WorkflowRunsResponse
+		if err := json.Unmarshal(resp, &pageRuns); err != nil {
+			return nil, err
+		}
+		runs = append(runs, pageRuns.WorkflowRuns...)
+		if len(pageRuns.WorkflowRuns) < workflowRunsPerPage {
+			break
+		}
+	}
+
+	var approvals []PendingApproval
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		zap.S().Debugf("Gathering pending deployments of run %d in %s", run.ID, repo)
+		resp, err := g.GetPendingDeployments(ctx, owner, repo, run.ID)
+		if err != nil {
+			return nil, err
+		}
+		var pending []data.PendingDeployment
+		if err := json.Unmarshal(resp, &pending); err != nil {
+			return nil, err
+		}
+		for _, deployment := range pending {
+			// Runs also wait on wait timers and custom protection rules;
+			// only environments with reviewers can be approved here
+			if len(deployment.Reviewers) == 0 || !envs.Match(deployment.Environment.Name) {
+				continue
+			}
+			approval := PendingApproval{
+				Repository:    repo,
+				RunID:         run.ID,
+				RunNumber:     run.RunNumber,
+				Workflow:      run.Name,
+				Branch:        run.HeadBranch,
+				Actor:         run.Actor.Login,
+				CreatedAt:     run.CreatedAt,
+				URL:           run.HTMLURL,
+				Environment:   deployment.Environment.Name,
+				EnvironmentID: deployment.Environment.ID,
+				CanApprove:    deployment.CurrentUserCanApprove,
+				Reviewers:     []string{},
+			}
+			for _, reviewer := range deployment.Reviewers {
+				name := reviewer.Reviewer.Login
+				if reviewer.Type == "Team" && reviewer.Reviewer.Slug != "" {
+					name = reviewer.Reviewer.Slug
+				}
+				approval.Reviewers = append(approval.Reviewers, reviewer.Type+":"+name)
+			}
+			approvals = append(approvals, approval)
+		}
+	}
+	return approvals, nil
+}
+
+// GatherOrgPendingApprovals returns the pending approvals of every repository
+// of owner selected by repos and filter.
+func GatherOrgPendingApprovals(ctx context.Context, g *APIGetter, owner string, repos []string, filter RepoFilter, envs EnvFilter) ([]PendingApproval, error) {
+	allRepos, err := GatherRepos(ctx, g, owner, repos, filter)
+	if err != nil {
+		zap.S().Error("Error raised in gathering repos", zap.Error(err))
+		return nil, err
+	}
+	allRepos, _, err = SelectReposByProperties(ctx, g, owner, allRepos, filter.Properties)
+	if err != nil {
+		zap.S().Error("Error raised in gathering repository custom properties", zap.Error(err))
+		return nil, err
+	}
+
+	var approvals []PendingApproval
+	tracker := progress.FromContext(ctx)
+	for _, repo := range allRepos {
+		repoApprovals, err := GatherPendingApprovals(ctx, g, owner, repo.Name, envs)
+		if err != nil {
+			zap.S().Errorf("Error raised in gathering pending deployments for %s", repo.Name)
+			return nil, err
+		}
+		approvals = append(approvals, repoApprovals...)
+		tracker.RepositoryProcessed()
+	}
+	return approvals, nil
+}
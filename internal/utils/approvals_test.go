@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+func pendingDeployment(id int, name string, canApprove bool, reviewers ...data.Reviewers) data.PendingDeployment {
+	pending := data.PendingDeployment{CurrentUserCanApprove: canApprove, Reviewers: reviewers}
+	pending.Environment.ID = id
+	pending.Environment.Name = name
+	return pending
+}
+
+func TestGatherPendingApprovals(t *testing.T) {
+	ctx := context.Background()
+	team := data.Reviewers{Type: "Team", Reviewer: data.Reviewer{ID: 2, Login: "Release Managers", Slug: "release-managers"}}
+	user := data.Reviewers{Type: "User", Reviewer: data.Reviewer{ID: 1, Login: "octocat"}}
+	created := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	server := NewMockGitHubServer()
+	server.AddRepo(1, "app").WaitingRuns = []*MockWorkflowRun{
+		{
+			WorkflowRun: data.WorkflowRun{ID: 20, Name: "Deploy", HeadBranch: "main", Actor: data.User{Login: "hubot"}, CreatedAt: created.Add(time.Hour)},
+			Pending: []data.PendingDeployment{
+				pendingDeployment(100, "production", true, team, user),
+				pendingDeployment(101, "staging", false),
+			},
+		},
+		{
+			WorkflowRun: data.WorkflowRun{ID: 10, Name: "Deploy", HeadBranch: "fix", Actor: data.User{Login: "octocat"}, CreatedAt: created},
+			Pending:     []data.PendingDeployment{pendingDeployment(101, "staging", false, user)},
+		},
+	}
+	g := newMockServerGetter(t, server)
+
+	approvals, err := GatherPendingApprovals(ctx, g, "testorg", "app", EnvFilter{})
+	if err != nil {
+		t.Fatalf("GatherPendingApprovals() error = %v", err)
+	}
+	want := []PendingApproval{
+		{Repository: "app", RunID: 10, Workflow: "Deploy", Branch: "fix", Actor: "octocat", CreatedAt: created, Environment: "staging", EnvironmentID: 101, Reviewers: []string{"User:octocat"}},
+		{Repository: "app", RunID: 20, Workflow: "Deploy", Branch: "main", Actor: "hubot", CreatedAt: created.Add(time.Hour), Environment: "production", EnvironmentID: 100, CanApprove: true, Reviewers: []string{"Team:release-managers", "User:octocat"}},
+	}
+	if !reflect.DeepEqual(approvals, want) {
+		t.Errorf("GatherPendingApprovals() = %+v, want %+v", approvals, want)
+	}
+
+	approvals, err = GatherPendingApprovals(ctx, g, "testorg", "app", EnvFilter{Globs: []string{"prod*"}})
+	if err != nil {
+		t.Fatalf("GatherPendingApprovals() error = %v", err)
+	}
+	if len(approvals) != 1 || approvals[0].Environment != "production" {
+		t.Errorf("Expected only production, got %+v", approvals)
+	}
+}
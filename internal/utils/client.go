@@ -0,0 +1,252 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/cli/go-gh/v2/pkg/auth"
+	"github.com/katiem0/gh-environments/internal/config"
+	"github.com/katiem0/gh-environments/internal/githubapp"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"github.com/katiem0/gh-environments/internal/version"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+// appAuthToken satisfies go-gh's check for a token when a GitHub App
+// transport replaces the Authorization header of every request.
+const appAuthToken = "github-app"
+
+// ClientConfig is what every command needs to connect to GitHub.
+type ClientConfig struct {
+	Hostname string
+	Token    string
+	// TokenSource is where the token is read from when Token is empty, as
+	// set by the profile: gh, env:NAME or file:PATH
+	TokenSource string
+	// Owner is the organization whose GitHub App installation is used for
+	// requests that do not name one
+	Owner string
+	App   githubapp.Config
+	// CABundle is the path to PEM certificates trusted in addition to the
+	// system ones
+	CABundle string
+	// Proxy is the URL of the proxy to use instead of the one from the
+	// HTTPS_PROXY environment variable
+	Proxy string
+	// Timeout limits each API request, no limit when zero
+	Timeout   time.Duration
+	UserAgent string
+	// Concurrency is the number of repositories gathered at the same time
+	Concurrency int
+}
+
+// DefaultUserAgent identifies this extension and its version in requests.
+func DefaultUserAgent() string {
+	return "gh-environments/" + version.Version()
+}
+
+// AddClientFlags registers the flags read by ClientConfigFromFlags. They are
+// persistent flags of the root command so every command connects the same
+// way.
+func AddClientFlags(flags *pflag.FlagSet) {
+	flags.StringP("token", "t", "", `GitHub personal access token (default "gh auth token")`)
+	flags.String("hostname", "github.com", "GitHub Enterprise Server hostname")
+	flags.BoolP("debug", "d", false, "To debug logging")
+	flags.String("ca-bundle", "", "Path to PEM certificates to trust in addition to the system ones")
+	flags.String("proxy", "", "Proxy URL (default: the HTTPS_PROXY environment variable)")
+	flags.Duration("request-timeout", 0, "Time limit for each API request, such as 30s (default: no limit)")
+	flags.String("user-agent", DefaultUserAgent(), "User-Agent sent with every request")
+	flags.Int("concurrency", 1, "Number of repositories to gather at the same time")
+	AddAppFlags(flags)
+}
+
+// AddAppFlags registers the GitHub App authentication flags.
+func AddAppFlags(flags *pflag.FlagSet) {
+	flags.Int64("app-id", 0, "GitHub App ID to authenticate as, instead of a token")
+	flags.String("private-key", "", "Path to the PEM private key of the GitHub App")
+	flags.Int64("installation-id", 0, "GitHub App installation ID (default: the installation on each organization)")
+}
+
+// ClientConfigFromFlags reads the flags registered by AddClientFlags. Flags
+// that are not registered, as when a command runs without the root command,
+// are left unset.
+func ClientConfigFromFlags(flags *pflag.FlagSet) ClientConfig {
+	var cfg ClientConfig
+	cfg.Hostname, _ = flags.GetString("hostname")
+	cfg.Token, _ = flags.GetString("token")
+	cfg.TokenSource = config.TokenSource(flags)
+	cfg.CABundle, _ = flags.GetString("ca-bundle")
+	cfg.Proxy, _ = flags.GetString("proxy")
+	cfg.Timeout, _ = flags.GetDuration("request-timeout")
+	cfg.UserAgent, _ = flags.GetString("user-agent")
+	cfg.Concurrency, _ = flags.GetInt("concurrency")
+	cfg.App = AppConfigFromFlags(flags)
+	if cfg.Hostname == "" {
+		cfg.Hostname = "github.com"
+	}
+	return cfg
+}
+
+// AppConfigFromFlags reads the flags registered by AddAppFlags.
+func AppConfigFromFlags(flags *pflag.FlagSet) githubapp.Config {
+	var cfg githubapp.Config
+	cfg.AppID, _ = flags.GetInt64("app-id")
+	cfg.PrivateKeyPath, _ = flags.GetString("private-key")
+	cfg.InstallationID, _ = flags.GetInt64("installation-id")
+	return cfg
+}
+
+// NewTransport returns the transport shared by the API clients and GitHub
+// App token requests, trusting cfg.CABundle, using cfg.Proxy and sending
+// cfg.UserAgent. Requests are counted in the progress tracker of their
+// context.
+func NewTransport(cfg ClientConfig) (http.RoundTripper, error) {
+	base := http.DefaultTransport
+	if cfg.CABundle != "" || cfg.Proxy != "" {
+		transport, ok := base.(*http.Transport)
+		if ok {
+			transport = transport.Clone()
+		} else {
+			transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+		}
+		if err := configureTransport(transport, cfg); err != nil {
+			return nil, err
+		}
+		base = transport
+	}
+
+	userAgent := cfg.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent()
+	}
+	return &userAgentTransport{userAgent: userAgent, base: &progress.Transport{Base: base}}, nil
+}
+
+func configureTransport(transport *http.Transport, cfg ClientConfig) error {
+	if cfg.CABundle != "" {
+		pem, err := os.ReadFile(cfg.CABundle)
+		if err != nil {
+			return fmt.Errorf("reading CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in CA bundle %s", cfg.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	if cfg.Proxy != "" {
+		proxy, err := url.Parse(cfg.Proxy)
+		if err != nil || proxy.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q", cfg.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return nil
+}
+
+// userAgentTransport sets the User-Agent of every request, replacing the one
+// go-gh adds.
+type userAgentTransport struct {
+	userAgent string
+	base      http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.base.RoundTrip(req)
+}
+
+// NewClients returns the GraphQL and REST clients used by APIGetter. The
+// token is taken from cfg.Token, then the gh CLI, unless a GitHub App is
+// configured.
+func NewClients(cfg ClientConfig) (*api.GraphQLClient, *api.RESTClient, error) {
+	transport, err := NewTransport(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gqlOptions := api.ClientOptions{
+		Headers: map[string]string{
+			"Accept": "application/vnd.github.hawkgirl-preview+json",
+		},
+		Host:      cfg.Hostname,
+		Timeout:   cfg.Timeout,
+		Transport: transport,
+	}
+	restOptions := api.ClientOptions{
+		Headers: map[string]string{
+			"Accept": "application/vnd.github+json",
+		},
+		Host:      cfg.Hostname,
+		Timeout:   cfg.Timeout,
+		Transport: transport,
+	}
+
+	if cfg.App.Enabled() {
+		if cfg.Token != "" {
+			return nil, nil, errors.New("--token cannot be combined with GitHub App authentication")
+		}
+		source, err := githubapp.NewTokenSource(cfg.App, cfg.Hostname, transport)
+		if err != nil {
+			return nil, nil, err
+		}
+		appTransport := &githubapp.Transport{Source: source, Owner: cfg.Owner, Base: transport}
+		for _, opts := range []*api.ClientOptions{&gqlOptions, &restOptions} {
+			opts.AuthToken = appAuthToken
+			opts.Transport = appTransport
+		}
+	} else {
+		token := cfg.Token
+		if token == "" {
+			var err error
+			token, err = config.Profile{Token: cfg.TokenSource}.ResolveToken()
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		if token == "" {
+			token, _ = auth.TokenForHost(cfg.Hostname)
+		}
+		gqlOptions.AuthToken = token
+		restOptions.AuthToken = token
+	}
+
+	gqlClient, err := api.NewGraphQLClient(gqlOptions)
+	if err != nil {
+		zap.S().Errorf("Error arose retrieving graphql client")
+		return nil, nil, err
+	}
+	restClient, err := api.NewRESTClient(restOptions)
+	if err != nil {
+		zap.S().Errorf("Error arose retrieving rest client")
+		return nil, nil, err
+	}
+	return gqlClient, restClient, nil
+}
+
+// NewGetter returns the APIGetter every command uses, connected as described
+// by cfg.
+func NewGetter(cfg ClientConfig) (*APIGetter, error) {
+	if cfg.Concurrency < 0 {
+		return nil, errors.New("--concurrency cannot be negative")
+	}
+	gqlClient, restClient, err := NewClients(cfg)
+	if err != nil {
+		return nil, err
+	}
+	g := NewAPIGetter(gqlClient, restClient)
+	g.concurrency = cfg.Concurrency
+	return g, nil
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/config"
+	"github.com/katiem0/gh-environments/internal/githubapp"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"github.com/spf13/pflag"
+)
+
+func TestAppConfigFromFlags(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddAppFlags(flags)
+	if err := flags.Parse([]string{"--app-id", "12", "--private-key", "app.pem", "--installation-id", "34"}); err != nil {
+		t.Fatal(err)
+	}
+	want := githubapp.Config{AppID: 12, PrivateKeyPath: "app.pem", InstallationID: 34}
+	if got := AppConfigFromFlags(flags); got != want {
+		t.Errorf("AppConfigFromFlags() = %+v, want %+v", got, want)
+	}
+
+	if got := AppConfigFromFlags(pflag.NewFlagSet("empty", pflag.ContinueOnError)); got.Enabled() {
+		t.Errorf("Expected no app configuration without the flags, got %+v", got)
+	}
+}
+
+func TestNewClients(t *testing.T) {
+	gqlClient, restClient, err := NewClients(ClientConfig{Hostname: "github.com", Token: "token"})
+	if err != nil || gqlClient == nil || restClient == nil {
+		t.Fatalf("NewClients() = %v, %v, %v", gqlClient, restClient, err)
+	}
+
+	invalid := []ClientConfig{
+		{Hostname: "github.com", Token: "token", App: githubapp.Config{AppID: 1, PrivateKeyPath: "app.pem"}},
+		{Hostname: "github.com", App: githubapp.Config{InstallationID: 1}},
+		{Hostname: "github.com", App: githubapp.Config{AppID: 1, PrivateKeyPath: "missing.pem"}},
+	}
+	for _, cfg := range invalid {
+		if _, _, err := NewClients(cfg); err == nil {
+			t.Errorf("Expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestClientConfigFromFlags(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddClientFlags(flags)
+	if err := flags.Parse([]string{"--hostname", "ghes.example.com", "--token", "abc", "--proxy", "http://proxy:3128", "--request-timeout", "30s", "--user-agent", "audit/1.0"}); err != nil {
+		t.Fatal(err)
+	}
+	got := ClientConfigFromFlags(flags)
+	if got.Hostname != "ghes.example.com" || got.Token != "abc" || got.Proxy != "http://proxy:3128" || got.Timeout != 30*time.Second || got.UserAgent != "audit/1.0" {
+		t.Errorf("ClientConfigFromFlags() = %+v", got)
+	}
+
+	if got := ClientConfigFromFlags(pflag.NewFlagSet("empty", pflag.ContinueOnError)); got.Hostname != "github.com" {
+		t.Errorf("Expected github.com without the flags, got %q", got.Hostname)
+	}
+}
+
+func TestClientConfigTokenSource(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddClientFlags(flags)
+	if err := (config.Profile{Token: "env:PROFILE_TOKEN"}).Apply(flags); err != nil {
+		t.Fatal(err)
+	}
+	cfg := ClientConfigFromFlags(flags)
+	if cfg.Token != "" || cfg.TokenSource != "env:PROFILE_TOKEN" {
+		t.Fatalf("Expected the token source of the profile, got %+v", cfg)
+	}
+
+	// The token is read when the client is built
+	if _, _, err := NewClients(cfg); err == nil || !strings.Contains(err.Error(), "PROFILE_TOKEN is not set") {
+		t.Errorf("Expected an error for the unset variable, got %v", err)
+	}
+	t.Setenv("PROFILE_TOKEN", "from-env")
+	if _, _, err := NewClients(cfg); err != nil {
+		t.Errorf("NewClients() error = %v", err)
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+	}))
+	defer server.Close()
+
+	transport, err := NewTransport(ClientConfig{UserAgent: "audit/1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("User-Agent", "go-gh")
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if userAgent != "audit/1.0" {
+		t.Errorf("Expected User-Agent audit/1.0, got %q", userAgent)
+	}
+
+	// Requests are counted in the progress tracker of their context
+	tracker := progress.NewTracker()
+	req, _ = http.NewRequestWithContext(progress.WithTracker(context.Background(), tracker), http.MethodGet, server.URL, nil)
+	resp, err = transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if calls := tracker.Snapshot().APICalls; calls != 1 {
+		t.Errorf("Expected 1 API call to be counted, got %d", calls)
+	}
+
+	if _, err := NewTransport(ClientConfig{Proxy: "http://proxy:3128"}); err != nil {
+		t.Errorf("NewTransport() with a proxy error = %v", err)
+	}
+
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	invalid := []ClientConfig{
+		{Proxy: "://proxy"},
+		{CABundle: filepath.Join(t.TempDir(), "missing.pem")},
+		{CABundle: notPEM},
+	}
+	for _, cfg := range invalid {
+		if _, err := NewTransport(cfg); err == nil {
+			t.Errorf("Expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestNewGetter(t *testing.T) {
+	g, err := NewGetter(ClientConfig{Hostname: "github.com", Token: "token", Concurrency: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Concurrency() != 4 {
+		t.Errorf("Expected concurrency 4, got %d", g.Concurrency())
+	}
+
+	if _, err := NewGetter(ClientConfig{Hostname: "github.com", Token: "token", Concurrency: -1}); err == nil {
+		t.Error("Expected error for negative concurrency")
+	}
+}
@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"go.uber.org/zap"
+)
+
+var (
+	EnvironmentRequiredHeaders = []string{"RepositoryName", "EnvironmentName"}
+	SecretRequiredHeaders      = []string{"RepositoryName", "EnvironmentName", "SecretName", "SecretValue"}
+	VariableRequiredHeaders    = []string{"RepositoryName", "EnvironmentName", "VariableName", "VariableValue"}
+)
+
+// ReportColumn describes a column written by a list command and whether the
+// matching create command applies it. Read-only columns are exported for
+// reference only and are ignored on import.
+type ReportColumn struct {
+	Name     string
+	ReadOnly bool
+}
+
+// EnvironmentReportColumns lists the columns of the environments report, in
+// the order they are written.
+var EnvironmentReportColumns = []ReportColumn{
+	{Name: "RepositoryName"},
+	{Name: "RepositoryID", ReadOnly: true},
+	{Name: "EnvironmentName"},
+	{Name: "AdminBypass"},
+	{Name: "WaitTimer"},
+	{Name: "Reviewers"},
+	{Name: "PreventSelfReview"},
+	{Name: "BranchPolicyType"},
+	{Name: "Branches"},
+	{Name: "CustomDeploymentProtectionPolicy"},
+	{Name: "SecretsTotalCount", ReadOnly: true},
+	{Name: "VariablesTotalCount", ReadOnly: true},
+}
+
+// ReportHeaders returns the names of the given report columns.
+func ReportHeaders(columns []ReportColumn) []string {
+	var headers []string
+	for _, column := range columns {
+		headers = append(headers, column.Name)
+	}
+	return headers
+}
+
+// WritePartialReport ends a report cut short because ctx is done. It flushes
+// the rows written so far and returns the error the command exits with.
+func WritePartialReport(ctx context.Context, csvWriter *csv.Writer, fileName string) error {
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return err
+	}
+	return PartialOutputError(ctx, fileName)
+}
+
+// PartialOutputError returns the error a command exits with when ctx is done
+// before it gathered everything, and what it did gather was written to
+// fileName.
+func PartialOutputError(ctx context.Context, fileName string) error {
+	reason := stopReason(ctx)
+	zap.S().Warnf("Run %s, writing partial output to %s", reason, fileName)
+	return exitcode.New(exitcode.PartialFailure, fmt.Errorf("run %s, partial output written to %s: %w", reason, fileName, ctx.Err()))
+}
+
+// InterruptedError returns the error a command exits with when ctx is done
+// before it applied every change, leaving the changes made so far in place.
+func InterruptedError(ctx context.Context) error {
+	reason := stopReason(ctx)
+	zap.S().Warnf("Run %s before every change was applied", reason)
+	return exitcode.New(exitcode.PartialFailure, fmt.Errorf("run %s before every change was applied: %w", reason, ctx.Err()))
+}
+
+// stopReason describes why ctx is done.
+func stopReason(ctx context.Context) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "timed out"
+	}
+	return "interrupted"
+}
+
+// ReadCSVFile reads every record from a CSV file, allowing rows with differing
+// column counts so that they can be reported by validation.
+func ReadCSVFile(fileName string) ([][]string, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil {
+			zap.S().Warnf("Error closing file: %v", closeErr)
+		}
+	}()
+
+	csvReader := csv.NewReader(f)
+	csvReader.FieldsPerRecord = -1
+	return csvReader.ReadAll()
+}
+
+// CSVHeader maps the column names of an import file to their positions, so that
+// files with reordered, extra or missing optional columns can be read.
+type CSVHeader map[string]int
+
+func NewCSVHeader(header []string) CSVHeader {
+	h := make(CSVHeader, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if _, ok := h[name]; !ok {
+			h[name] = i
+		}
+	}
+	return h
+}
+
+// Has reports whether the named column is present.
+func (h CSVHeader) Has(name string) bool {
+	_, ok := h[name]
+	return ok
+}
+
+// Missing returns the required column names that are not present.
+func (h CSVHeader) Missing(required []string) []string {
+	var missing []string
+	for _, name := range required {
+		if !h.Has(name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// Value returns the named column of a row, or an empty string if the column is
+// not present or the row is too short.
+func (h CSVHeader) Value(row []string, name string) string {
+	i, ok := h[name]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return row[i]
+}
+
+// parseCSVHeader returns the header of an import file, erroring if the file is
+// empty or any required column is missing.
+func parseCSVHeader(fileData [][]string, required []string) (CSVHeader, error) {
+	if len(fileData) == 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+	h := NewCSVHeader(fileData[0])
+	if missing := h.Missing(required); len(missing) > 0 {
+		return nil, fmt.Errorf("missing required header(s): %s", strings.Join(missing, ", "))
+	}
+	return h, nil
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/exitcode"
+)
+
+func TestCSVHeader(t *testing.T) {
+	header := NewCSVHeader([]string{"\ufeffRepositoryID", " RepositoryName ", "EnvironmentName"})
+
+	if !header.Has("RepositoryID") {
+		t.Error("Expected header to strip byte order mark")
+	}
+
+	if !header.Has("RepositoryName") {
+		t.Error("Expected header to trim whitespace")
+	}
+
+	row := []string{"12345", "testrepo"}
+	if header.Value(row, "RepositoryName") != "testrepo" {
+		t.Errorf("Expected RepositoryName testrepo, got %s", header.Value(row, "RepositoryName"))
+	}
+
+	if header.Value(row, "EnvironmentName") != "" {
+		t.Error("Expected empty value for short row")
+	}
+
+	if header.Value(row, "Missing") != "" {
+		t.Error("Expected empty value for missing column")
+	}
+
+	missing := header.Missing([]string{"RepositoryName", "SecretName", "SecretValue"})
+	if len(missing) != 2 || missing[0] != "SecretName" || missing[1] != "SecretValue" {
+		t.Errorf("Expected SecretName and SecretValue to be missing, got %v", missing)
+	}
+}
+
+func TestParseCSVHeader(t *testing.T) {
+	_, err := parseCSVHeader([][]string{}, EnvironmentRequiredHeaders)
+	if err == nil {
+		t.Error("Expected error for empty file, got nil")
+	}
+
+	_, err = parseCSVHeader([][]string{{"RepositoryName", "SecretName"}}, SecretRequiredHeaders)
+	if err == nil || !strings.Contains(err.Error(), "EnvironmentName, SecretValue") {
+		t.Errorf("Expected missing header error, got %v", err)
+	}
+
+	header, err := parseCSVHeader([][]string{{"EnvironmentName", "RepositoryName"}}, EnvironmentRequiredHeaders)
+	if err != nil {
+		t.Errorf("parseCSVHeader() error = %v", err)
+	}
+	if header["RepositoryName"] != 1 {
+		t.Errorf("Expected RepositoryName at index 1, got %d", header["RepositoryName"])
+	}
+}
+
+func TestReadCSVFile(t *testing.T) {
+	csvFile := filepath.Join(t.TempDir(), "test.csv")
+	err := os.WriteFile(csvFile, []byte("RepositoryName,EnvironmentName\ntestrepo,production,extra\ntestrepo\n"), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create test CSV file: %v", err)
+	}
+
+	records, err := ReadCSVFile(csvFile)
+	if err != nil {
+		t.Fatalf("ReadCSVFile() error = %v", err)
+	}
+
+	if len(records) != 3 {
+		t.Errorf("Expected 3 records, got %d", len(records))
+	}
+
+	if len(records[1]) != 3 || len(records[2]) != 1 {
+		t.Error("Expected rows with differing column counts to be returned as-is")
+	}
+
+	_, err = ReadCSVFile(filepath.Join(t.TempDir(), "missing.csv"))
+	if err == nil {
+		t.Error("Expected error for missing file, got nil")
+	}
+}
+
+func TestWritePartialReport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var report bytes.Buffer
+	csvWriter := csv.NewWriter(&report)
+	if err := csvWriter.Write([]string{"RepositoryName", "EnvironmentName"}); err != nil {
+		t.Fatal(err)
+	}
+	err := WritePartialReport(ctx, csvWriter, "report.csv")
+	if !errors.Is(err, context.Canceled) || err.Error() != "run interrupted, partial output written to report.csv: context canceled" {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if code := exitcode.Code(err); code != exitcode.PartialFailure {
+		t.Errorf("Expected exit code %d, got %d", exitcode.PartialFailure, code)
+	}
+	// The rows written so far are kept as they are, so they can be imported
+	if want := "RepositoryName,EnvironmentName\n"; report.String() != want {
+		t.Errorf("Expected rows to be flushed, got %q", report.String())
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	err = PartialOutputError(ctx, "backup.tar.gz")
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "run timed out, partial output written to backup.tar.gz") {
+		t.Errorf("Expected a timed out error, got %v", err)
+	}
+
+	err = InterruptedError(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || exitcode.Code(err) != exitcode.PartialFailure || err.Error() != "run timed out before every change was applied: context deadline exceeded" {
+		t.Errorf("Expected a timed out partial failure, got %v", err)
+	}
+}
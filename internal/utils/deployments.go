@@ -0,0 +1,205 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	neturl "net/url"
+	"strconv"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"go.uber.org/zap"
+)
+
+const deploymentsPerPage = 100
+
+func (g *APIGetter) GetDeployments(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error) {
+	url := fmt.Sprintf("repos/%s/%s/deployments?environment=%s&per_page=%d&page=%d", owner, repo, neturl.QueryEscape(env), deploymentsPerPage, page)
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP error for URL %s: %v", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Error closing response body: %v", closeErr)
+		}
+	}()
+	responseData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body from URL %s: %w", url, err)
+	}
+	return responseData, nil
+}
+
+// GetLatestDeploymentStatus returns a list holding only the most recent
+// status of the deployment.
+func (g *APIGetter) GetLatestDeploymentStatus(ctx context.Context, owner string, repo string, deploymentID int) ([]byte, error) {
+	url := fmt.Sprintf("repos/%s/%s/deployments/%d/statuses?per_page=1", owner, repo, deploymentID)
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP error for URL %s: %v", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Error closing response body: %v", closeErr)
+		}
+	}()
+	responseData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body from URL %s: %w", url, err)
+	}
+	return responseData, nil
+}
+
+type deploymentGetter interface {
+	GetDeployments(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error)
+	GetLatestDeploymentStatus(ctx context.Context, owner string, repo string, deploymentID int) ([]byte, error)
+}
+
+// DeploymentSummary describes how an environment has been used, based on
+// its most recent deployments.
+type DeploymentSummary struct {
+	Repository     string
+	Environment    string
+	Deployments    int
+	LatestState    string
+	LastDeployedAt time.Time
+	LastSuccessAt  time.Time
+	LastSuccessBy  string
+	LastSuccessRef string
+	LastSuccessSHA string
+	Failures       int
+}
+
+var DeploymentReportColumns = []string{
+	"RepositoryName",
+	"EnvironmentName",
+	"Deployments",
+	"LatestState",
+	"LastDeployedAt",
+	"LastSuccessAt",
+	"LastSuccessBy",
+	"LastSuccessRef",
+	"LastSuccessSHA",
+	"Failures",
+}
+
+// Row formats the summary in the order of DeploymentReportColumns.
+func (s DeploymentSummary) Row() []string {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		s.Repository,
+		s.Environment,
+		strconv.Itoa(s.Deployments),
+		s.LatestState,
+		formatTime(s.LastDeployedAt),
+		formatTime(s.LastSuccessAt),
+		s.LastSuccessBy,
+		s.LastSuccessRef,
+		s.LastSuccessSHA,
+		strconv.Itoa(s.Failures),
+	}
+}
+
+// GatherDeployments returns up to limit of the most recent deployments to
+// env, newest first.
+func GatherDeployments(ctx context.Context, g deploymentGetter, owner string, repo string, env string, limit int) ([]data.Deployment, error) {
+	var deployments []data.Deployment
+	for page := 1; len(deployments) < limit; page++ {
+		resp, err := g.GetDeployments(ctx, owner, repo, env, page)
+		if err != nil {
+			return nil, err
+		}
+		var pageDeployments []data.Deployment
+		if err := json.Unmarshal(resp, &pageDeployments); err != nil {
+			return nil, err
+		}
+		deployments = append(deployments, pageDeployments...)
+		if len(pageDeployments) < deploymentsPerPage {
+			break
+		}
+	}
+	if len(deployments) > limit {
+		deployments = deployments[:limit]
+	}
+	return deployments, nil
+}
+
+// GatherDeploymentSummary summarizes up to limit of the most recent
+// deployments to env. The latest status of each deployment is fetched to
+// find successes and count failures.
+func GatherDeploymentSummary(ctx context.Context, g deploymentGetter, owner string, repo string, env string, limit int) (DeploymentSummary, error) {
+	summary := DeploymentSummary{Repository: repo, Environment: env}
+
+	deployments, err := GatherDeployments(ctx, g, owner, repo, env, limit)
+	if err != nil {
+		return summary, err
+	}
+	summary.Deployments = len(deployments)
+	zap.S().Debugf("Gathering statuses of %d deployment(s) to %s/%s", len(deployments), repo, env)
+
+	for i, deployment := range deployments {
+		resp, err := g.GetLatestDeploymentStatus(ctx, owner, repo, deployment.ID)
+		if err != nil {
+			return summary, err
+		}
+		var statuses []data.DeploymentStatus
+		if err := json.Unmarshal(resp, &statuses); err != nil {
+			return summary, err
+		}
+		state := ""
+		if len(statuses) > 0 {
+			state = statuses[0].State
+		}
+		if i == 0 {
+			summary.LatestState = state
+			summary.LastDeployedAt = deployment.CreatedAt
+		}
+		switch state {
+		case "success":
+			if summary.LastSuccessAt.IsZero() {
+				summary.LastSuccessAt = statuses[0].CreatedAt
+				summary.LastSuccessBy = deployment.Creator.Login
+				summary.LastSuccessRef = deployment.Ref
+				summary.LastSuccessSHA = deployment.SHA
+			}
+		case "failure", "error":
+			summary.Failures++
+		}
+	}
+	return summary, nil
+}
+
+type environmentsGetter interface {
+	GetRepoEnvironments(ctx context.Context, owner string, repo string) ([]byte, error)
+}
+
+// GatherEnvironmentNames returns the names of the environments of repo that
+// match envs.
+func GatherEnvironmentNames(ctx context.Context, g environmentsGetter, owner string, repo string, envs EnvFilter) ([]string, error) {
+	resp, err := g.GetRepoEnvironments(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	var environments data.EnvResponse
+	if err := json.Unmarshal(resp, &environments); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, env := range environments.Environments {
+		if envs.Match(env.Name) {
+			names = append(names, env.Name)
+		}
+	}
+	progress.FromContext(ctx).EnvironmentsFound(len(names))
+	return names, nil
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+func mockDeployment(id int, env string, createdAt time.Time, states ...string) MockDeployment {
+	deployment := MockDeployment{Deployment: data.Deployment{
+		ID:          id,
+		SHA:         "sha" + env,
+		Ref:         "main",
+		Environment: env,
+		Creator:     data.User{Login: "octocat"},
+		CreatedAt:   createdAt,
+	}}
+	for i, state := range states {
+		deployment.Statuses = append(deployment.Statuses, data.DeploymentStatus{
+			ID:        id*10 + i,
+			State:     state,
+			CreatedAt: createdAt.Add(time.Duration(i+1) * time.Minute),
+		})
+	}
+	return deployment
+}
+
+func TestGatherDeploymentSummary(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	server := NewMockGitHubServer()
+	repo := server.AddRepo(1, "app")
+	repo.Deployments = []MockDeployment{
+		mockDeployment(1, "production", base, "in_progress", "success"),
+		mockDeployment(2, "production", base.Add(time.Hour), "failure"),
+		mockDeployment(3, "production", base.Add(2*time.Hour), "in_progress", "error"),
+		mockDeployment(4, "staging", base.Add(3*time.Hour), "success"),
+	}
+	repo.Deployments[0].Ref = "v1.0.0"
+	repo.Deployments[0].Creator.Login = "hubot"
+	g := newMockServerGetter(t, server)
+
+	summary, err := GatherDeploymentSummary(ctx, g, "testorg", "app", "production", 100)
+	if err != nil {
+		t.Fatalf("GatherDeploymentSummary() error = %v", err)
+	}
+	want := DeploymentSummary{
+		Repository:     "app",
+		Environment:    "production",
+		Deployments:    3,
+		LatestState:    "error",
+		LastDeployedAt: base.Add(2 * time.Hour),
+		LastSuccessAt:  base.Add(2 * time.Minute),
+		LastSuccessBy:  "hubot",
+		LastSuccessRef: "v1.0.0",
+		LastSuccessSHA: "shaproduction",
+		Failures:       2,
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("GatherDeploymentSummary() = %+v, want %+v", summary, want)
+	}
+
+	row := summary.Row()
+	if len(row) != len(DeploymentReportColumns) || row[5] != "2024-06-01T00:02:00Z" {
+		t.Errorf("Unexpected row %v", row)
+	}
+
+	summary, err = GatherDeploymentSummary(ctx, g, "testorg", "app", "qa", 100)
+	if err != nil {
+		t.Fatalf("GatherDeploymentSummary() error = %v", err)
+	}
+	if summary.Deployments != 0 || summary.LatestState != "" || !summary.LastSuccessAt.IsZero() {
+		t.Errorf("Expected no deployments to qa, got %+v", summary)
+	}
+	if row := summary.Row(); row[4] != "" || row[5] != "" {
+		t.Errorf("Expected empty times for unused environment, got %v", row)
+	}
+}
+
+func TestGatherDeploymentsLimit(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	server := NewMockGitHubServer()
+	repo := server.AddRepo(1, "app")
+	for i := 1; i <= 150; i++ {
+		repo.Deployments = append(repo.Deployments, mockDeployment(i, "production", base.Add(time.Duration(i)*time.Minute)))
+	}
+	g := newMockServerGetter(t, server)
+
+	deployments, err := GatherDeployments(ctx, g, "testorg", "app", "production", 120)
+	if err != nil {
+		t.Fatalf("GatherDeployments() error = %v", err)
+	}
+	if len(deployments) != 120 || deployments[0].ID != 150 || deployments[119].ID != 31 {
+		t.Errorf("Expected the 120 newest deployments, got %d starting with %d", len(deployments), deployments[0].ID)
+	}
+
+	deployments, err = GatherDeployments(ctx, g, "testorg", "app", "production", 500)
+	if err != nil {
+		t.Fatalf("GatherDeployments() error = %v", err)
+	}
+	if len(deployments) != 150 {
+		t.Errorf("Expected all 150 deployments, got %d", len(deployments))
+	}
+}
+
+func TestGatherEnvironmentNames(t *testing.T) {
+	ctx := context.Background()
+	server := NewMockGitHubServer()
+	repo := server.AddRepo(1, "app")
+	repo.Environments["production"] = &MockEnvironment{Name: "production"}
+	repo.Environments["staging"] = &MockEnvironment{Name: "staging"}
+	g := newMockServerGetter(t, server)
+
+	names, err := GatherEnvironmentNames(ctx, g, "testorg", "app", EnvFilter{Globs: []string{"stag*"}})
+	if err != nil {
+		t.Fatalf("GatherEnvironmentNames() error = %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"staging"}) {
+		t.Errorf("Expected [staging], got %v", names)
+	}
+}
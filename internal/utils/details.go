@@ -0,0 +1,320 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"go.uber.org/zap"
+)
+
+type environmentDetailsGetter interface {
+	GetRepoEnvironments(ctx context.Context, owner string, repo string) ([]byte, error)
+	GetDeploymentBranchPolicies(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error)
+	GetDeploymentProtectionRules(ctx context.Context, owner string, repo string, env string) ([]byte, error)
+	GetEnvironmentSecrets(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error)
+	GetEnvironmentVariables(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error)
+}
+
+// GatherEnvironments returns the details of every environment matching envs
+// in repos. Repositories whose environments cannot be read, and details that
+// cannot be read, are logged and left out, unless complete is set: commands
+// whose output is taken as the whole state of the environments, such as
+// snapshots and backups, fail instead of treating them as missing. When g
+// gathers more than one repository at a time, the details still follow the
+// order of repos.
+func GatherEnvironments(ctx context.Context, g environmentDetailsGetter, owner string, repos []data.RepoInfo, envs EnvFilter, complete bool) ([]data.EnvironmentDetails, error) {
+	type repoResult struct {
+		details []data.EnvironmentDetails
+		err     error
+	}
+	results := make([]repoResult, len(repos))
+
+	zap.S().Debug("Gathering all repository environments")
+	forEachConcurrently(concurrencyOf(g), len(repos), func(i int) {
+		results[i].details, results[i].err = gatherRepoEnvironments(ctx, g, owner, repos[i], envs, complete)
+	})
+
+	var details []data.EnvironmentDetails
+	for _, result := range results {
+		details = append(details, result.details...)
+		if result.err != nil {
+			return details, result.err
+		}
+	}
+	return details, nil
+}
+
+func gatherRepoEnvironments(ctx context.Context, g environmentDetailsGetter, owner string, repo data.RepoInfo, envs EnvFilter, complete bool) ([]data.EnvironmentDetails, error) {
+	var details []data.EnvironmentDetails
+
+	// Requests failing because the run was interrupted are not skipped
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	tracker := progress.FromContext(ctx)
+	defer tracker.RepositoryProcessed()
+
+	zap.S().Debugf("Gathering Environments for repo %s", repo.Name)
+	repoEnvs, err := g.GetRepoEnvironments(ctx, owner, repo.Name)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		zap.S().Errorf("Error accessing repo environments for %s: %v", repo.Name, err)
+		if complete && !strings.Contains(err.Error(), "404: Not Found") {
+			return nil, fmt.Errorf("reading the environments of %s: %w", repo.Name, err)
+		}
+		tracker.Warn("could not read the environments of %s: %v", repo.Name, err)
+		return nil, nil
+	}
+	var responseEnvs data.EnvResponse
+	err = json.Unmarshal(repoEnvs, &responseEnvs)
+	if err != nil {
+		zap.S().Errorf("Error unmarshaling response for %s: %v", repo.Name, err)
+		if complete {
+			return nil, fmt.Errorf("parsing the environments of %s: %w", repo.Name, err)
+		}
+		tracker.Warn("could not parse the environments of %s: %v", repo.Name, err)
+		return nil, nil
+	}
+
+	zap.S().Debugf("Gathering data for %d environment(s) for repository %s", responseEnvs.TotalCount, repo.Name)
+	for _, env := range responseEnvs.Environments {
+		if !envs.Match(env.Name) {
+			zap.S().Debugf("Skipping environment %s as it does not match the environment filters", env.Name)
+			continue
+		}
+		tracker.EnvironmentsFound(1)
+		envDetails, err := GatherEnvironmentDetails(ctx, g, owner, repo, env, complete)
+		if err != nil {
+			return details, err
+		}
+		// Details missing requests cut short by an interrupt are incomplete
+		if err := ctx.Err(); err != nil {
+			return details, err
+		}
+		details = append(details, envDetails)
+	}
+	return details, nil
+}
+
+// concurrencyOf returns the number of repositories g gathers at the same
+// time, one unless it is configured otherwise.
+func concurrencyOf(g interface{}) int {
+	if c, ok := g.(interface{ Concurrency() int }); ok && c.Concurrency() > 1 {
+		return c.Concurrency()
+	}
+	return 1
+}
+
+// forEachConcurrently calls fn for every index below n, running up to
+// concurrency calls at the same time.
+func forEachConcurrently(concurrency int, n int, fn func(i int)) {
+	if concurrency <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	indexes := make(chan int)
+	for w := 0; w < concurrency && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// GatherOrgEnvironments selects the repositories of owner matching repos and
+// filter, including custom property selectors, and returns the details of
+// their environments matching envs, failing on details that cannot be read
+// when complete is set, as GatherEnvironments does. When ctx is done, the
+// details gathered so far are returned with its error.
+func GatherOrgEnvironments(ctx context.Context, g *APIGetter, owner string, repos []string, filter RepoFilter, envs EnvFilter, complete bool) ([]data.EnvironmentDetails, error) {
+	allRepos, err := GatherRepos(ctx, g, owner, repos, filter)
+	if err != nil {
+		zap.S().Error("Error raised in gathering repos", zap.Error(err))
+		return nil, err
+	}
+	allRepos, _, err = SelectReposByProperties(ctx, g, owner, allRepos, filter.Properties)
+	if err != nil {
+		zap.S().Error("Error raised in gathering repository custom properties", zap.Error(err))
+		return nil, err
+	}
+	environments, err := GatherEnvironments(ctx, g, owner, allRepos, envs, complete)
+	if err != nil {
+		zap.S().Error("Error raised in gathering environments", zap.Error(err))
+		return environments, err
+	}
+	return environments, nil
+}
+
+// GatherEnvironmentDetails fills in the branch policies, custom deployment
+// protection rules, secrets and variables of env. Those that cannot be read
+// are logged and left out, unless complete is set and the error is returned.
+func GatherEnvironmentDetails(ctx context.Context, g environmentDetailsGetter, owner string, repo data.RepoInfo, env data.Environment, complete bool) (data.EnvironmentDetails, error) {
+	details := data.EnvironmentDetails{
+		Organization: owner,
+		Repository:   repo.Name,
+		RepositoryID: repo.DatabaseId,
+		Name:         env.Name,
+		AdminBypass:  env.AdminByPass,
+	}
+
+	for _, rules := range env.ProtectionRules {
+		zap.S().Debugf("Gathering Protection Rules for environment %s", env.Name)
+		switch rules.Type {
+		case "wait_timer":
+			details.WaitTimer = rules.WaitTimer
+		case "required_reviewers":
+			zap.S().Debugf("Gathering Required Reviewers for environment %s", env.Name)
+			details.PreventSelfReview = rules.PreventSelfReview
+			details.Reviewers = append(details.Reviewers, rules.Reviewers...)
+		case "branch_policy":
+			zap.S().Debugf("Gathering Branch Policies for environment %s", env.Name)
+			if env.DeploymentPolicy == nil {
+				continue
+			}
+			if env.DeploymentPolicy.CustomPolicies {
+				details.BranchPolicyType = "custom"
+
+				policies, err := GatherDeploymentBranchPolicies(ctx, g, owner, repo.Name, env.Name)
+				if err != nil {
+					zap.S().Error("Error raised in gathering branch policies", zap.Error(err))
+					if complete {
+						return details, fmt.Errorf("reading the branch policies of %s/%s: %w", repo.Name, env.Name, err)
+					}
+					continue
+				}
+				details.BranchPolicies = policies
+			} else if env.DeploymentPolicy.ProtectedBranches {
+				details.BranchPolicyType = "protected"
+			}
+		}
+	}
+
+	zap.S().Debugf("Gathering Custom Deployment Protection Policies for environment %s", env.Name)
+	envProtectionResp, err := g.GetDeploymentProtectionRules(ctx, owner, repo.Name, env.Name)
+	if err != nil {
+		if strings.Contains(err.Error(), "404: Not Found") {
+			zap.S().Debug("No custom deployment protection policies found for environment")
+		} else {
+			zap.S().Error("Error raised in gathering deployment protection policies", zap.Error(err))
+			if complete {
+				return details, fmt.Errorf("reading the custom deployment protection rules of %s/%s: %w", repo.Name, env.Name, err)
+			}
+		}
+	} else {
+		var envDeploymentProtectionPolicy data.DeploymentProtectionPolicy
+		err = json.Unmarshal(envProtectionResp, &envDeploymentProtectionPolicy)
+		if err != nil {
+			return details, err
+		}
+		details.ProtectionRules = envDeploymentProtectionPolicy.CustomDeploymentRules
+	}
+
+	zap.S().Debugf("Gathering Secrets for environment %s", env.Name)
+	secrets, err := GatherEnvironmentSecrets(ctx, g, owner, repo.Name, env.Name)
+	if err != nil {
+		if strings.Contains(err.Error(), "404: Not Found") {
+			zap.S().Debug("No secrets found for environment")
+		} else {
+			zap.S().Error("Error raised in gathering environment secrets", zap.Error(err))
+			if complete {
+				return details, fmt.Errorf("reading the secrets of %s/%s: %w", repo.Name, env.Name, err)
+			}
+		}
+	} else {
+		details.SecretsCount = len(secrets)
+		details.Secrets = secrets
+	}
+
+	zap.S().Debugf("Gathering Variables for environment %s", env.Name)
+	variables, err := GatherEnvironmentVariables(ctx, g, owner, repo.Name, env.Name)
+	if err != nil {
+		if strings.Contains(err.Error(), "404: Not Found") {
+			zap.S().Debug("No variables found for environment")
+		} else {
+			zap.S().Error("Error raised in gathering environment variables", zap.Error(err))
+			if complete {
+				return details, fmt.Errorf("reading the variables of %s/%s: %w", repo.Name, env.Name, err)
+			}
+		}
+	} else {
+		details.VariablesCount = len(variables)
+		details.Variables = variables
+	}
+
+	return details, nil
+}
+
+// ImportedEnvironment converts gathered details into the form used to create
+// an environment, as if it had been read from a report file.
+func ImportedEnvironment(details data.EnvironmentDetails) data.ImportedEnvironment {
+	environment := data.ImportedEnvironment{
+		RepositoryName:    details.Repository,
+		RepositoryID:      details.RepositoryID,
+		EnvironmentName:   details.Name,
+		AdminBypass:       strconv.FormatBool(details.AdminBypass),
+		WaitTimer:         details.WaitTimer,
+		Reviewers:         details.Reviewers,
+		PreventSelfReview: details.PreventSelfReview,
+		DeploymentPolicy:  details.BranchPolicyType,
+		ProtectionRules:   details.ProtectionRules,
+	}
+	for _, branch := range details.BranchPolicies {
+		environment.Branches = append(environment.Branches, data.CreateDeploymentBranch{Name: branch.Name, Type: branch.Type})
+	}
+	return environment
+}
+
+// EnvironmentReportRow formats details in the order of
+// EnvironmentReportColumns.
+func EnvironmentReportRow(details data.EnvironmentDetails) []string {
+	var reviewers, branches, apps []string
+	for _, reviewer := range details.Reviewers {
+		reviewers = append(reviewers, strings.Join([]string{reviewer.Type, reviewer.Reviewer.Login, strconv.Itoa(reviewer.Reviewer.ID)}, ";"))
+	}
+	for _, branch := range details.BranchPolicies {
+		branches = append(branches, strings.Join([]string{branch.Name, branch.Type}, ";"))
+	}
+	for _, rule := range details.ProtectionRules {
+		apps = append(apps, strings.Join([]string{
+			strconv.Itoa(rule.PolicyID),
+			strconv.FormatBool(rule.Enabled),
+			strconv.Itoa(rule.App.IntegrationID),
+			rule.App.Slug,
+		}, ";"))
+	}
+
+	return []string{
+		details.Repository,
+		strconv.Itoa(details.RepositoryID),
+		details.Name,
+		strconv.FormatBool(details.AdminBypass),
+		strconv.Itoa(details.WaitTimer),
+		strings.Join(reviewers, "|"),
+		strconv.FormatBool(details.PreventSelfReview),
+		details.BranchPolicyType,
+		strings.Join(branches, "|"),
+		strings.Join(apps, "|"),
+		strconv.Itoa(details.SecretsCount),
+		strconv.Itoa(details.VariablesCount),
+	}
+}
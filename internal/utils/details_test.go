@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/progress"
+)
+
+func newMockServerGetter(t *testing.T, server *MockGitHubServer) *APIGetter {
+	t.Helper()
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestGatherEnvironments(t *testing.T) {
+	ctx := context.Background()
+	server := NewMockGitHubServer()
+	repo := server.AddRepo(1, "app")
+	repo.Environments["production"] = &MockEnvironment{
+		Name:              "production",
+		WaitTimer:         30,
+		PreventSelfReview: true,
+		Reviewers:         []data.Reviewers{{Type: "User", Reviewer: data.Reviewer{Login: "octocat", ID: 7}}},
+		DeploymentPolicy:  &data.DeploymentPolicy{CustomPolicies: true},
+		BranchPolicies:    []data.BranchPolicy{{ID: 3, Name: "main", Type: "branch"}},
+		ProtectionRules:   []data.DeploymentProtectionPolicyApp{{PolicyID: 4, Enabled: true, App: data.DeploymentApp{IntegrationID: 5, Slug: "gate"}}},
+		Secrets:           []data.Secret{{Name: "TOKEN"}},
+		Variables:         []data.Variable{{Name: "A"}, {Name: "B"}},
+	}
+	repo.Environments["staging"] = &MockEnvironment{Name: "staging", CanAdminsBypass: true}
+	g := newMockServerGetter(t, server)
+
+	details, err := GatherEnvironments(ctx, g, "testorg", []data.RepoInfo{{Name: "app", DatabaseId: 1}}, EnvFilter{Globs: []string{"prod*"}}, false)
+	if err != nil {
+		t.Fatalf("GatherEnvironments() error = %v", err)
+	}
+	if len(details) != 1 {
+		t.Fatalf("Expected only production to be gathered, got %+v", details)
+	}
+
+	want := []string{"app", "1", "production", "false", "30", "User;octocat;7", "true", "custom", "main;branch", "4;true;5;gate", "1", "2"}
+	if got := EnvironmentReportRow(details[0]); !reflect.DeepEqual(got, want) {
+		t.Errorf("EnvironmentReportRow() = %v, want %v", got, want)
+	}
+	if details[0].Organization != "testorg" {
+		t.Errorf("Expected organization to be set, got %q", details[0].Organization)
+	}
+
+	// Repositories whose environments cannot be read are skipped with a warning
+	tracker := progress.NewTracker()
+	details, err = GatherEnvironments(progress.WithTracker(ctx, tracker), g, "testorg", []data.RepoInfo{{Name: "missing"}}, EnvFilter{}, false)
+	if err != nil || len(details) != 0 {
+		t.Errorf("Expected missing repository to be skipped, got %v %v", details, err)
+	}
+	if warnings := tracker.Summary(nil).Warnings; len(warnings) != 1 || !strings.Contains(warnings[0], "missing") {
+		t.Errorf("Expected a warning for the missing repository, got %v", warnings)
+	}
+}
+
+func TestGatherEnvironmentsComplete(t *testing.T) {
+	ctx := context.Background()
+	server := NewMockGitHubServer()
+	server.AddRepo(1, "app").Environments["production"] = &MockEnvironment{
+		Name:      "production",
+		Variables: []data.Variable{{Name: "REGION", Value: "eu"}},
+	}
+	// The variables of production cannot be read
+	server.Reject = func(req *http.Request) int {
+		if strings.HasSuffix(req.URL.Path, "environments/production/variables") {
+			return http.StatusInternalServerError
+		}
+		return 0
+	}
+	g := newMockServerGetter(t, server)
+	repos := []data.RepoInfo{{Name: "app", DatabaseId: 1}}
+
+	// Without complete the variables are left out
+	details, err := GatherEnvironments(ctx, g, "testorg", repos, EnvFilter{}, false)
+	if err != nil || len(details) != 1 || len(details[0].Variables) != 0 {
+		t.Errorf("Expected production without variables, got %+v, %v", details, err)
+	}
+
+	// With complete the run fails instead of reporting no variables
+	_, err = GatherEnvironments(ctx, g, "testorg", repos, EnvFilter{}, true)
+	if err == nil || !strings.Contains(err.Error(), "reading the variables of app/production") {
+		t.Errorf("Expected an error reading the variables, got %v", err)
+	}
+
+	// Repositories whose environments cannot be read fail as well
+	server.Reject = func(req *http.Request) int {
+		if strings.HasSuffix(req.URL.Path, "repos/testorg/app/environments") {
+			return http.StatusForbidden
+		}
+		return 0
+	}
+	_, err = GatherEnvironments(ctx, g, "testorg", repos, EnvFilter{}, true)
+	if err == nil || !strings.Contains(err.Error(), "reading the environments of app") {
+		t.Errorf("Expected an error reading the environments, got %v", err)
+	}
+}
+
+func TestGatherEnvironmentsConcurrently(t *testing.T) {
+	ctx := context.Background()
+	server := NewMockGitHubServer()
+	var repos []data.RepoInfo
+	var want []string
+	for i := 1; i <= 10; i++ {
+		name := fmt.Sprintf("repo-%02d", i)
+		server.AddRepo(i, name).Environments["production"] = &MockEnvironment{Name: "production"}
+		repos = append(repos, data.RepoInfo{Name: name, DatabaseId: i})
+		want = append(want, name)
+	}
+	g := newMockServerGetter(t, server)
+	g.concurrency = 4
+
+	details, err := GatherEnvironments(ctx, g, "testorg", repos, EnvFilter{}, false)
+	if err != nil {
+		t.Fatalf("GatherEnvironments() error = %v", err)
+	}
+	var got []string
+	for _, env := range details {
+		got = append(got, env.Repository)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the order of the repositories, got %v", got)
+	}
+}
+
+func TestGatherEnvironmentsInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := NewMockGitHubServer()
+	var repos []data.RepoInfo
+	for i, name := range []string{"api", "app", "web"} {
+		server.AddRepo(i+1, name).Environments["production"] = &MockEnvironment{Name: "production"}
+		repos = append(repos, data.RepoInfo{Name: name, DatabaseId: i + 1})
+	}
+	server.BeforeRequest = func(req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "repos/testorg/app/environments") {
+			cancel()
+		}
+	}
+	g := newMockServerGetter(t, server)
+
+	// Repositories are not skipped as unreadable once the run is interrupted
+	details, err := GatherEnvironments(ctx, g, "testorg", repos, EnvFilter{}, false)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the run to be cancelled, got %v", err)
+	}
+	if len(details) != 1 || details[0].Repository != "api" {
+		t.Errorf("Expected the environments gathered before the interrupt, got %+v", details)
+	}
+}
+
+func TestGatherOrgEnvironmentsProgress(t *testing.T) {
+	server := NewMockGitHubServer()
+	server.AddRepo(1, "api").Environments["production"] = &MockEnvironment{Name: "production"}
+	app := server.AddRepo(2, "app")
+	app.Environments["production"] = &MockEnvironment{Name: "production"}
+	app.Environments["staging"] = &MockEnvironment{Name: "staging"}
+	server.AddRepo(3, "web").IsArchived = true
+	g := newMockServerGetter(t, server)
+
+	tracker := progress.NewTracker()
+	ctx := progress.WithTracker(context.Background(), tracker)
+	if _, err := GatherOrgEnvironments(ctx, g, "testorg", nil, RepoFilter{ExcludeArchived: true}, EnvFilter{}, false); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := tracker.Snapshot()
+	if snapshot.Repositories != 2 || snapshot.Processed != 2 || snapshot.Environments != 3 {
+		t.Errorf("Expected 2 of 2 repositories and 3 environments, got %+v", snapshot)
+	}
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"github.com/shurcooL/graphql"
+	"go.uber.org/zap"
+)
+
+func (g *APIGetter) GetEnterpriseOrgs(ctx context.Context, enterprise string, endCursor *string) (*data.EnterpriseOrgsQuery, error) {
+	query := new(data.EnterpriseOrgsQuery)
+	variables := map[string]interface{}{
+		"endCursor": (*graphql.String)(endCursor),
+		"slug":      graphql.String(enterprise),
+	}
+
+	err := g.gqlClient.QueryWithContext(ctx, "getEnterpriseOrgs", &query, variables)
+	return query, err
+}
+
+type enterpriseGetter interface {
+	GetEnterpriseOrgs(ctx context.Context, enterprise string, endCursor *string) (*data.EnterpriseOrgsQuery, error)
+}
+
+// GatherEnterpriseOrgs returns the login of every organization in the
+// enterprise.
+func GatherEnterpriseOrgs(ctx context.Context, g enterpriseGetter, enterprise string) ([]string, error) {
+	var orgs []string
+	var cursor *string
+	for {
+		zap.S().Debugf("Gathering organizations for enterprise %s", enterprise)
+		query, err := g.GetEnterpriseOrgs(ctx, enterprise, cursor)
+		if err != nil {
+			return nil, err
+		}
+		for _, org := range query.Enterprise.Organizations.Nodes {
+			orgs = append(orgs, org.Login)
+		}
+		cursor = &query.Enterprise.Organizations.PageInfo.EndCursor
+		if !query.Enterprise.Organizations.PageInfo.HasNextPage {
+			break
+		}
+	}
+	if len(orgs) == 0 {
+		return nil, fmt.Errorf("no organizations found for enterprise %s", enterprise)
+	}
+	return orgs, nil
+}
+
+// OrgSummary records what was collected from one organization during an
+// enterprise-wide scan.
+type OrgSummary struct {
+	Organization string
+	Repositories int
+	Rows         int
+	Err          error
+}
+
+// WriteOrgSummary prints one line per organization. rowLabel names what the
+// report rows are, such as environments or secrets.
+func WriteOrgSummary(out io.Writer, summaries []OrgSummary, rowLabel string) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintf(w, "Organization\tRepositories\t%s\tStatus\n", rowLabel); err != nil {
+		return err
+	}
+	var repos, rows, failed int
+	for _, summary := range summaries {
+		status := "ok"
+		if summary.Err != nil {
+			status = fmt.Sprintf("failed: %v", summary.Err)
+			failed++
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", summary.Organization, summary.Repositories, summary.Rows, status); err != nil {
+			return err
+		}
+		repos += summary.Repositories
+		rows += summary.Rows
+	}
+	if _, err := fmt.Fprintf(w, "Total (%d organizations, %d failed)\t%d\t%d\t\n", len(summaries), failed, repos, rows); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// OrgFailures records a warning for each organization an enterprise-wide scan
+// failed to collect, and returns a partial failure when some did, or a failure
+// when all did.
+func OrgFailures(ctx context.Context, summaries []OrgSummary) error {
+	failed := 0
+	for _, summary := range summaries {
+		if summary.Err != nil {
+			progress.FromContext(ctx).Warn("could not scan organization %s: %v", summary.Organization, summary.Err)
+			failed++
+		}
+	}
+	if failed == 0 {
+		return nil
+	}
+	return exitcode.Failures(failed, len(summaries), fmt.Errorf("failed to scan %d organization(s)", failed))
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/progress"
+)
+
+type fakeEnterpriseGetter struct {
+	pages [][]string
+	err   error
+}
+
+func (f *fakeEnterpriseGetter) GetEnterpriseOrgs(ctx context.Context, enterprise string, endCursor *string) (*data.EnterpriseOrgsQuery, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	page := 0
+	if endCursor != nil {
+		page = 1
+	}
+	query := new(data.EnterpriseOrgsQuery)
+	for _, login := range f.pages[page] {
+		query.Enterprise.Organizations.Nodes = append(query.Enterprise.Organizations.Nodes, struct{ Login string }{login})
+	}
+	query.Enterprise.Organizations.PageInfo = data.PageInfo{EndCursor: "next", HasNextPage: page+1 < len(f.pages)}
+	return query, nil
+}
+
+func TestGatherEnterpriseOrgs(t *testing.T) {
+	ctx := context.Background()
+	orgs, err := GatherEnterpriseOrgs(ctx, &fakeEnterpriseGetter{pages: [][]string{{"org1", "org2"}, {"org3"}}}, "acme")
+	if err != nil {
+		t.Fatalf("GatherEnterpriseOrgs() error = %v", err)
+	}
+	if !reflect.DeepEqual(orgs, []string{"org1", "org2", "org3"}) {
+		t.Errorf("GatherEnterpriseOrgs() = %v", orgs)
+	}
+
+	if _, err := GatherEnterpriseOrgs(ctx, &fakeEnterpriseGetter{pages: [][]string{{}}}, "acme"); err == nil {
+		t.Error("Expected error for enterprise without organizations")
+	}
+	if _, err := GatherEnterpriseOrgs(ctx, &fakeEnterpriseGetter{err: errors.New("boom")}, "acme"); err == nil {
+		t.Error("Expected error to be returned")
+	}
+}
+
+func TestWriteOrgSummary(t *testing.T) {
+	var out bytes.Buffer
+	err := WriteOrgSummary(&out, []OrgSummary{
+		{Organization: "org1", Repositories: 3, Rows: 5},
+		{Organization: "org2", Repositories: 1, Rows: 0, Err: errors.New("forbidden")},
+	}, "Environments")
+	if err != nil {
+		t.Fatalf("WriteOrgSummary() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 lines, got %q", out.String())
+	}
+	if !strings.Contains(lines[0], "Environments") {
+		t.Errorf("Expected row label in header, got %q", lines[0])
+	}
+	if !strings.Contains(lines[2], "failed: forbidden") {
+		t.Errorf("Expected failure status, got %q", lines[2])
+	}
+	if fields := strings.Fields(lines[3]); fields[len(fields)-2] != "4" || fields[len(fields)-1] != "5" {
+		t.Errorf("Expected totals of 4 repositories and 5 rows, got %q", lines[3])
+	}
+}
+
+func TestOrgFailures(t *testing.T) {
+	tracker := progress.NewTracker()
+	ctx := progress.WithTracker(context.Background(), tracker)
+	ok := OrgSummary{Organization: "org1"}
+	failed := OrgSummary{Organization: "org2", Err: errors.New("forbidden")}
+
+	if err := OrgFailures(ctx, []OrgSummary{ok}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if code := exitcode.Code(OrgFailures(ctx, []OrgSummary{ok, failed})); code != exitcode.PartialFailure {
+		t.Errorf("Expected a partial failure, got exit code %d", code)
+	}
+	if code := exitcode.Code(OrgFailures(ctx, []OrgSummary{failed})); code != exitcode.Failure {
+		t.Errorf("Expected a failure, got exit code %d", code)
+	}
+	if warnings := tracker.Summary(nil).Warnings; len(warnings) != 2 || !strings.Contains(warnings[0], "org2: forbidden") {
+		t.Errorf("Expected a warning per failed organization, got %v", warnings)
+	}
+}
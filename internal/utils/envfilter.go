@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+// EnvFilter selects environments by name. The zero value matches every
+// environment.
+type EnvFilter struct {
+	// Globs match case-insensitively; an environment matches if any does
+	Globs []string
+	Regex *regexp.Regexp
+}
+
+// Match reports whether name passes both the glob and regex filters.
+func (f EnvFilter) Match(name string) bool {
+	if len(f.Globs) > 0 {
+		matched := false
+		for _, glob := range f.Globs {
+			if ok, _ := path.Match(strings.ToLower(glob), strings.ToLower(name)); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if f.Regex != nil && !f.Regex.MatchString(name) {
+		return false
+	}
+	return true
+}
+
+// SelectEnvironments keeps the items, such as rows read from an import file,
+// whose environment matches f. key returns the repository and environment of
+// an item.
+func SelectEnvironments[T any](f EnvFilter, items []T, key func(T) (string, string)) []T {
+	var selected []T
+	for _, item := range items {
+		repo, env := key(item)
+		if f.Match(env) {
+			selected = append(selected, item)
+		} else {
+			zap.S().Debugf("Skipping %s/%s as it does not match the environment filters", repo, env)
+		}
+	}
+	return selected
+}
+
+// EnvFilterFlags holds the raw values of the environment filter flags shared
+// by the list and create commands.
+type EnvFilterFlags struct {
+	Globs []string
+	Regex string
+}
+
+func (e *EnvFilterFlags) AddFlags(flags *pflag.FlagSet) {
+	flags.StringArrayVar(&e.Globs, "env", nil, "Only include environments matching this glob, such as production* (repeatable)")
+	flags.StringVar(&e.Regex, "env-regex", "", "Only include environments whose name matches this regular expression")
+}
+
+// Filter validates the flag values and builds the matching EnvFilter. Invalid
+// values are validation failures.
+func (e *EnvFilterFlags) Filter() (EnvFilter, error) {
+	filter, err := e.filter()
+	return filter, exitcode.New(exitcode.ValidationFailure, err)
+}
+
+func (e *EnvFilterFlags) filter() (EnvFilter, error) {
+	var filter EnvFilter
+	for _, glob := range e.Globs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return filter, fmt.Errorf("invalid --env %q: %w", glob, err)
+		}
+		filter.Globs = append(filter.Globs, glob)
+	}
+	if e.Regex != "" {
+		re, err := regexp.Compile(e.Regex)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --env-regex: %w", err)
+		}
+		filter.Regex = re
+	}
+	return filter, nil
+}
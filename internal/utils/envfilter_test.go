@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+func TestEnvFilterMatch(t *testing.T) {
+	flags := EnvFilterFlags{Globs: []string{"production*", "staging"}}
+	filter, err := flags.Filter()
+	if err != nil {
+		t.Fatalf("Filter() error = %v", err)
+	}
+	for name, want := range map[string]bool{
+		"production":    true,
+		"Production-EU": true,
+		"staging":       true,
+		"staging-2":     false,
+		"dev":           false,
+	} {
+		if got := filter.Match(name); got != want {
+			t.Errorf("Match(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	flags.Regex = "-eu$"
+	filter, err = flags.Filter()
+	if err != nil {
+		t.Fatalf("Filter() error = %v", err)
+	}
+	if filter.Match("production") || !filter.Match("production-eu") {
+		t.Error("Expected environments to match both the glob and the regex")
+	}
+
+	if !(EnvFilter{}).Match("anything") {
+		t.Error("Expected zero filter to match every environment")
+	}
+}
+
+func TestEnvFilterFlagsInvalid(t *testing.T) {
+	for _, flags := range []EnvFilterFlags{
+		{Globs: []string{"prod["}},
+		{Regex: "("},
+	} {
+		if _, err := flags.Filter(); err == nil {
+			t.Errorf("Expected error for %+v", flags)
+		}
+	}
+}
+
+func TestSelectEnvironments(t *testing.T) {
+	filter, err := (&EnvFilterFlags{Globs: []string{"prod*"}}).Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	selected := SelectEnvironments(filter, []data.ImportedSecret{
+		{RepositoryName: "app", EnvironmentName: "production", Name: "TOKEN"},
+		{RepositoryName: "app", EnvironmentName: "staging", Name: "TOKEN"},
+		{RepositoryName: "api", EnvironmentName: "prod-eu", Name: "TOKEN"},
+	}, func(secret data.ImportedSecret) (string, string) {
+		return secret.RepositoryName, secret.EnvironmentName
+	})
+	if len(selected) != 2 || selected[0].EnvironmentName != "production" || selected[1].EnvironmentName != "prod-eu" {
+		t.Errorf("Unexpected secrets selected: %+v", selected)
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -8,11 +10,12 @@ import (
 	"strings"
 
 	"github.com/katiem0/gh-environments/internal/data"
+	"go.uber.org/zap"
 )
 
-func (g *APIGetter) GetRepoEnvironments(owner string, repo string) ([]byte, error) {
+func (g *APIGetter) GetRepoEnvironments(ctx context.Context, owner string, repo string) ([]byte, error) {
 	url := fmt.Sprintf("repos/%s/%s/environments", owner, repo)
-	resp, err := g.restClient.Request("GET", url, nil)
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP error for URL %s: %v", url, err)
 	}
@@ -28,9 +31,11 @@ func (g *APIGetter) GetRepoEnvironments(owner string, repo string) ([]byte, erro
 	return responseData, nil
 }
 
-func (g *APIGetter) GetDeploymentBranchPolicies(owner string, repo string, env string) ([]byte, error) {
-	url := fmt.Sprintf("repos/%s/%s/environments/%s/deployment-branch-policies", owner, repo, env)
-	resp, err := g.restClient.Request("GET", url, nil)
+const branchPoliciesPerPage = 100
+
+func (g *APIGetter) GetDeploymentBranchPolicies(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error) {
+	url := fmt.Sprintf("repos/%s/%s/environments/%s/deployment-branch-policies?per_page=%d&page=%d", owner, repo, env, branchPoliciesPerPage, page)
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP error: %v", err)
 	}
@@ -46,22 +51,52 @@ func (g *APIGetter) GetDeploymentBranchPolicies(owner string, repo string, env s
 	return responseData, nil
 }
 
-func (g *APIGetter) CreateEnvironmentList(fileData [][]string) []data.ImportedEnvironment {
-	// convert csv lines to array of structs
+type branchPoliciesGetter interface {
+	GetDeploymentBranchPolicies(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error)
+}
+
+// GatherDeploymentBranchPolicies returns every deployment branch policy of
+// env, reading as many pages as it takes to reach the total count.
+func GatherDeploymentBranchPolicies(ctx context.Context, g branchPoliciesGetter, owner string, repo string, env string) ([]data.BranchPolicy, error) {
+	var policies []data.BranchPolicy
+	for page := 1; ; page++ {
+		zap.S().Debugf("Gathering branch policies for %s/%s/%s, page %d", owner, repo, env, page)
+		resp, err := g.GetDeploymentBranchPolicies(ctx, owner, repo, env, page)
+		if err != nil {
+			return nil, err
+		}
+		var pagePolicies data.BranchPolicies
+		if err := json.Unmarshal(resp, &pagePolicies); err != nil {
+			return nil, fmt.Errorf("parsing branch policies: %w", err)
+		}
+		policies = append(policies, pagePolicies.BranchPolicies...)
+		if len(pagePolicies.BranchPolicies) == 0 || len(policies) >= pagePolicies.TotalCount {
+			return policies, nil
+		}
+	}
+}
+
+func (g *APIGetter) CreateEnvironmentList(fileData [][]string) ([]data.ImportedEnvironment, error) {
+	// convert csv lines to array of structs, mapping columns by header name
 	var environmentList []data.ImportedEnvironment
-	var envs data.ImportedEnvironment
+
+	header, err := parseCSVHeader(fileData, EnvironmentRequiredHeaders)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, each := range fileData[1:] {
+		var envs data.ImportedEnvironment
 		var reviewers []data.Reviewers
 		var branches []data.CreateDeploymentBranch
 
-		envs.RepositoryName = each[0]
-		envs.RepositoryID, _ = strconv.Atoi(each[1])
-		envs.EnvironmentName = each[2]
-		envs.AdminBypass = each[3]
-		envs.WaitTimer, _ = strconv.Atoi(each[4])
+		envs.RepositoryName = header.Value(each, "RepositoryName")
+		envs.RepositoryID, _ = strconv.Atoi(header.Value(each, "RepositoryID"))
+		envs.EnvironmentName = header.Value(each, "EnvironmentName")
+		envs.AdminBypass = header.Value(each, "AdminBypass")
+		envs.WaitTimer, _ = strconv.Atoi(header.Value(each, "WaitTimer"))
 
-		reviewersData := each[5]
+		reviewersData := header.Value(each, "Reviewers")
 		reviewersParts := strings.Split(reviewersData, "|")
 		for _, part := range reviewersParts {
 			fields := strings.Split(part, ";")
@@ -83,10 +118,10 @@ func (g *APIGetter) CreateEnvironmentList(fileData [][]string) []data.ImportedEn
 			}
 		}
 		envs.Reviewers = reviewers
-		envs.PreventSelfReview, _ = strconv.ParseBool(each[6])
-		envs.DeploymentPolicy = each[7]
+		envs.PreventSelfReview, _ = strconv.ParseBool(header.Value(each, "PreventSelfReview"))
+		envs.DeploymentPolicy = header.Value(each, "BranchPolicyType")
 
-		deploymentData := each[8]
+		deploymentData := header.Value(each, "Branches")
 		deploymentDataParts := strings.Split(deploymentData, "|")
 		for _, policy := range deploymentDataParts {
 			policyFields := strings.Split(policy, ";")
@@ -102,9 +137,34 @@ func (g *APIGetter) CreateEnvironmentList(fileData [][]string) []data.ImportedEn
 			}
 		}
 		envs.Branches = branches
+
+		protectionData := header.Value(each, "CustomDeploymentProtectionPolicy")
+		if protectionData != "" {
+			for _, rule := range strings.Split(protectionData, "|") {
+				ruleFields := strings.Split(rule, ";")
+				if len(ruleFields) != 4 {
+					log.Printf("Skipping malformed deployment protection rule %q for environment %v", rule, envs.EnvironmentName)
+					continue
+				}
+				policyID, _ := strconv.Atoi(ruleFields[0])
+				enabled, err := strconv.ParseBool(ruleFields[1])
+				if err != nil {
+					enabled = true
+				}
+				appID, _ := strconv.Atoi(ruleFields[2])
+				envs.ProtectionRules = append(envs.ProtectionRules, data.DeploymentProtectionPolicyApp{
+					PolicyID: policyID,
+					Enabled:  enabled,
+					App: data.DeploymentApp{
+						IntegrationID: appID,
+						Slug:          ruleFields[3],
+					},
+				})
+			}
+		}
 		environmentList = append(environmentList, envs)
 	}
-	return environmentList
+	return environmentList, nil
 }
 
 func CreateEnvironmentData(environment data.ImportedEnvironment) *data.CreateEnvironment {
@@ -133,46 +193,127 @@ func CreateEnvironmentData(environment data.ImportedEnvironment) *data.CreateEnv
 
 	s := data.CreateEnvironment{
 		WaitTimer:              environment.WaitTimer,
-		PreventSelfReview:      environment.PreventSelfReview,
 		Reviewers:              createReviewers,
 		DeploymentBranchPolicy: deploymentPolicy,
 	}
+	// Prevent self review only applies to the required reviewers rule
+	if len(createReviewers) > 0 {
+		s.PreventSelfReview = environment.PreventSelfReview
+	}
+	if adminBypass, err := strconv.ParseBool(environment.AdminBypass); err == nil {
+		s.CanAdminsBypass = &adminBypass
+	}
 	return &s
 }
 
-func (g *APIGetter) CreateEnvironment(owner string, repo string, env string, data io.Reader) error {
+func (g *APIGetter) CreateEnvironment(ctx context.Context, owner string, repo string, env string, data io.Reader) error {
 	url := fmt.Sprintf("repos/%s/%s/environments/%s", owner, repo, env)
 
-	resp, err := g.restClient.Request("PUT", url, data)
+	resp, err := g.restClient.RequestWithContext(ctx, "PUT", url, data)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("HTTP error for URL %s: %v", url, err)
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
 			log.Printf("Error closing response body: %v", closeErr)
 		}
 	}()
-	return err
+	return nil
 }
 
-func (g *APIGetter) CreateDeploymentBranches(owner string, repo string, env string, data io.Reader) error {
+func (g *APIGetter) CreateDeploymentBranches(ctx context.Context, owner string, repo string, env string, data io.Reader) error {
 	url := fmt.Sprintf("repos/%s/%s/environments/%s/deployment-branch-policies", owner, repo, env)
 
-	resp, err := g.restClient.Request("POST", url, data)
+	resp, err := g.restClient.RequestWithContext(ctx, "POST", url, data)
+	if err != nil {
+		return fmt.Errorf("HTTP error for URL %s: %v", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Error closing response body: %v", closeErr)
+		}
+	}()
+	return nil
+}
+
+func (g *APIGetter) DeleteEnvironment(ctx context.Context, owner string, repo string, env string) error {
+	url := fmt.Sprintf("repos/%s/%s/environments/%s", owner, repo, env)
+
+	resp, err := g.restClient.RequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("HTTP error for URL %s: %v", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Error closing response body: %v", closeErr)
+		}
+	}()
+	return nil
+}
+
+func (g *APIGetter) DeleteDeploymentBranchPolicy(ctx context.Context, owner string, repo string, env string, policyID int) error {
+	url := fmt.Sprintf("repos/%s/%s/environments/%s/deployment-branch-policies/%d", owner, repo, env, policyID)
+
+	resp, err := g.restClient.RequestWithContext(ctx, "DELETE", url, nil)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("HTTP error for URL %s: %v", url, err)
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
 			log.Printf("Error closing response body: %v", closeErr)
 		}
 	}()
-	return err
+	return nil
 }
 
-func (g *APIGetter) GetDeploymentProtectionRules(owner string, repo string, env string) ([]byte, error) {
+// BranchPolicyChanges describes how the existing deployment branch policies of
+// an environment differ from the policies listed in an import file.
+type BranchPolicyChanges struct {
+	Add       []data.CreateDeploymentBranch
+	Remove    []data.BranchPolicy
+	Unchanged []data.BranchPolicy
+}
+
+// DiffDeploymentBranches compares existing and desired branch policies by name
+// and type. Policies without a type are treated as branch policies.
+func DiffDeploymentBranches(existing []data.BranchPolicy, desired []data.CreateDeploymentBranch) BranchPolicyChanges {
+	var changes BranchPolicyChanges
+	key := func(name string, policyType string) string {
+		if policyType == "" {
+			policyType = "branch"
+		}
+		return policyType + ";" + name
+	}
+
+	wanted := make(map[string]bool)
+	for _, branch := range desired {
+		wanted[key(branch.Name, branch.Type)] = true
+	}
+
+	found := make(map[string]bool)
+	for _, policy := range existing {
+		k := key(policy.Name, policy.Type)
+		if wanted[k] {
+			found[k] = true
+			changes.Unchanged = append(changes.Unchanged, policy)
+		} else {
+			changes.Remove = append(changes.Remove, policy)
+		}
+	}
+
+	for _, branch := range desired {
+		k := key(branch.Name, branch.Type)
+		if !found[k] {
+			found[k] = true
+			changes.Add = append(changes.Add, branch)
+		}
+	}
+	return changes
+}
+
+func (g *APIGetter) GetDeploymentProtectionRules(ctx context.Context, owner string, repo string, env string) ([]byte, error) {
 	url := fmt.Sprintf("repos/%s/%s/environments/%s/deployment_protection_rules", owner, repo, env)
-	resp, err := g.restClient.Request("GET", url, nil)
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		log.Printf("Body read error, %v", err)
 		return nil, err
@@ -188,3 +329,40 @@ func (g *APIGetter) GetDeploymentProtectionRules(owner string, repo string, env
 	}
 	return responseData, err
 }
+
+// EnvironmentIndex remembers the environments of each repository it is asked
+// about, so commands writing environments can tell creates from updates with
+// one request per repository.
+type EnvironmentIndex struct {
+	g     environmentsGetter
+	owner string
+	names map[string]map[string]bool
+}
+
+func NewEnvironmentIndex(g environmentsGetter, owner string) *EnvironmentIndex {
+	return &EnvironmentIndex{g: g, owner: owner, names: make(map[string]map[string]bool)}
+}
+
+// Exists reports whether env exists in repo. Repositories whose environments
+// cannot be read are treated as having none.
+func (x *EnvironmentIndex) Exists(ctx context.Context, repo string, env string) bool {
+	names, ok := x.names[repo]
+	if !ok {
+		names = make(map[string]bool)
+		x.names[repo] = names
+		resp, err := x.g.GetRepoEnvironments(ctx, x.owner, repo)
+		if err != nil {
+			zap.S().Debugf("Unable to read the environments of %s: %v", repo, err)
+			return false
+		}
+		var environments data.EnvResponse
+		if err := json.Unmarshal(resp, &environments); err != nil {
+			zap.S().Debugf("Unable to parse the environments of %s: %v", repo, err)
+			return false
+		}
+		for _, environment := range environments.Environments {
+			names[strings.ToLower(environment.Name)] = true
+		}
+	}
+	return names[strings.ToLower(env)]
+}
@@ -2,7 +2,9 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
@@ -14,6 +16,7 @@ import (
 )
 
 func TestGetRepoEnvironments(t *testing.T) {
+	ctx := context.Background()
 	logger := zaptest.NewLogger(t)
 	zap.ReplaceGlobals(logger)
 
@@ -32,7 +35,7 @@ func TestGetRepoEnvironments(t *testing.T) {
 	getter := newAPIGetterWithMockREST(mockClient)
 
 	// Call the method
-	result, err := getter.GetRepoEnvironments("testorg", "testrepo")
+	result, err := getter.GetRepoEnvironments(ctx, "testorg", "testrepo")
 
 	// Verify
 	if err != nil {
@@ -72,6 +75,7 @@ func TestGetRepoEnvironments(t *testing.T) {
 }
 
 func TestGetDeploymentBranchPolicies(t *testing.T) {
+	ctx := context.Background()
 	logger := zaptest.NewLogger(t)
 	zap.ReplaceGlobals(logger)
 
@@ -95,7 +99,7 @@ func TestGetDeploymentBranchPolicies(t *testing.T) {
 	getter := newAPIGetterWithMockREST(mockClient)
 
 	// Call the method
-	result, err := getter.GetDeploymentBranchPolicies("testorg", "testrepo", "production")
+	result, err := getter.GetDeploymentBranchPolicies(ctx, "testorg", "testrepo", "production", 1)
 
 	// Verify
 	if err != nil {
@@ -135,9 +139,13 @@ func TestCreateEnvironmentList(t *testing.T) {
 	}
 
 	// Execute
-	result := g.CreateEnvironmentList(filedata)
+	result, err := g.CreateEnvironmentList(filedata)
 
 	// Verify
+	if err != nil {
+		t.Fatalf("CreateEnvironmentList() error = %v", err)
+	}
+
 	if len(result) != 2 {
 		t.Errorf("Expected 2 environments, got %d", len(result))
 		return
@@ -187,6 +195,7 @@ func TestCreateEnvironmentList(t *testing.T) {
 }
 
 func TestCreateEnvironment(t *testing.T) {
+	ctx := context.Background()
 	logger := zaptest.NewLogger(t)
 	zap.ReplaceGlobals(logger)
 
@@ -244,10 +253,221 @@ func TestCreateEnvironment(t *testing.T) {
 	jsonData, _ := json.Marshal(envData)
 
 	// Call the method
-	err := getter.CreateEnvironment("testorg", "testrepo", "production", bytes.NewReader(jsonData))
+	err := getter.CreateEnvironment(ctx, "testorg", "testrepo", "production", bytes.NewReader(jsonData))
 
 	// Verify
 	if err != nil {
 		t.Errorf("CreateEnvironment() error = %v", err)
 	}
 }
+
+func TestCreateEnvironmentListByHeader(t *testing.T) {
+	g := &APIGetter{}
+	filedata := [][]string{
+		{"EnvironmentName", "RepositoryName", "WaitTimer", "SecretsTotalCount", "BranchPolicyType", "Branches"},
+		{"production", "testrepo", "10", "3", "custom", "main;branch|v*;tag"},
+	}
+
+	result, err := g.CreateEnvironmentList(filedata)
+	if err != nil {
+		t.Fatalf("CreateEnvironmentList() error = %v", err)
+	}
+
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 environment, got %d", len(result))
+	}
+
+	if result[0].RepositoryName != "testrepo" || result[0].EnvironmentName != "production" {
+		t.Errorf("Expected testrepo/production, got %s/%s", result[0].RepositoryName, result[0].EnvironmentName)
+	}
+
+	if result[0].WaitTimer != 10 {
+		t.Errorf("Expected WaitTimer 10, got %d", result[0].WaitTimer)
+	}
+
+	if len(result[0].Reviewers) != 0 {
+		t.Errorf("Expected no reviewers, got %d", len(result[0].Reviewers))
+	}
+
+	if len(result[0].Branches) != 2 || result[0].Branches[1].Type != "tag" {
+		t.Errorf("Expected 2 branches with a tag pattern, got %v", result[0].Branches)
+	}
+
+	_, err = g.CreateEnvironmentList([][]string{{"RepositoryName", "WaitTimer"}})
+	if err == nil {
+		t.Error("Expected error for missing EnvironmentName header, got nil")
+	}
+}
+
+func TestDiffDeploymentBranches(t *testing.T) {
+	existing := []data.BranchPolicy{
+		{ID: 1, Name: "main", Type: "branch"},
+		{ID: 2, Name: "release/*", Type: "branch"},
+		{ID: 3, Name: "v*", Type: "tag"},
+	}
+	desired := []data.CreateDeploymentBranch{
+		{Name: "main", Type: "branch"},
+		{Name: "v*", Type: "branch"},
+		{Name: "hotfix/*", Type: ""},
+		{Name: "main", Type: "branch"},
+	}
+
+	changes := DiffDeploymentBranches(existing, desired)
+
+	if len(changes.Unchanged) != 1 || changes.Unchanged[0].ID != 1 {
+		t.Errorf("Expected main to be unchanged, got %v", changes.Unchanged)
+	}
+
+	if len(changes.Remove) != 2 || changes.Remove[0].ID != 2 || changes.Remove[1].ID != 3 {
+		t.Errorf("Expected release/* and the v* tag to be removed, got %v", changes.Remove)
+	}
+
+	if len(changes.Add) != 2 || changes.Add[0].Name != "v*" || changes.Add[1].Name != "hotfix/*" {
+		t.Errorf("Expected the v* branch and hotfix/* to be added, got %v", changes.Add)
+	}
+}
+
+func TestDeleteDeploymentBranchPolicy(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	zap.ReplaceGlobals(logger)
+
+	// Setup mock REST client
+	mockClient := &mockRESTClient{
+		RequestFunc: func(method string, path string, body io.Reader) (*http.Response, error) {
+			if method != "DELETE" {
+				t.Errorf("Expected method DELETE, got %s", method)
+			}
+
+			expectedPath := "repos/testorg/testrepo/environments/production/deployment-branch-policies/123"
+			if path != expectedPath {
+				t.Errorf("Expected path %s, got %s", expectedPath, path)
+			}
+
+			return &http.Response{
+				StatusCode: 204,
+				Body:       io.NopCloser(strings.NewReader("")),
+			}, nil
+		},
+	}
+
+	getter := newAPIGetterWithMockREST(mockClient)
+
+	err := getter.DeleteDeploymentBranchPolicy(ctx, "testorg", "testrepo", "production", 123)
+	if err != nil {
+		t.Errorf("DeleteDeploymentBranchPolicy() error = %v", err)
+	}
+}
+
+func TestCreateEnvironmentListProtectionRules(t *testing.T) {
+	g := &APIGetter{}
+	filedata := [][]string{
+		{"RepositoryName", "EnvironmentName", "CustomDeploymentProtectionPolicy"},
+		{"testrepo", "production", "10;true;1;deploy-gate|11;false;2;old-gate|malformed"},
+	}
+
+	result, err := g.CreateEnvironmentList(filedata)
+	if err != nil {
+		t.Fatalf("CreateEnvironmentList() error = %v", err)
+	}
+
+	rules := result[0].ProtectionRules
+	if len(rules) != 2 {
+		t.Fatalf("Expected 2 protection rules, got %d", len(rules))
+	}
+
+	if rules[0].PolicyID != 10 || !rules[0].Enabled || rules[0].App.IntegrationID != 1 || rules[0].App.Slug != "deploy-gate" {
+		t.Errorf("Unexpected first protection rule: %v", rules[0])
+	}
+
+	if rules[1].Enabled {
+		t.Error("Expected second protection rule to be disabled")
+	}
+}
+
+func TestCreateEnvironmentDataFidelity(t *testing.T) {
+	result := CreateEnvironmentData(data.ImportedEnvironment{
+		AdminBypass:       "False",
+		PreventSelfReview: true,
+	})
+
+	if result.CanAdminsBypass == nil || *result.CanAdminsBypass {
+		t.Error("Expected CanAdminsBypass to be set to false")
+	}
+
+	if result.PreventSelfReview {
+		t.Error("Expected PreventSelfReview to be omitted when there are no reviewers")
+	}
+
+	result = CreateEnvironmentData(data.ImportedEnvironment{
+		PreventSelfReview: true,
+		Reviewers:         []data.Reviewers{{Type: "User", Reviewer: data.Reviewer{ID: 1}}},
+	})
+
+	if result.CanAdminsBypass != nil {
+		t.Error("Expected CanAdminsBypass to be omitted when AdminBypass is empty")
+	}
+
+	if !result.PreventSelfReview {
+		t.Error("Expected PreventSelfReview to be set when there are reviewers")
+	}
+
+	body, _ := json.Marshal(result)
+	if strings.Contains(string(body), "can_admins_bypass") {
+		t.Errorf("Expected can_admins_bypass to be omitted from %s", body)
+	}
+}
+
+func TestEnvironmentIndex(t *testing.T) {
+	ctx := context.Background()
+	server := NewMockGitHubServer()
+	server.AddRepo(1, "app").Environments["Production"] = &MockEnvironment{Name: "Production"}
+	requests := 0
+	server.BeforeRequest = func(req *http.Request) { requests++ }
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := NewEnvironmentIndex(g, "testorg")
+	if !index.Exists(ctx, "app", "production") {
+		t.Error("Expected production to exist")
+	}
+	if index.Exists(ctx, "app", "staging") {
+		t.Error("Expected staging not to exist")
+	}
+	if requests != 1 {
+		t.Errorf("Expected the environments of app to be read once, got %d requests", requests)
+	}
+	// Repositories that cannot be read have no environments
+	if index.Exists(ctx, "missing", "production") {
+		t.Error("Expected no environments for a missing repository")
+	}
+}
+
+func TestGatherDeploymentBranchPolicies(t *testing.T) {
+	ctx := context.Background()
+	server := NewMockGitHubServer()
+	env := &MockEnvironment{Name: "production"}
+	for i := 0; i < 150; i++ {
+		env.BranchPolicies = append(env.BranchPolicies, data.BranchPolicy{ID: i + 1, Name: fmt.Sprintf("release/%d", i), Type: "branch"})
+	}
+	server.AddRepo(1, "app").Environments["production"] = env
+	requests := 0
+	server.BeforeRequest = func(req *http.Request) { requests++ }
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policies, err := GatherDeploymentBranchPolicies(ctx, g, "testorg", "app", "production")
+	if err != nil {
+		t.Fatalf("GatherDeploymentBranchPolicies() error = %v", err)
+	}
+	if len(policies) != 150 || policies[149].Name != "release/149" {
+		t.Errorf("Expected every branch policy to be gathered, got %d", len(policies))
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 pages to be read, got %d requests", requests)
+	}
+}
@@ -1,34 +1,57 @@
 package utils
 
 import (
+	"context"
 	"io"
 
 	"github.com/cli/go-gh/v2/pkg/api"
 	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/githubapp"
 	"github.com/shurcooL/graphql"
 )
 
 type Getter interface {
-	CreateEnvironment(owner string, repo string, env string, data io.Reader) error
-	CreateEnvironmentList(filedata [][]string) []data.ImportedEnvironment
-	CreateEnvironmentVariables(repo_id int, env string, data io.Reader) error
-	CreateEnvironmentSecret(repo_id int, env string, secret string, data io.Reader) error
-	CreateDeploymentBranches(owner string, repo string, env string, data io.Reader) error
-	CreateSecretList(filedata [][]string) []data.ImportedSecret
+	CreateEnvironment(ctx context.Context, owner string, repo string, env string, data io.Reader) error
+	CreateEnvironmentList(filedata [][]string) ([]data.ImportedEnvironment, error)
+	CreateEnvironmentVariables(ctx context.Context, repo_id int, env string, data io.Reader) error
+	CreateEnvironmentSecret(ctx context.Context, repo_id int, env string, secret string, data io.Reader) error
+	CreateDeploymentBranches(ctx context.Context, owner string, repo string, env string, data io.Reader) error
+	CreateDeploymentProtectionRule(ctx context.Context, owner string, repo string, env string, data io.Reader) error
+	CreateSecretList(filedata [][]string) ([]data.ImportedSecret, error)
+	DeleteDeploymentBranchPolicy(ctx context.Context, owner string, repo string, env string, policyID int) error
+	DeleteDeploymentProtectionRule(ctx context.Context, owner string, repo string, env string, ruleID int) error
+	DeleteEnvironment(ctx context.Context, owner string, repo string, env string) error
 	EncryptSecret(publickey string, secret string) (string, error)
-	GetDeploymentBranchPolicies(owner string, repo string, env string) ([]byte, error)
-	GetDeploymentProtectionRules(owner string, repo string, env string) ([]byte, error)
-	GetEnvironmentPublicKey(repo_id int, env string) ([]byte, error)
-	GetEnvironmentVariables(repo_id int, env string) ([]byte, error)
-	GetEnvironmentSecrets(repo_id int, env string) ([]byte, error)
-	GetRepo(owner string, name string) ([]data.RepoSingleQuery, error)
-	GetRepoEnvironments(owner string, repo string) ([]byte, error)
-	GetReposList(owner string, endCursor *string) ([]data.ReposQuery, error)
+	GetAvailableDeploymentApps(ctx context.Context, owner string, repo string, env string) ([]byte, error)
+	GetDeploymentBranchPolicies(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error)
+	GetDeploymentProtectionRules(ctx context.Context, owner string, repo string, env string) ([]byte, error)
+	GetDeployments(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error)
+	GetEnvironmentPublicKey(ctx context.Context, repo_id int, env string) ([]byte, error)
+	GetEnvironmentVariables(ctx context.Context, repo_id int, env string, page int) ([]byte, error)
+	GetEnvironmentSecrets(ctx context.Context, repo_id int, env string, page int) ([]byte, error)
+	GetLatestDeploymentStatus(ctx context.Context, owner string, repo string, deploymentID int) ([]byte, error)
+	GetPendingDeployments(ctx context.Context, owner string, repo string, runID int) ([]byte, error)
+	GetRepoContents(ctx context.Context, owner string, repo string, path string) ([]byte, error)
+	GetRepo(ctx context.Context, owner string, name string) ([]data.RepoSingleQuery, error)
+	GetRepoEnvironments(ctx context.Context, owner string, repo string) ([]byte, error)
+	GetReposList(ctx context.Context, owner string, endCursor *string) ([]data.ReposQuery, error)
+	GetFilteredReposList(ctx context.Context, owner string, endCursor *string, filter RepoFilter) (*data.ReposQuery, error)
+	GetTeam(ctx context.Context, owner string, slug string) ([]byte, error)
+	GetTeamMembers(ctx context.Context, owner string, slug string, page int) ([]byte, error)
+	GetUser(ctx context.Context, login string) ([]byte, error)
+	GetWaitingRuns(ctx context.Context, owner string, repo string, page int) ([]byte, error)
+	ReviewPendingDeployments(ctx context.Context, owner string, repo string, runID int, data io.Reader) error
 }
 
 type APIGetter struct {
-	gqlClient  api.GraphQLClient
-	restClient api.RESTClient
+	gqlClient   api.GraphQLClient
+	restClient  api.RESTClient
+	concurrency int
+}
+
+// Concurrency is the number of repositories gathered at the same time.
+func (g *APIGetter) Concurrency() int {
+	return g.concurrency
 }
 
 func NewAPIGetter(gqlClient *api.GraphQLClient, restClient *api.RESTClient) *APIGetter {
@@ -48,25 +71,17 @@ func NewSourceAPIGetter(restClient api.RESTClient) *sourceAPIGetter {
 	}
 }
 
-func (g *APIGetter) GetReposList(owner string, endCursor *string) (*data.ReposQuery, error) {
-	query := new(data.ReposQuery)
-	variables := map[string]interface{}{
-		"endCursor": (*graphql.String)(endCursor),
-		"owner":     graphql.String(owner),
-	}
-
-	err := g.gqlClient.Query("getRepos", &query, variables)
-
-	return query, err
+func (g *APIGetter) GetReposList(ctx context.Context, owner string, endCursor *string) (*data.ReposQuery, error) {
+	return g.GetFilteredReposList(ctx, owner, endCursor, RepoFilter{})
 }
 
-func (g *APIGetter) GetRepo(owner string, name string) (*data.RepoSingleQuery, error) {
+func (g *APIGetter) GetRepo(ctx context.Context, owner string, name string) (*data.RepoSingleQuery, error) {
 	query := new(data.RepoSingleQuery)
 	variables := map[string]interface{}{
 		"owner": graphql.String(owner),
 		"name":  graphql.String(name),
 	}
 
-	err := g.gqlClient.Query("getRepo", &query, variables)
+	err := g.gqlClient.QueryWithContext(githubapp.WithOwner(ctx, owner), "getRepo", &query, variables)
 	return query, err
 }
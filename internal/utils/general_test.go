@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"testing"
 
 	"github.com/cli/go-gh/v2/pkg/api"
@@ -26,6 +27,7 @@ func TestNewAPIGetter(t *testing.T) {
 }
 
 func TestMockAPIGetter(t *testing.T) {
+	ctx := context.Background()
 	// Create a mock API getter
 	mockGetter := NewMockAPIGetter()
 
@@ -33,7 +35,7 @@ func TestMockAPIGetter(t *testing.T) {
 	mockGetter.EnvironmentsData = []byte(`{"total_count": 1, "environments": [{"name": "production"}]}`)
 
 	// Call a method on the mock
-	data, err := mockGetter.GetRepoEnvironments("testorg", "testrepo")
+	data, err := mockGetter.GetRepoEnvironments(ctx, "testorg", "testrepo")
 
 	// Verify
 	if err != nil {
@@ -46,7 +48,7 @@ func TestMockAPIGetter(t *testing.T) {
 
 	// Test error scenario
 	mockGetter.ShouldFailGetEnvironments = true
-	_, err = mockGetter.GetRepoEnvironments("testorg", "testrepo")
+	_, err = mockGetter.GetRepoEnvironments(ctx, "testorg", "testrepo")
 	if err == nil {
 		t.Error("Expected error from mock, got nil")
 	}
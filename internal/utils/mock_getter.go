@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -15,6 +16,7 @@ type MockAPIGetter struct {
 	EnvironmentsData         []byte
 	BranchPoliciesData       []byte
 	ProtectionRulesData      []byte
+	AvailableAppsData        []byte
 	EnvironmentSecretsData   []byte
 	EnvironmentVariablesData []byte
 	PublicKeyData            []byte
@@ -27,6 +29,16 @@ type MockAPIGetter struct {
 	ShouldFailCreateVariable    bool
 	ShouldFailGetPublicKey      bool
 	ShouldFailEncryptSecret     bool
+	ShouldFailCreateBranch      bool
+	ShouldFailCreateRule        bool
+
+	// Branch policies added and removed through CreateDeploymentBranches and DeleteDeploymentBranchPolicy
+	CreatedBranchPolicies []data.CreateDeploymentBranch
+	DeletedBranchPolicies []int
+
+	// Protection rules enabled and disabled through CreateDeploymentProtectionRule and DeleteDeploymentProtectionRule
+	CreatedProtectionRules []data.CreateDeploymentProtectionRule
+	DeletedProtectionRules []int
 }
 
 func NewMockAPIGetter() *MockAPIGetter {
@@ -76,37 +88,46 @@ func NewMockAPIGetter() *MockAPIGetter {
 	return m
 }
 
-func (m *MockAPIGetter) GetRepoEnvironments(owner string, repo string) ([]byte, error) {
+func (m *MockAPIGetter) GetRepoEnvironments(ctx context.Context, owner string, repo string) ([]byte, error) {
 	if m.ShouldFailGetEnvironments {
 		return nil, fmt.Errorf("mock error: failed to get environments")
 	}
 	return m.EnvironmentsData, nil
 }
 
-func (m *MockAPIGetter) CreateEnvironment(owner string, repo string, env string, data io.Reader) error {
+func (m *MockAPIGetter) CreateEnvironment(ctx context.Context, owner string, repo string, env string, data io.Reader) error {
 	if m.ShouldFailCreateEnvironment {
 		return fmt.Errorf("mock error: failed to create environment")
 	}
 	return nil
 }
 
-func (m *MockAPIGetter) GetDeploymentBranchPolicies(owner string, repo string, env string) ([]byte, error) {
+func (m *MockAPIGetter) GetDeploymentBranchPolicies(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error) {
+	if page > 1 {
+		return []byte("{}"), nil
+	}
 	return m.BranchPoliciesData, nil
 }
 
-func (m *MockAPIGetter) GetDeploymentProtectionRules(owner string, repo string, env string) ([]byte, error) {
+func (m *MockAPIGetter) GetDeploymentProtectionRules(ctx context.Context, owner string, repo string, env string) ([]byte, error) {
 	return m.ProtectionRulesData, nil
 }
 
-func (m *MockAPIGetter) GetEnvironmentSecrets(owner string, repo string, env string) ([]byte, error) {
+func (m *MockAPIGetter) GetEnvironmentSecrets(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error) {
+	if page > 1 {
+		return []byte("{}"), nil
+	}
 	return m.EnvironmentSecretsData, nil
 }
 
-func (m *MockAPIGetter) GetEnvironmentVariables(owner string, repo string, env string) ([]byte, error) {
+func (m *MockAPIGetter) GetEnvironmentVariables(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error) {
+	if page > 1 {
+		return []byte("{}"), nil
+	}
 	return m.EnvironmentVariablesData, nil
 }
 
-func (m *MockAPIGetter) GetEnvironmentPublicKey(owner string, repo string, env string) ([]byte, error) {
+func (m *MockAPIGetter) GetEnvironmentPublicKey(ctx context.Context, owner string, repo string, env string) ([]byte, error) {
 	if m.ShouldFailGetPublicKey {
 		return nil, fmt.Errorf("mock error: failed to get public key")
 	}
@@ -121,7 +142,7 @@ func (m *MockAPIGetter) EncryptSecret(publicKey string, secret string) (string,
 }
 
 // Add the missing CreateEnvironmentSecret method
-func (m *MockAPIGetter) CreateEnvironmentSecret(owner string, repo string, env string, secret string, data io.Reader) error {
+func (m *MockAPIGetter) CreateEnvironmentSecret(ctx context.Context, owner string, repo string, env string, secret string, data io.Reader) error {
 	if m.ShouldFailCreateSecret {
 		return fmt.Errorf("mock error: failed to create environment secret")
 	}
@@ -129,7 +150,7 @@ func (m *MockAPIGetter) CreateEnvironmentSecret(owner string, repo string, env s
 }
 
 // Add the missing CreateEnvironmentVariables method
-func (m *MockAPIGetter) CreateEnvironmentVariables(owner string, repo string, env string, data io.Reader) error {
+func (m *MockAPIGetter) CreateEnvironmentVariables(ctx context.Context, owner string, repo string, env string, data io.Reader) error {
 	if m.ShouldFailCreateVariable {
 		return fmt.Errorf("mock error: failed to create environment variable")
 	}
@@ -137,76 +158,98 @@ func (m *MockAPIGetter) CreateEnvironmentVariables(owner string, repo string, en
 }
 
 // Add CreateEnvironmentList method for completeness
-func (m *MockAPIGetter) CreateEnvironmentList(filedata [][]string) []data.ImportedEnvironment {
+func (m *MockAPIGetter) CreateEnvironmentList(filedata [][]string) ([]data.ImportedEnvironment, error) {
 	var environmentList []data.ImportedEnvironment
 	// Implement basic conversion from CSV data to ImportedEnvironment
-	for i, row := range filedata {
-		if i == 0 { // Skip header row
-			continue
-		}
-
-		if len(row) < 3 { // Need at least repo name, ID, and env name
-			continue
-		}
-
+	header, err := parseCSVHeader(filedata, EnvironmentRequiredHeaders)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range filedata[1:] {
 		env := data.ImportedEnvironment{
-			RepositoryName:  row[0],
-			EnvironmentName: row[2],
+			RepositoryName:  header.Value(row, "RepositoryName"),
+			EnvironmentName: header.Value(row, "EnvironmentName"),
 		}
 		environmentList = append(environmentList, env)
 	}
-	return environmentList
+	return environmentList, nil
 }
 
 // Add CreateSecretList method for completeness
-func (m *MockAPIGetter) CreateSecretList(filedata [][]string) []data.ImportedSecret {
+func (m *MockAPIGetter) CreateSecretList(filedata [][]string) ([]data.ImportedSecret, error) {
 	var secretList []data.ImportedSecret
 	// Implement basic conversion from CSV data to ImportedSecret
-	for i, row := range filedata {
-		if i == 0 { // Skip header row
-			continue
-		}
-
-		if len(row) < 5 { // Need repo name, repo ID, env name, secret name, secret value
-			continue
-		}
-
+	header, err := parseCSVHeader(filedata, SecretRequiredHeaders)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range filedata[1:] {
 		secret := data.ImportedSecret{
-			RepositoryName:  row[0],
-			EnvironmentName: row[2],
-			Name:            row[3],
-			Value:           row[4],
+			RepositoryName:  header.Value(row, "RepositoryName"),
+			EnvironmentName: header.Value(row, "EnvironmentName"),
+			Name:            header.Value(row, "SecretName"),
+			Value:           header.Value(row, "SecretValue"),
 		}
 		secretList = append(secretList, secret)
 	}
-	return secretList
+	return secretList, nil
 }
 
 // Add CreateVariableList method for completeness
-func (m *MockAPIGetter) CreateVariableList(filedata [][]string) []data.ImportedVariable {
+func (m *MockAPIGetter) CreateVariableList(filedata [][]string) ([]data.ImportedVariable, error) {
 	var variableList []data.ImportedVariable
 	// Implement basic conversion from CSV data to ImportedVariable
-	for i, row := range filedata {
-		if i == 0 { // Skip header row
-			continue
-		}
-
-		if len(row) < 5 { // Need repo name, repo ID, env name, var name, var value
-			continue
-		}
-
+	header, err := parseCSVHeader(filedata, VariableRequiredHeaders)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range filedata[1:] {
 		variable := data.ImportedVariable{
-			RepositoryName:  row[0],
-			EnvironmentName: row[2],
-			Name:            row[3],
-			Value:           row[4],
+			RepositoryName:  header.Value(row, "RepositoryName"),
+			EnvironmentName: header.Value(row, "EnvironmentName"),
+			Name:            header.Value(row, "VariableName"),
+			Value:           header.Value(row, "VariableValue"),
 		}
 		variableList = append(variableList, variable)
 	}
-	return variableList
+	return variableList, nil
 }
 
 // Add CreateDeploymentBranches method for completeness
-func (m *MockAPIGetter) CreateDeploymentBranches(owner string, repo string, env string, data io.Reader) error {
+func (m *MockAPIGetter) CreateDeploymentBranches(ctx context.Context, owner string, repo string, env string, body io.Reader) error {
+	if m.ShouldFailCreateBranch {
+		return fmt.Errorf("mock error: failed to create branch policy")
+	}
+	var branch data.CreateDeploymentBranch
+	if err := json.NewDecoder(body).Decode(&branch); err != nil {
+		return err
+	}
+	m.CreatedBranchPolicies = append(m.CreatedBranchPolicies, branch)
+	return nil
+}
+
+func (m *MockAPIGetter) DeleteDeploymentBranchPolicy(ctx context.Context, owner string, repo string, env string, policyID int) error {
+	m.DeletedBranchPolicies = append(m.DeletedBranchPolicies, policyID)
+	return nil
+}
+
+func (m *MockAPIGetter) GetAvailableDeploymentApps(ctx context.Context, owner string, repo string, env string) ([]byte, error) {
+	return m.AvailableAppsData, nil
+}
+
+func (m *MockAPIGetter) CreateDeploymentProtectionRule(ctx context.Context, owner string, repo string, env string, body io.Reader) error {
+	if m.ShouldFailCreateRule {
+		return fmt.Errorf("mock error: failed to create protection rule")
+	}
+	var rule data.CreateDeploymentProtectionRule
+	if err := json.NewDecoder(body).Decode(&rule); err != nil {
+		return err
+	}
+	m.CreatedProtectionRules = append(m.CreatedProtectionRules, rule)
+	return nil
+}
+
+func (m *MockAPIGetter) DeleteDeploymentProtectionRule(ctx context.Context, owner string, repo string, env string, ruleID int) error {
+	m.DeletedProtectionRules = append(m.DeletedProtectionRules, ruleID)
 	return nil
 }
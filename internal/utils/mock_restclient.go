@@ -41,9 +41,9 @@ func newAPIGetterWithMockREST(client *mockRESTClient) *testAPIGetterWrapper {
 	}
 }
 
-func (t *testAPIGetterWrapper) GetRepoEnvironments(owner string, repo string) ([]byte, error) {
+func (t *testAPIGetterWrapper) GetRepoEnvironments(ctx context.Context, owner string, repo string) ([]byte, error) {
 	url := fmt.Sprintf("repos/%s/%s/environments", owner, repo)
-	resp, err := t.mockClient.Request("GET", url, nil)
+	resp, err := t.mockClient.RequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP error: %v", err)
 	}
@@ -60,9 +60,9 @@ func (t *testAPIGetterWrapper) GetRepoEnvironments(owner string, repo string) ([
 	return responseData, nil
 }
 
-func (t *testAPIGetterWrapper) GetDeploymentBranchPolicies(owner string, repo string, env string) ([]byte, error) {
-	url := fmt.Sprintf("repos/%s/%s/environments/%s/deployment-branch-policies", owner, repo, env)
-	resp, err := t.mockClient.Request("GET", url, nil)
+func (t *testAPIGetterWrapper) GetDeploymentBranchPolicies(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error) {
+	url := fmt.Sprintf("repos/%s/%s/environments/%s/deployment-branch-policies?per_page=%d&page=%d", owner, repo, env, branchPoliciesPerPage, page)
+	resp, err := t.mockClient.RequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP error: %v", err)
 	}
@@ -79,9 +79,9 @@ func (t *testAPIGetterWrapper) GetDeploymentBranchPolicies(owner string, repo st
 	return responseData, nil
 }
 
-func (t *testAPIGetterWrapper) GetDeploymentProtectionRules(owner string, repo string, env string) ([]byte, error) {
+func (t *testAPIGetterWrapper) GetDeploymentProtectionRules(ctx context.Context, owner string, repo string, env string) ([]byte, error) {
 	url := fmt.Sprintf("repos/%s/%s/environments/%s/deployment_protection_rules", owner, repo, env)
-	resp, err := t.mockClient.Request("GET", url, nil)
+	resp, err := t.mockClient.RequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP error: %v", err)
 	}
@@ -98,9 +98,9 @@ func (t *testAPIGetterWrapper) GetDeploymentProtectionRules(owner string, repo s
 	return responseData, nil
 }
 
-func (t *testAPIGetterWrapper) CreateEnvironment(owner string, repo string, env string, data io.Reader) error {
+func (t *testAPIGetterWrapper) CreateEnvironment(ctx context.Context, owner string, repo string, env string, data io.Reader) error {
 	url := fmt.Sprintf("repos/%s/%s/environments/%s", owner, repo, env)
-	resp, err := t.mockClient.Request("PUT", url, data)
+	resp, err := t.mockClient.RequestWithContext(ctx, "PUT", url, data)
 	if err != nil {
 		return fmt.Errorf("HTTP error: %v", err)
 	}
@@ -113,9 +113,73 @@ func (t *testAPIGetterWrapper) CreateEnvironment(owner string, repo string, env
 	return nil
 }
 
-func (t *testAPIGetterWrapper) CreateDeploymentBranches(owner string, repo string, env string, data io.Reader) error {
+func (t *testAPIGetterWrapper) CreateDeploymentBranches(ctx context.Context, owner string, repo string, env string, data io.Reader) error {
 	url := fmt.Sprintf("repos/%s/%s/environments/%s/deployment-branch-policies", owner, repo, env)
-	resp, err := t.mockClient.Request("POST", url, data)
+	resp, err := t.mockClient.RequestWithContext(ctx, "POST", url, data)
+	if err != nil {
+		return fmt.Errorf("HTTP error: %v", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			// Log error or handle it appropriately
+			fmt.Printf("Error closing response body: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+func (t *testAPIGetterWrapper) DeleteDeploymentBranchPolicy(ctx context.Context, owner string, repo string, env string, policyID int) error {
+	url := fmt.Sprintf("repos/%s/%s/environments/%s/deployment-branch-policies/%d", owner, repo, env, policyID)
+	resp, err := t.mockClient.RequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("HTTP error: %v", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			// Log error or handle it appropriately
+			fmt.Printf("Error closing response body: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+func (t *testAPIGetterWrapper) GetAvailableDeploymentApps(ctx context.Context, owner string, repo string, env string) ([]byte, error) {
+	url := fmt.Sprintf("repos/%s/%s/environments/%s/deployment_protection_rules/apps", owner, repo, env)
+	resp, err := t.mockClient.RequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP error: %v", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			// Log error or handle it appropriately
+			fmt.Printf("Error closing response body: %v\n", err)
+		}
+	}()
+	responseData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	return responseData, nil
+}
+
+func (t *testAPIGetterWrapper) CreateDeploymentProtectionRule(ctx context.Context, owner string, repo string, env string, data io.Reader) error {
+	url := fmt.Sprintf("repos/%s/%s/environments/%s/deployment_protection_rules", owner, repo, env)
+	resp, err := t.mockClient.RequestWithContext(ctx, "POST", url, data)
+	if err != nil {
+		return fmt.Errorf("HTTP error: %v", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			// Log error or handle it appropriately
+			fmt.Printf("Error closing response body: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+func (t *testAPIGetterWrapper) DeleteDeploymentProtectionRule(ctx context.Context, owner string, repo string, env string, ruleID int) error {
+	url := fmt.Sprintf("repos/%s/%s/environments/%s/deployment_protection_rules/%d", owner, repo, env, ruleID)
+	resp, err := t.mockClient.RequestWithContext(ctx, "DELETE", url, nil)
 	if err != nil {
 		return fmt.Errorf("HTTP error: %v", err)
 	}
@@ -129,9 +193,9 @@ func (t *testAPIGetterWrapper) CreateDeploymentBranches(owner string, repo strin
 }
 
 // Environment secrets methods
-func (t *testAPIGetterWrapper) GetEnvironmentSecrets(owner string, repo string, env string) ([]byte, error) {
-	url := fmt.Sprintf("repos/%s/%s/environments/%s/secrets", owner, repo, env)
-	resp, err := t.mockClient.Request("GET", url, nil)
+func (t *testAPIGetterWrapper) GetEnvironmentSecrets(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error) {
+	url := fmt.Sprintf("repos/%s/%s/environments/%s/secrets?per_page=%d&page=%d", owner, repo, env, secretsPerPage, page)
+	resp, err := t.mockClient.RequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP error: %v", err)
 	}
@@ -148,9 +212,9 @@ func (t *testAPIGetterWrapper) GetEnvironmentSecrets(owner string, repo string,
 	return responseData, nil
 }
 
-func (t *testAPIGetterWrapper) GetEnvironmentPublicKey(owner string, repo string, env string) ([]byte, error) {
+func (t *testAPIGetterWrapper) GetEnvironmentPublicKey(ctx context.Context, owner string, repo string, env string) ([]byte, error) {
 	url := fmt.Sprintf("repos/%s/%s/environments/%s/secrets/public-key", owner, repo, env)
-	resp, err := t.mockClient.Request("GET", url, nil)
+	resp, err := t.mockClient.RequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP error: %v", err)
 	}
@@ -161,9 +225,9 @@ func (t *testAPIGetterWrapper) GetEnvironmentPublicKey(owner string, repo string
 	return responseData, nil
 }
 
-func (t *testAPIGetterWrapper) CreateEnvironmentSecret(owner string, repo string, env string, secret string, data io.Reader) error {
+func (t *testAPIGetterWrapper) CreateEnvironmentSecret(ctx context.Context, owner string, repo string, env string, secret string, data io.Reader) error {
 	url := fmt.Sprintf("repos/%s/%s/environments/%s/secrets/%s", owner, repo, env, secret)
-	resp, err := t.mockClient.Request("PUT", url, data)
+	resp, err := t.mockClient.RequestWithContext(ctx, "PUT", url, data)
 	if err != nil {
 		return fmt.Errorf("HTTP error: %v", err)
 	}
@@ -177,9 +241,9 @@ func (t *testAPIGetterWrapper) CreateEnvironmentSecret(owner string, repo string
 }
 
 // Environment variables methods
-func (t *testAPIGetterWrapper) GetEnvironmentVariables(owner string, repo string, env string) ([]byte, error) {
-	url := fmt.Sprintf("repos/%s/%s/environments/%s/variables", owner, repo, env)
-	resp, err := t.mockClient.Request("GET", url, nil)
+func (t *testAPIGetterWrapper) GetEnvironmentVariables(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error) {
+	url := fmt.Sprintf("repos/%s/%s/environments/%s/variables?per_page=%d&page=%d", owner, repo, env, variablesPerPage, page)
+	resp, err := t.mockClient.RequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP error: %v", err)
 	}
@@ -190,9 +254,9 @@ func (t *testAPIGetterWrapper) GetEnvironmentVariables(owner string, repo string
 	return responseData, nil
 }
 
-func (t *testAPIGetterWrapper) CreateEnvironmentVariables(owner string, repo string, env string, data io.Reader) error {
+func (t *testAPIGetterWrapper) CreateEnvironmentVariables(ctx context.Context, owner string, repo string, env string, data io.Reader) error {
 	url := fmt.Sprintf("repos/%s/%s/environments/%s/variables", owner, repo, env)
-	resp, err := t.mockClient.Request("POST", url, data)
+	resp, err := t.mockClient.RequestWithContext(ctx, "POST", url, data)
 	if err != nil {
 		return fmt.Errorf("HTTP error: %v", err)
 	}
@@ -0,0 +1,713 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+// MockGitHubServer is an in-memory stand-in for the parts of the GitHub REST and
+// GraphQL APIs used by this extension. It implements http.RoundTripper so that
+// real API clients can be pointed at it in tests.
+type MockGitHubServer struct {
+	mu     sync.Mutex
+	nextID int
+
+	Repos map[string]*MockRepo
+	// Apps available to be enabled as custom deployment protection rules
+	Apps []data.AvailableDeploymentApp
+	// Accounts used to resolve reviewer logins from the IDs sent on create
+	Accounts []data.Reviewers
+	// TeamMembers maps team slugs to the logins of their members. Members
+	// can also be looked up as users.
+	TeamMembers map[string][]string
+	// SuspendedUsers maps logins to when they were suspended
+	SuspendedUsers map[string]time.Time
+	// Organizations returned for any enterprise. Every organization shares
+	// the same repositories.
+	Organizations []string
+	// BeforeRequest, when set, is called with every request before it is
+	// served, letting tests interrupt a run part way through
+	BeforeRequest func(req *http.Request)
+	// Reject, when set, returns the error status to answer a request with
+	// instead of serving it, or 0 to serve it
+	Reject func(req *http.Request) int
+}
+
+type MockRepo struct {
+	ID         int
+	Name       string
+	Visibility string
+	IsArchived bool
+	IsFork     bool
+	Topics     []string
+	UpdatedAt  time.Time
+	// Properties are custom property values, either a string or []string
+	Properties   map[string]interface{}
+	Environments map[string]*MockEnvironment
+	Deployments  []MockDeployment
+	// Workflows maps file names in .github/workflows to their content
+	Workflows map[string]string
+	// WaitingRuns are workflow runs waiting for environment approval
+	WaitingRuns []*MockWorkflowRun
+}
+
+// MockWorkflowRun is a waiting run. Reviewing an environment removes it from
+// Pending and records the review.
+type MockWorkflowRun struct {
+	data.WorkflowRun
+	Pending []data.PendingDeployment
+	Reviews []data.ReviewPendingDeployments
+}
+
+// MockDeployment is a deployment and its statuses, newest first.
+type MockDeployment struct {
+	data.Deployment
+	Statuses []data.DeploymentStatus
+}
+
+type MockEnvironment struct {
+	Name              string
+	CanAdminsBypass   bool
+	WaitTimer         int
+	PreventSelfReview bool
+	Reviewers         []data.Reviewers
+	DeploymentPolicy  *data.DeploymentPolicy
+	BranchPolicies    []data.BranchPolicy
+	ProtectionRules   []data.DeploymentProtectionPolicyApp
+	Secrets           []data.Secret
+	Variables         []data.Variable
+}
+
+func NewMockGitHubServer() *MockGitHubServer {
+	return &MockGitHubServer{
+		nextID: 1000,
+		Repos:  make(map[string]*MockRepo),
+	}
+}
+
+// AddRepo registers an empty repository with the server.
+func (s *MockGitHubServer) AddRepo(id int, name string) *MockRepo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	repo := &MockRepo{
+		ID:           id,
+		Name:         name,
+		Visibility:   "PRIVATE",
+		UpdatedAt:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Environments: make(map[string]*MockEnvironment),
+	}
+	s.Repos[name] = repo
+	return repo
+}
+
+// APIGetter returns an APIGetter whose clients send every request to the
+// server.
+func (s *MockGitHubServer) APIGetter() (*APIGetter, error) {
+	opts := api.ClientOptions{AuthToken: "token", Host: "github.com", Transport: s}
+	gqlClient, err := api.NewGraphQLClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	restClient, err := api.NewRESTClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewAPIGetter(gqlClient, restClient), nil
+}
+
+func (s *MockGitHubServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/api/v3")
+	path = strings.Trim(path, "/")
+	if s.BeforeRequest != nil {
+		s.BeforeRequest(req)
+	}
+	// Like http.Transport, requests of a cancelled run are not sent
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	if s.Reject != nil {
+		if status := s.Reject(req); status != 0 {
+			return mockResponse(req, status, map[string]string{"message": http.StatusText(status)})
+		}
+	}
+	if strings.HasSuffix(path, "graphql") {
+		return s.graphql(req, body)
+	}
+	return s.rest(req, strings.Split(path, "/"), body)
+}
+
+func (s *MockGitHubServer) rest(req *http.Request, parts []string, body []byte) (*http.Response, error) {
+	// orgs/{owner}/properties/values, returned as a single page
+	if len(parts) == 4 && parts[0] == "orgs" && parts[2] == "properties" && parts[3] == "values" {
+		return mockResponse(req, http.StatusOK, s.propertyValuesResponse(parts[1]))
+	}
+
+	// orgs/{owner}/teams/{slug} and users/{login}, resolved from Accounts
+	if len(parts) == 4 && parts[0] == "orgs" && parts[2] == "teams" {
+		return s.accountResponse(req, "Team", parts[3])
+	}
+	if len(parts) == 5 && parts[0] == "orgs" && parts[2] == "teams" && parts[4] == "members" {
+		members := []data.User{}
+		if req.URL.Query().Get("page") == "1" {
+			for _, login := range s.TeamMembers[parts[3]] {
+				members = append(members, data.User{Login: login})
+			}
+		}
+		return mockResponse(req, http.StatusOK, members)
+	}
+	if len(parts) == 2 && parts[0] == "users" {
+		return s.accountResponse(req, "User", parts[1])
+	}
+
+	// repos/{owner}/{repo}/deployments[/{id}/statuses]
+	if len(parts) >= 4 && parts[0] == "repos" && parts[3] == "deployments" {
+		if repo, ok := s.Repos[parts[2]]; ok {
+			return s.deployments(req, repo, parts[4:])
+		}
+	}
+
+	// repos/{owner}/{repo}/actions/runs[/{id}/pending_deployments]
+	if len(parts) >= 5 && parts[0] == "repos" && parts[3] == "actions" && parts[4] == "runs" {
+		if repo, ok := s.Repos[parts[2]]; ok {
+			return s.workflowRuns(req, repo, parts[5:], body)
+		}
+	}
+
+	// repos/{owner}/{repo}/contents/.github/workflows[/{file}]
+	if len(parts) >= 4 && parts[0] == "repos" && parts[3] == "contents" {
+		if repo, ok := s.Repos[parts[2]]; ok {
+			return s.contents(req, repo, strings.Join(parts[4:], "/"))
+		}
+	}
+
+	// repos/{owner}/{repo}/environments[/{env}[/...]]
+	if len(parts) < 4 || parts[0] != "repos" || parts[3] != "environments" {
+		return mockResponse(req, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	}
+	repo, ok := s.Repos[parts[2]]
+	if !ok {
+		return mockResponse(req, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	}
+
+	if len(parts) == 4 {
+		return mockResponse(req, http.StatusOK, s.environmentsResponse(repo))
+	}
+
+	envName := parts[4]
+	env, exists := repo.Environments[envName]
+	if len(parts) == 5 {
+		switch req.Method {
+		case "PUT":
+			var create data.CreateEnvironment
+			if len(body) > 0 {
+				if err := json.Unmarshal(body, &create); err != nil {
+					return mockResponse(req, http.StatusUnprocessableEntity, map[string]string{"message": err.Error()})
+				}
+			}
+			if !exists {
+				env = &MockEnvironment{Name: envName, CanAdminsBypass: true}
+				repo.Environments[envName] = env
+			}
+			s.applyEnvironment(env, create)
+			return mockResponse(req, http.StatusOK, map[string]string{"name": envName})
+		case "DELETE":
+			delete(repo.Environments, envName)
+			return mockResponse(req, http.StatusNoContent, nil)
+		}
+	}
+	if !exists {
+		return mockResponse(req, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	}
+
+	switch parts[5] {
+	case "deployment-branch-policies":
+		return s.branchPolicies(req, env, parts[6:], body)
+	case "deployment_protection_rules":
+		return s.protectionRules(req, env, parts[6:], body)
+	case "secrets":
+		start, end := mockPage(req, len(env.Secrets), 30)
+		return mockResponse(req, http.StatusOK, data.EnvSecret{TotalCount: len(env.Secrets), Secrets: env.Secrets[start:end]})
+	case "variables":
+		return s.variables(req, env, parts[6:], body)
+	}
+	return mockResponse(req, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+func (s *MockGitHubServer) applyEnvironment(env *MockEnvironment, create data.CreateEnvironment) {
+	env.WaitTimer = create.WaitTimer
+	env.PreventSelfReview = create.PreventSelfReview
+	if create.CanAdminsBypass != nil {
+		env.CanAdminsBypass = *create.CanAdminsBypass
+	}
+	env.Reviewers = nil
+	for _, reviewer := range create.Reviewers {
+		resolved := data.Reviewers{Type: reviewer.Type, Reviewer: data.Reviewer{ID: reviewer.ID}}
+		for _, account := range s.Accounts {
+			if account.Type == reviewer.Type && account.Reviewer.ID == reviewer.ID {
+				resolved = account
+			}
+		}
+		env.Reviewers = append(env.Reviewers, resolved)
+	}
+	if create.DeploymentBranchPolicy == nil || !create.DeploymentBranchPolicy.CustomPolicies {
+		env.BranchPolicies = nil
+	}
+	env.DeploymentPolicy = create.DeploymentBranchPolicy
+}
+
+func (s *MockGitHubServer) variables(req *http.Request, env *MockEnvironment, rest []string, body []byte) (*http.Response, error) {
+	switch {
+	case req.Method == "GET":
+		start, end := mockPage(req, len(env.Variables), 10)
+		return mockResponse(req, http.StatusOK, data.EnvVariables{TotalCount: len(env.Variables), Variables: env.Variables[start:end]})
+	case req.Method == "POST":
+		var create data.CreateVariable
+		if err := json.Unmarshal(body, &create); err != nil {
+			return mockResponse(req, http.StatusUnprocessableEntity, map[string]string{"message": err.Error()})
+		}
+		for _, variable := range env.Variables {
+			if variable.Name == create.Name {
+				return mockResponse(req, http.StatusConflict, map[string]string{"message": "Already exists"})
+			}
+		}
+		env.Variables = append(env.Variables, data.Variable{Name: create.Name, Value: create.Value})
+		return mockResponse(req, http.StatusCreated, nil)
+	case req.Method == "PATCH" && len(rest) == 1:
+		var update data.CreateVariable
+		if err := json.Unmarshal(body, &update); err != nil {
+			return mockResponse(req, http.StatusUnprocessableEntity, map[string]string{"message": err.Error()})
+		}
+		for i := range env.Variables {
+			if env.Variables[i].Name == rest[0] {
+				env.Variables[i].Value = update.Value
+				return mockResponse(req, http.StatusNoContent, nil)
+			}
+		}
+	}
+	return mockResponse(req, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+func (s *MockGitHubServer) deployments(req *http.Request, repo *MockRepo, rest []string) (*http.Response, error) {
+	deployments := make([]MockDeployment, len(repo.Deployments))
+	copy(deployments, repo.Deployments)
+	sort.SliceStable(deployments, func(i, j int) bool { return deployments[i].CreatedAt.After(deployments[j].CreatedAt) })
+
+	if len(rest) == 0 {
+		query := req.URL.Query()
+		page, perPage := 1, 30
+		if p, err := strconv.Atoi(query.Get("page")); err == nil {
+			page = p
+		}
+		if p, err := strconv.Atoi(query.Get("per_page")); err == nil {
+			perPage = p
+		}
+		result := []data.Deployment{}
+		for _, deployment := range deployments {
+			if env := query.Get("environment"); env == "" || deployment.Environment == env {
+				result = append(result, deployment.Deployment)
+			}
+		}
+		start := (page - 1) * perPage
+		if start > len(result) {
+			start = len(result)
+		}
+		end := start + perPage
+		if end > len(result) {
+			end = len(result)
+		}
+		return mockResponse(req, http.StatusOK, result[start:end])
+	}
+
+	if len(rest) == 2 && rest[1] == "statuses" {
+		id, _ := strconv.Atoi(rest[0])
+		for _, deployment := range deployments {
+			if deployment.ID != id {
+				continue
+			}
+			statuses := []data.DeploymentStatus{}
+			statuses = append(statuses, deployment.Statuses...)
+			sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].CreatedAt.After(statuses[j].CreatedAt) })
+			if perPage, err := strconv.Atoi(req.URL.Query().Get("per_page")); err == nil && perPage < len(statuses) {
+				statuses = statuses[:perPage]
+			}
+			return mockResponse(req, http.StatusOK, statuses)
+		}
+	}
+	return mockResponse(req, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+func (s *MockGitHubServer) workflowRuns(req *http.Request, repo *MockRepo, rest []string, body []byte) (*http.Response, error) {
+	if len(rest) == 0 {
+		runs := []data.WorkflowRun{}
+		if req.URL.Query().Get("page") == "1" {
+			for _, run := range repo.WaitingRuns {
+				if len(run.Pending) > 0 {
+					runs = append(runs, run.WorkflowRun)
+				}
+			}
+		}
+		return mockResponse(req, http.StatusOK, data.WorkflowRunsResponse{TotalCount: len(runs), WorkflowRuns: runs})
+	}
+
+	if len(rest) == 2 && rest[1] == "pending_deployments" {
+		id, _ := strconv.Atoi(rest[0])
+		for _, run := range repo.WaitingRuns {
+			if run.ID != id {
+				continue
+			}
+			if req.Method == "GET" {
+				return mockResponse(req, http.StatusOK, run.Pending)
+			}
+			var review data.ReviewPendingDeployments
+			if err := json.Unmarshal(body, &review); err != nil {
+				return mockResponse(req, http.StatusUnprocessableEntity, map[string]string{"message": err.Error()})
+			}
+			var remaining []data.PendingDeployment
+			for _, pending := range run.Pending {
+				reviewed := false
+				for _, envID := range review.EnvironmentIDs {
+					reviewed = reviewed || pending.Environment.ID == envID
+				}
+				if !reviewed {
+					remaining = append(remaining, pending)
+				}
+			}
+			run.Pending = remaining
+			run.Reviews = append(run.Reviews, review)
+			return mockResponse(req, http.StatusOK, []data.Deployment{})
+		}
+	}
+	return mockResponse(req, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+func (s *MockGitHubServer) contents(req *http.Request, repo *MockRepo, path string) (*http.Response, error) {
+	if path == ".github/workflows" && repo.Workflows != nil {
+		var names []string
+		for name := range repo.Workflows {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		entries := []data.RepoContent{}
+		for _, name := range names {
+			entries = append(entries, data.RepoContent{Name: name, Path: path + "/" + name, Type: "file"})
+		}
+		return mockResponse(req, http.StatusOK, entries)
+	}
+	if name, ok := strings.CutPrefix(path, ".github/workflows/"); ok {
+		if content, exists := repo.Workflows[name]; exists {
+			return mockResponse(req, http.StatusOK, data.RepoContent{
+				Name:     name,
+				Path:     path,
+				Type:     "file",
+				Content:  base64.StdEncoding.EncodeToString([]byte(content)),
+				Encoding: "base64",
+			})
+		}
+	}
+	return mockResponse(req, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+func (s *MockGitHubServer) accountResponse(req *http.Request, accountType string, name string) (*http.Response, error) {
+	for _, account := range s.Accounts {
+		if account.Type != accountType {
+			continue
+		}
+		if accountType == "Team" && (account.Reviewer.Slug == name || account.Reviewer.Login == name) {
+			return mockResponse(req, http.StatusOK, data.Team{ID: account.Reviewer.ID, Name: name, Slug: name})
+		}
+		if accountType == "User" && account.Reviewer.Login == name {
+			return mockResponse(req, http.StatusOK, s.user(account.Reviewer.ID, name))
+		}
+	}
+	if accountType == "User" {
+		for _, members := range s.TeamMembers {
+			for _, login := range members {
+				if login == name {
+					return mockResponse(req, http.StatusOK, s.user(0, name))
+				}
+			}
+		}
+	}
+	return mockResponse(req, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+func (s *MockGitHubServer) user(id int, login string) data.User {
+	user := data.User{ID: id, Login: login}
+	if suspendedAt, ok := s.SuspendedUsers[login]; ok {
+		user.SuspendedAt = &suspendedAt
+	}
+	return user
+}
+
+func (s *MockGitHubServer) environmentsResponse(repo *MockRepo) data.EnvResponse {
+	var names []string
+	for name := range repo.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	response := data.EnvResponse{TotalCount: len(names)}
+	for _, name := range names {
+		env := repo.Environments[name]
+		environment := data.Environment{
+			Name:             env.Name,
+			AdminByPass:      env.CanAdminsBypass,
+			DeploymentPolicy: env.DeploymentPolicy,
+		}
+		if env.WaitTimer > 0 {
+			environment.ProtectionRules = append(environment.ProtectionRules, data.Rules{Type: "wait_timer", WaitTimer: env.WaitTimer})
+		}
+		if len(env.Reviewers) > 0 {
+			environment.ProtectionRules = append(environment.ProtectionRules, data.Rules{Type: "required_reviewers", PreventSelfReview: env.PreventSelfReview, Reviewers: env.Reviewers})
+		}
+		if env.DeploymentPolicy != nil {
+			environment.ProtectionRules = append(environment.ProtectionRules, data.Rules{Type: "branch_policy"})
+		}
+		response.Environments = append(response.Environments, environment)
+	}
+	return response
+}
+
+func (s *MockGitHubServer) branchPolicies(req *http.Request, env *MockEnvironment, rest []string, body []byte) (*http.Response, error) {
+	switch {
+	case req.Method == "GET":
+		start, end := mockPage(req, len(env.BranchPolicies), 30)
+		return mockResponse(req, http.StatusOK, data.BranchPolicies{TotalCount: len(env.BranchPolicies), BranchPolicies: env.BranchPolicies[start:end]})
+	case req.Method == "POST":
+		var branch data.CreateDeploymentBranch
+		if err := json.Unmarshal(body, &branch); err != nil {
+			return mockResponse(req, http.StatusUnprocessableEntity, map[string]string{"message": err.Error()})
+		}
+		if branch.Type == "" {
+			branch.Type = "branch"
+		}
+		for _, policy := range env.BranchPolicies {
+			if policy.Name == branch.Name && policy.Type == branch.Type {
+				return mockResponse(req, http.StatusUnprocessableEntity, map[string]string{"message": "Name already exists"})
+			}
+		}
+		s.nextID++
+		policy := data.BranchPolicy{ID: s.nextID, Name: branch.Name, Type: branch.Type}
+		env.BranchPolicies = append(env.BranchPolicies, policy)
+		return mockResponse(req, http.StatusOK, policy)
+	case req.Method == "DELETE" && len(rest) == 1:
+		id, _ := strconv.Atoi(rest[0])
+		for i, policy := range env.BranchPolicies {
+			if policy.ID == id {
+				env.BranchPolicies = append(env.BranchPolicies[:i], env.BranchPolicies[i+1:]...)
+				return mockResponse(req, http.StatusNoContent, nil)
+			}
+		}
+	}
+	return mockResponse(req, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+func (s *MockGitHubServer) protectionRules(req *http.Request, env *MockEnvironment, rest []string, body []byte) (*http.Response, error) {
+	switch {
+	case req.Method == "GET" && len(rest) == 1 && rest[0] == "apps":
+		return mockResponse(req, http.StatusOK, data.AvailableDeploymentApps{TotalCount: len(s.Apps), Apps: s.Apps})
+	case req.Method == "GET":
+		return mockResponse(req, http.StatusOK, data.DeploymentProtectionPolicy{TotalCount: len(env.ProtectionRules), CustomDeploymentRules: env.ProtectionRules})
+	case req.Method == "POST":
+		var rule data.CreateDeploymentProtectionRule
+		if err := json.Unmarshal(body, &rule); err != nil {
+			return mockResponse(req, http.StatusUnprocessableEntity, map[string]string{"message": err.Error()})
+		}
+		for _, app := range s.Apps {
+			if app.ID == rule.IntegrationID {
+				s.nextID++
+				enabled := data.DeploymentProtectionPolicyApp{PolicyID: s.nextID, Enabled: true, App: data.DeploymentApp{IntegrationID: app.ID, Slug: app.Slug}}
+				env.ProtectionRules = append(env.ProtectionRules, enabled)
+				return mockResponse(req, http.StatusCreated, enabled)
+			}
+		}
+		return mockResponse(req, http.StatusUnprocessableEntity, map[string]string{"message": "Integration not found"})
+	case req.Method == "DELETE" && len(rest) == 1:
+		id, _ := strconv.Atoi(rest[0])
+		for i, rule := range env.ProtectionRules {
+			if rule.PolicyID == id {
+				env.ProtectionRules = append(env.ProtectionRules[:i], env.ProtectionRules[i+1:]...)
+				return mockResponse(req, http.StatusNoContent, nil)
+			}
+		}
+	}
+	return mockResponse(req, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
+
+func (s *MockGitHubServer) graphql(req *http.Request, body []byte) (*http.Response, error) {
+	var query struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.Unmarshal(body, &query); err != nil {
+		return mockResponse(req, http.StatusBadRequest, map[string]string{"message": err.Error()})
+	}
+
+	repoNode := func(repo *MockRepo) map[string]interface{} {
+		var topics []map[string]interface{}
+		for _, topic := range repo.Topics {
+			topics = append(topics, map[string]interface{}{"topic": map[string]string{"name": topic}})
+		}
+		return map[string]interface{}{
+			"databaseId":       repo.ID,
+			"name":             repo.Name,
+			"visibility":       repo.Visibility,
+			"updatedAt":        repo.UpdatedAt.Format(time.RFC3339),
+			"isArchived":       repo.IsArchived,
+			"isFork":           repo.IsFork,
+			"repositoryTopics": map[string]interface{}{"nodes": topics},
+		}
+	}
+
+	if strings.Contains(query.Query, "enterprise(") {
+		var nodes []map[string]string
+		for _, org := range s.Organizations {
+			nodes = append(nodes, map[string]string{"login": org})
+		}
+		return mockResponse(req, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{
+				"enterprise": map[string]interface{}{
+					"organizations": map[string]interface{}{
+						"nodes":    nodes,
+						"pageInfo": map[string]interface{}{"endCursor": "", "hasNextPage": false},
+					},
+				},
+			},
+		})
+	}
+
+	if strings.Contains(query.Query, "repository(") {
+		name := fmt.Sprint(query.Variables["name"])
+		repo, ok := s.Repos[name]
+		if !ok {
+			return mockResponse(req, http.StatusOK, map[string]interface{}{
+				"data":   map[string]interface{}{"repository": nil},
+				"errors": []map[string]string{{"message": fmt.Sprintf("Could not resolve to a Repository with the name '%s'.", name)}},
+			})
+		}
+		return mockResponse(req, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"repository": repoNode(repo)}})
+	}
+
+	var names []string
+	for name := range s.Repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// Apply the filters the organization repositories connection supports
+	var nodes []map[string]interface{}
+	for _, name := range names {
+		repo := s.Repos[name]
+		if isFork, ok := query.Variables["isFork"].(bool); ok && repo.IsFork != isFork {
+			continue
+		}
+		if isArchived, ok := query.Variables["isArchived"].(bool); ok && repo.IsArchived != isArchived {
+			continue
+		}
+		if privacy, ok := query.Variables["privacy"].(string); ok {
+			// Internal repositories are returned for the PRIVATE privacy filter
+			if (privacy == "PUBLIC") != (repo.Visibility == "PUBLIC") {
+				continue
+			}
+		}
+		nodes = append(nodes, repoNode(repo))
+	}
+	return mockResponse(req, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"organization": map[string]interface{}{
+				"repositories": map[string]interface{}{
+					"totalCount": len(nodes),
+					"nodes":      nodes,
+					"pageInfo":   map[string]interface{}{"endCursor": "", "hasNextPage": false},
+				},
+			},
+		},
+	})
+}
+
+func (s *MockGitHubServer) propertyValuesResponse(owner string) []data.RepoCustomPropertyValues {
+	var names []string
+	for name := range s.Repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := []data.RepoCustomPropertyValues{}
+	for _, name := range names {
+		repo := s.Repos[name]
+		repoValues := data.RepoCustomPropertyValues{
+			RepositoryID:       repo.ID,
+			RepositoryName:     repo.Name,
+			RepositoryFullName: owner + "/" + repo.Name,
+			Properties:         []data.CustomPropertyValue{},
+		}
+		var propertyNames []string
+		for propertyName := range repo.Properties {
+			propertyNames = append(propertyNames, propertyName)
+		}
+		sort.Strings(propertyNames)
+		for _, propertyName := range propertyNames {
+			repoValues.Properties = append(repoValues.Properties, data.CustomPropertyValue{PropertyName: propertyName, Value: repo.Properties[propertyName]})
+		}
+		values = append(values, repoValues)
+	}
+	return values
+}
+
+// mockPage returns the bounds of the page of count items requested by the
+// page and per_page parameters of req, with perPage items when per_page is
+// not given, as the API does.
+func mockPage(req *http.Request, count int, perPage int) (int, int) {
+	query := req.URL.Query()
+	page := 1
+	if p, err := strconv.Atoi(query.Get("page")); err == nil {
+		page = p
+	}
+	if p, err := strconv.Atoi(query.Get("per_page")); err == nil {
+		perPage = p
+	}
+	start := min((page-1)*perPage, count)
+	end := min(start+perPage, count)
+	return start, end
+}
+
+func mockResponse(req *http.Request, status int, payload interface{}) (*http.Response, error) {
+	var body []byte
+	if payload != nil {
+		var err error
+		body, err = json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
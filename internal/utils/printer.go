@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+)
+
+// Printer writes formatted output until a write fails and keeps the first
+// error, so a run of writes can be checked once at the end.
+type Printer struct {
+	out io.Writer
+	err error
+}
+
+func NewPrinter(out io.Writer) *Printer {
+	return &Printer{out: out}
+}
+
+// Printf writes to the output unless an earlier write failed.
+func (p *Printer) Printf(format string, args ...interface{}) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.out, format, args...)
+	}
+}
+
+// Println writes a line to the output unless an earlier write failed.
+func (p *Printer) Println(args ...interface{}) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintln(p.out, args...)
+	}
+}
+
+// Err returns the first write error.
+func (p *Printer) Err() error {
+	return p.err
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("disk full")
+}
+
+func TestPrinter(t *testing.T) {
+	var out bytes.Buffer
+	p := NewPrinter(&out)
+	p.Printf("%d environment(s)\n", 2)
+	p.Println("done")
+	if err := p.Err(); err != nil || out.String() != "2 environment(s)\ndone\n" {
+		t.Errorf("Unexpected output %q, error %v", out.String(), err)
+	}
+
+	failing := &failingWriter{}
+	p = NewPrinter(failing)
+	p.Printf("first\n")
+	p.Println("second")
+	if err := p.Err(); err == nil || err.Error() != "disk full" {
+		t.Errorf("Expected the first write error, got %v", err)
+	}
+	if failing.writes != 1 {
+		t.Errorf("Expected writes to stop after the first failure, got %d", failing.writes)
+	}
+}
@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"go.uber.org/zap"
+)
+
+const propertiesPerPage = 100
+
+// PropertySelector matches repositories whose custom property Name has Value.
+type PropertySelector struct {
+	Name  string
+	Value string
+}
+
+// ParsePropertySelectors parses --property values in the form key=value.
+func ParsePropertySelectors(values []string) ([]PropertySelector, error) {
+	var selectors []PropertySelector
+	for _, value := range values {
+		name, propertyValue, ok := strings.Cut(value, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --property %q, expected key=value", value)
+		}
+		selectors = append(selectors, PropertySelector{Name: name, Value: strings.TrimSpace(propertyValue)})
+	}
+	return selectors, nil
+}
+
+// PropertyNames returns the distinct property names used by selectors, in the
+// order they were first given.
+func PropertyNames(selectors []PropertySelector) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, selector := range selectors {
+		if !seen[selector.Name] {
+			seen[selector.Name] = true
+			names = append(names, selector.Name)
+		}
+	}
+	return names
+}
+
+// RepoProperties holds custom property values keyed by repository name and
+// then property name. Multi-select values are joined with "|".
+type RepoProperties map[string]map[string]string
+
+// Values returns the values of names for repo, in order.
+func (p RepoProperties) Values(repo string, names []string) []string {
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = p[repo][name]
+	}
+	return values
+}
+
+// MatchProperties reports whether values satisfy selectors. Selectors for the
+// same property match if any of them does; different properties must all
+// match. Multi-select properties match when any selected value does.
+func MatchProperties(values map[string]string, selectors []PropertySelector) bool {
+	matched := make(map[string]bool)
+	for _, selector := range selectors {
+		if matched[selector.Name] {
+			continue
+		}
+		matched[selector.Name] = false
+		value, ok := values[selector.Name]
+		if !ok {
+			continue
+		}
+		for _, v := range strings.Split(value, "|") {
+			if strings.EqualFold(v, selector.Value) {
+				matched[selector.Name] = true
+				break
+			}
+		}
+	}
+	for _, ok := range matched {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func propertyValueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return strings.Join(values, "|")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (g *APIGetter) GetOrgPropertyValues(ctx context.Context, owner string, page int) ([]byte, error) {
+	url := fmt.Sprintf("orgs/%s/properties/values?per_page=%d&page=%d", owner, propertiesPerPage, page)
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP error for URL %s: %v", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Error closing response body: %v", closeErr)
+		}
+	}()
+	responseData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body from URL %s: %w", url, err)
+	}
+	return responseData, nil
+}
+
+type propertyGetter interface {
+	GetOrgPropertyValues(ctx context.Context, owner string, page int) ([]byte, error)
+}
+
+// GatherRepoProperties fetches the custom property values of every repository
+// in the organization.
+func GatherRepoProperties(ctx context.Context, g propertyGetter, owner string) (RepoProperties, error) {
+	properties := make(RepoProperties)
+	for page := 1; ; page++ {
+		zap.S().Debugf("Gathering custom property values for %s, page %d", owner, page)
+		resp, err := g.GetOrgPropertyValues(ctx, owner, page)
+		if err != nil {
+			return nil, err
+		}
+		var repos []data.RepoCustomPropertyValues
+		if err := json.Unmarshal(resp, &repos); err != nil {
+			return nil, fmt.Errorf("parsing custom property values: %w", err)
+		}
+		for _, repo := range repos {
+			values := make(map[string]string)
+			for _, property := range repo.Properties {
+				values[property.PropertyName] = propertyValueString(property.Value)
+			}
+			properties[repo.RepositoryName] = values
+		}
+		if len(repos) < propertiesPerPage {
+			return properties, nil
+		}
+	}
+}
+
+// SelectReposByProperties keeps the repositories whose custom properties match
+// selectors and returns the property values for the report. With no selectors
+// repos is returned unchanged and no API calls are made.
+func SelectReposByProperties(ctx context.Context, g propertyGetter, owner string, repos []data.RepoInfo, selectors []PropertySelector) ([]data.RepoInfo, RepoProperties, error) {
+	if len(selectors) == 0 {
+		return repos, nil, nil
+	}
+	properties, err := GatherRepoProperties(ctx, g, owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	var matched []data.RepoInfo
+	for _, repo := range repos {
+		if MatchProperties(properties[repo.Name], selectors) {
+			matched = append(matched, repo)
+		} else {
+			zap.S().Debugf("Skipping %s as it does not match the property selectors", repo.Name)
+		}
+	}
+	progress.FromContext(ctx).AddRepositories(len(matched) - len(repos))
+	return matched, properties, nil
+}
@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+type fakePropertyGetter struct {
+	pages [][]data.RepoCustomPropertyValues
+	calls int
+	err   error
+}
+
+func (f *fakePropertyGetter) GetOrgPropertyValues(ctx context.Context, owner string, page int) ([]byte, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	if page > len(f.pages) {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(f.pages[page-1])
+}
+
+func TestParsePropertySelectors(t *testing.T) {
+	selectors, err := ParsePropertySelectors([]string{"team=payments", " tier = 1 ", "team=core", "empty="})
+	if err != nil {
+		t.Fatalf("ParsePropertySelectors() error = %v", err)
+	}
+	want := []PropertySelector{{"team", "payments"}, {"tier", "1"}, {"team", "core"}, {"empty", ""}}
+	if !reflect.DeepEqual(selectors, want) {
+		t.Errorf("ParsePropertySelectors() = %v, want %v", selectors, want)
+	}
+	if names := PropertyNames(selectors); !reflect.DeepEqual(names, []string{"team", "tier", "empty"}) {
+		t.Errorf("PropertyNames() = %v", names)
+	}
+
+	for _, value := range []string{"team", "=payments"} {
+		if _, err := ParsePropertySelectors([]string{value}); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
+
+func TestMatchProperties(t *testing.T) {
+	values := map[string]string{"team": "payments", "tier": "1", "regions": "eu|us"}
+	tests := []struct {
+		name      string
+		selectors []PropertySelector
+		want      bool
+	}{
+		{"single match", []PropertySelector{{"team", "Payments"}}, true},
+		{"single mismatch", []PropertySelector{{"team", "core"}}, false},
+		{"same key any", []PropertySelector{{"team", "core"}, {"team", "payments"}}, true},
+		{"different keys all", []PropertySelector{{"team", "payments"}, {"tier", "2"}}, false},
+		{"multi select", []PropertySelector{{"regions", "us"}}, true},
+		{"missing property", []PropertySelector{{"owner", "x"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchProperties(values, tt.selectors); got != tt.want {
+				t.Errorf("MatchProperties() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGatherRepoProperties(t *testing.T) {
+	ctx := context.Background()
+	var firstPage []data.RepoCustomPropertyValues
+	for i := 0; i < propertiesPerPage; i++ {
+		firstPage = append(firstPage, data.RepoCustomPropertyValues{RepositoryName: fmt.Sprintf("repo%d", i)})
+	}
+	getter := &fakePropertyGetter{pages: [][]data.RepoCustomPropertyValues{
+		firstPage,
+		{{
+			RepositoryName: "app",
+			Properties: []data.CustomPropertyValue{
+				{PropertyName: "team", Value: "payments"},
+				{PropertyName: "regions", Value: []interface{}{"eu", "us"}},
+				{PropertyName: "unset", Value: nil},
+			},
+		}},
+	}}
+
+	properties, err := GatherRepoProperties(ctx, getter, "testorg")
+	if err != nil {
+		t.Fatalf("GatherRepoProperties() error = %v", err)
+	}
+	if getter.calls != 2 {
+		t.Errorf("Expected 2 pages to be requested, got %d", getter.calls)
+	}
+	if len(properties) != propertiesPerPage+1 {
+		t.Errorf("Expected %d repositories, got %d", propertiesPerPage+1, len(properties))
+	}
+	values := properties.Values("app", []string{"team", "regions", "unset", "missing"})
+	if !reflect.DeepEqual(values, []string{"payments", "eu|us", "", ""}) {
+		t.Errorf("Unexpected values %v", values)
+	}
+
+	if _, err := GatherRepoProperties(ctx, &fakePropertyGetter{err: errors.New("boom")}, "testorg"); err == nil {
+		t.Error("Expected error to be returned")
+	}
+}
+
+func TestSelectReposByProperties(t *testing.T) {
+	ctx := context.Background()
+	repos := []data.RepoInfo{{Name: "app"}, {Name: "api"}}
+
+	getter := &fakePropertyGetter{}
+	selected, properties, err := SelectReposByProperties(ctx, getter, "testorg", repos, nil)
+	if err != nil || len(selected) != 2 || properties != nil || getter.calls != 0 {
+		t.Errorf("Expected repos unchanged without selectors, got %v %v %v", selected, properties, err)
+	}
+
+	getter.pages = [][]data.RepoCustomPropertyValues{{
+		{RepositoryName: "app", Properties: []data.CustomPropertyValue{{PropertyName: "team", Value: "payments"}}},
+		{RepositoryName: "api", Properties: []data.CustomPropertyValue{{PropertyName: "team", Value: "core"}}},
+	}}
+	selected, properties, err = SelectReposByProperties(ctx, getter, "testorg", repos, []PropertySelector{{"team", "payments"}})
+	if err != nil {
+		t.Fatalf("SelectReposByProperties() error = %v", err)
+	}
+	if len(selected) != 1 || selected[0].Name != "app" {
+		t.Errorf("Expected only app to be selected, got %v", selected)
+	}
+	if properties["api"]["team"] != "core" {
+		t.Errorf("Expected property values for all repositories, got %v", properties)
+	}
+}
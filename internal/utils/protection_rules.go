@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+func (g *APIGetter) GetAvailableDeploymentApps(ctx context.Context, owner string, repo string, env string) ([]byte, error) {
+	url := fmt.Sprintf("repos/%s/%s/environments/%s/deployment_protection_rules/apps", owner, repo, env)
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP error for URL %s: %v", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Error closing response body: %v", closeErr)
+		}
+	}()
+	responseData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body from URL %s: %w", url, err)
+	}
+	return responseData, nil
+}
+
+func (g *APIGetter) CreateDeploymentProtectionRule(ctx context.Context, owner string, repo string, env string, data io.Reader) error {
+	url := fmt.Sprintf("repos/%s/%s/environments/%s/deployment_protection_rules", owner, repo, env)
+
+	resp, err := g.restClient.RequestWithContext(ctx, "POST", url, data)
+	if err != nil {
+		return fmt.Errorf("HTTP error for URL %s: %v", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Error closing response body: %v", closeErr)
+		}
+	}()
+	return nil
+}
+
+func (g *APIGetter) DeleteDeploymentProtectionRule(ctx context.Context, owner string, repo string, env string, ruleID int) error {
+	url := fmt.Sprintf("repos/%s/%s/environments/%s/deployment_protection_rules/%d", owner, repo, env, ruleID)
+
+	resp, err := g.restClient.RequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("HTTP error for URL %s: %v", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Error closing response body: %v", closeErr)
+		}
+	}()
+	return nil
+}
+
+// ProtectionRuleChanges describes how the custom deployment protection rules
+// enabled on an environment differ from the rules listed in an import file.
+type ProtectionRuleChanges struct {
+	Add         []data.AvailableDeploymentApp
+	Remove      []data.DeploymentProtectionPolicyApp
+	Unchanged   []data.DeploymentProtectionPolicyApp
+	Unavailable []data.DeploymentProtectionPolicyApp
+}
+
+// DiffProtectionRules compares the enabled rules of an environment with the
+// desired rules. Apps are matched by slug, falling back to the app ID when no
+// slug is given, since app IDs differ between GitHub instances. Desired rules
+// that are disabled are ignored, and apps to add are resolved against the apps
+// available to the environment.
+func DiffProtectionRules(existing []data.DeploymentProtectionPolicyApp, desired []data.DeploymentProtectionPolicyApp, available []data.AvailableDeploymentApp) ProtectionRuleChanges {
+	var changes ProtectionRuleChanges
+
+	matches := func(rule data.DeploymentProtectionPolicyApp, slug string, id int) bool {
+		if rule.App.Slug != "" {
+			return rule.App.Slug == slug
+		}
+		return rule.App.IntegrationID == id
+	}
+
+	var wanted []data.DeploymentProtectionPolicyApp
+	for _, rule := range desired {
+		if rule.Enabled {
+			wanted = append(wanted, rule)
+		}
+	}
+
+	for _, rule := range existing {
+		keep := false
+		for _, want := range wanted {
+			if matches(want, rule.App.Slug, rule.App.IntegrationID) {
+				keep = true
+				break
+			}
+		}
+		if keep {
+			changes.Unchanged = append(changes.Unchanged, rule)
+		} else {
+			changes.Remove = append(changes.Remove, rule)
+		}
+	}
+
+	for _, want := range wanted {
+		enabled := false
+		for _, rule := range existing {
+			if matches(want, rule.App.Slug, rule.App.IntegrationID) {
+				enabled = true
+				break
+			}
+		}
+		if enabled {
+			continue
+		}
+
+		resolved := false
+		for _, app := range available {
+			if matches(want, app.Slug, app.ID) {
+				duplicate := false
+				for _, added := range changes.Add {
+					if added.ID == app.ID {
+						duplicate = true
+						break
+					}
+				}
+				if !duplicate {
+					changes.Add = append(changes.Add, app)
+				}
+				resolved = true
+				break
+			}
+		}
+		if !resolved {
+			changes.Unavailable = append(changes.Unavailable, want)
+		}
+	}
+	return changes
+}
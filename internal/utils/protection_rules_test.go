@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestGetAvailableDeploymentApps(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	zap.ReplaceGlobals(logger)
+
+	// Setup mock REST client
+	mockResponse := `{"total_count": 1, "available_custom_deployment_protection_rule_integrations": [{"id": 3, "slug": "deploy-gate", "node_id": "abc"}]}`
+	mockClient := &mockRESTClient{
+		RequestFunc: func(method string, path string, body io.Reader) (*http.Response, error) {
+			expectedPath := "repos/testorg/testrepo/environments/production/deployment_protection_rules/apps"
+			if path != expectedPath {
+				t.Errorf("Expected path %s, got %s", expectedPath, path)
+			}
+			return &http.Response{
+				StatusCode: 200,
+				Body:       io.NopCloser(strings.NewReader(mockResponse)),
+			}, nil
+		},
+	}
+
+	getter := newAPIGetterWithMockREST(mockClient)
+
+	result, err := getter.GetAvailableDeploymentApps(ctx, "testorg", "testrepo", "production")
+	if err != nil {
+		t.Errorf("GetAvailableDeploymentApps() error = %v", err)
+	}
+
+	var apps data.AvailableDeploymentApps
+	if err = json.Unmarshal(result, &apps); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if len(apps.Apps) != 1 || apps.Apps[0].Slug != "deploy-gate" || apps.Apps[0].ID != 3 {
+		t.Errorf("Expected deploy-gate app with ID 3, got %v", apps.Apps)
+	}
+}
+
+func TestCreateDeploymentProtectionRule(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	zap.ReplaceGlobals(logger)
+
+	// Setup mock REST client
+	mockClient := &mockRESTClient{
+		RequestFunc: func(method string, path string, body io.Reader) (*http.Response, error) {
+			if method != "POST" {
+				t.Errorf("Expected method POST, got %s", method)
+			}
+
+			var rule data.CreateDeploymentProtectionRule
+			if err := json.NewDecoder(body).Decode(&rule); err != nil {
+				t.Errorf("Failed to decode request body: %v", err)
+			}
+			if rule.IntegrationID != 3 {
+				t.Errorf("Expected integration_id 3, got %d", rule.IntegrationID)
+			}
+
+			return &http.Response{
+				StatusCode: 201,
+				Body:       io.NopCloser(strings.NewReader(`{}`)),
+			}, nil
+		},
+	}
+
+	getter := newAPIGetterWithMockREST(mockClient)
+
+	body, _ := json.Marshal(data.CreateDeploymentProtectionRule{IntegrationID: 3})
+	err := getter.CreateDeploymentProtectionRule(ctx, "testorg", "testrepo", "production", strings.NewReader(string(body)))
+	if err != nil {
+		t.Errorf("CreateDeploymentProtectionRule() error = %v", err)
+	}
+}
+
+func TestDeleteDeploymentProtectionRule(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	zap.ReplaceGlobals(logger)
+
+	// Setup mock REST client
+	mockClient := &mockRESTClient{
+		RequestFunc: func(method string, path string, body io.Reader) (*http.Response, error) {
+			if method != "DELETE" {
+				t.Errorf("Expected method DELETE, got %s", method)
+			}
+
+			expectedPath := "repos/testorg/testrepo/environments/production/deployment_protection_rules/42"
+			if path != expectedPath {
+				t.Errorf("Expected path %s, got %s", expectedPath, path)
+			}
+
+			return &http.Response{
+				StatusCode: 204,
+				Body:       io.NopCloser(strings.NewReader("")),
+			}, nil
+		},
+	}
+
+	getter := newAPIGetterWithMockREST(mockClient)
+
+	if err := getter.DeleteDeploymentProtectionRule(ctx, "testorg", "testrepo", "production", 42); err != nil {
+		t.Errorf("DeleteDeploymentProtectionRule() error = %v", err)
+	}
+}
+
+func TestDiffProtectionRules(t *testing.T) {
+	existing := []data.DeploymentProtectionPolicyApp{
+		{PolicyID: 10, Enabled: true, App: data.DeploymentApp{IntegrationID: 1, Slug: "deploy-gate"}},
+		{PolicyID: 11, Enabled: true, App: data.DeploymentApp{IntegrationID: 2, Slug: "old-gate"}},
+	}
+	desired := []data.DeploymentProtectionPolicyApp{
+		{Enabled: true, App: data.DeploymentApp{IntegrationID: 99, Slug: "deploy-gate"}},
+		{Enabled: true, App: data.DeploymentApp{IntegrationID: 5, Slug: "security-gate"}},
+		{Enabled: true, App: data.DeploymentApp{IntegrationID: 6}},
+		{Enabled: true, App: data.DeploymentApp{Slug: "missing-gate"}},
+		{Enabled: false, App: data.DeploymentApp{Slug: "disabled-gate"}},
+	}
+	available := []data.AvailableDeploymentApp{
+		{ID: 1, Slug: "deploy-gate"},
+		{ID: 7, Slug: "security-gate"},
+		{ID: 6, Slug: "id-gate"},
+		{ID: 8, Slug: "disabled-gate"},
+	}
+
+	changes := DiffProtectionRules(existing, desired, available)
+
+	if len(changes.Unchanged) != 1 || changes.Unchanged[0].PolicyID != 10 {
+		t.Errorf("Expected deploy-gate to be matched by slug and unchanged, got %v", changes.Unchanged)
+	}
+
+	if len(changes.Remove) != 1 || changes.Remove[0].PolicyID != 11 {
+		t.Errorf("Expected old-gate to be removed, got %v", changes.Remove)
+	}
+
+	if len(changes.Add) != 2 || changes.Add[0].ID != 7 || changes.Add[1].ID != 6 {
+		t.Errorf("Expected security-gate resolved to ID 7 and id-gate by ID, got %v", changes.Add)
+	}
+
+	if len(changes.Unavailable) != 1 || changes.Unavailable[0].App.Slug != "missing-gate" {
+		t.Errorf("Expected missing-gate to be unavailable, got %v", changes.Unavailable)
+	}
+}
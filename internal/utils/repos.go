@@ -0,0 +1,297 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/exitcode"
+	"github.com/katiem0/gh-environments/internal/githubapp"
+	"github.com/katiem0/gh-environments/internal/progress"
+	"github.com/shurcooL/graphql"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+// RepoFilter narrows the repositories scanned by the list commands. The zero
+// value matches every repository.
+type RepoFilter struct {
+	// Visibility is one of public, private or internal
+	Visibility      string
+	ExcludeArchived bool
+	ExcludeForks    bool
+	// Topics match when a repository has any of them
+	Topics       []string
+	NameRegex    *regexp.Regexp
+	UpdatedSince time.Time
+	// Properties are checked by SelectReposByProperties, not Match, as they
+	// need a separate API call
+	Properties []PropertySelector
+}
+
+// Match reports whether repo passes every filter. It is applied client-side
+// to all repositories, including those already filtered by the API.
+func (f RepoFilter) Match(repo data.RepoInfo) bool {
+	if f.Visibility != "" && !strings.EqualFold(repo.Visibility, f.Visibility) {
+		return false
+	}
+	if f.ExcludeArchived && repo.IsArchived {
+		return false
+	}
+	if f.ExcludeForks && repo.IsFork {
+		return false
+	}
+	if len(f.Topics) > 0 && !repoHasTopic(repo, f.Topics) {
+		return false
+	}
+	if f.NameRegex != nil && !f.NameRegex.MatchString(repo.Name) {
+		return false
+	}
+	if !f.UpdatedSince.IsZero() && repo.UpdatedAt.Before(f.UpdatedSince) {
+		return false
+	}
+	return true
+}
+
+func repoHasTopic(repo data.RepoInfo, topics []string) bool {
+	for _, node := range repo.RepositoryTopics.Nodes {
+		for _, topic := range topics {
+			if strings.EqualFold(node.Topic.Name, topic) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// queryVariables maps the filters GraphQL supports onto the organization
+// repositories query. Internal repositories are returned by the PRIVATE
+// privacy filter and narrowed by Match.
+func (f RepoFilter) queryVariables() map[string]interface{} {
+	var isFork, isArchived *graphql.Boolean
+	var privacy *data.RepositoryPrivacy
+
+	if f.ExcludeForks {
+		isFork = graphql.NewBoolean(false)
+	}
+	if f.ExcludeArchived {
+		isArchived = graphql.NewBoolean(false)
+	}
+	switch strings.ToLower(f.Visibility) {
+	case "public":
+		p := data.RepositoryPrivacy("PUBLIC")
+		privacy = &p
+	case "private", "internal":
+		p := data.RepositoryPrivacy("PRIVATE")
+		privacy = &p
+	}
+
+	return map[string]interface{}{
+		"isFork":     isFork,
+		"isArchived": isArchived,
+		"privacy":    privacy,
+	}
+}
+
+func (g *APIGetter) GetFilteredReposList(ctx context.Context, owner string, endCursor *string, filter RepoFilter) (*data.ReposQuery, error) {
+	query := new(data.ReposQuery)
+	variables := filter.queryVariables()
+	variables["endCursor"] = (*graphql.String)(endCursor)
+	variables["owner"] = graphql.String(owner)
+
+	err := g.gqlClient.QueryWithContext(githubapp.WithOwner(ctx, owner), "getRepos", &query, variables)
+
+	return query, err
+}
+
+// RepoFilterFlags holds the raw values of the repository filter flags shared
+// by the list commands.
+type RepoFilterFlags struct {
+	Visibility      string
+	IncludeArchived bool
+	ExcludeArchived bool
+	ExcludeForks    bool
+	Topics          []string
+	NameRegex       string
+	UpdatedSince    string
+	ReposFile       string
+	Properties      []string
+}
+
+func (r *RepoFilterFlags) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&r.Visibility, "visibility", "", "Only include repositories with this visibility: public, private or internal")
+	flags.BoolVar(&r.IncludeArchived, "include-archived", false, "Include archived repositories (default behaviour)")
+	flags.BoolVar(&r.ExcludeArchived, "exclude-archived", false, "Exclude archived repositories")
+	flags.BoolVar(&r.ExcludeForks, "exclude-forks", false, "Exclude forked repositories")
+	flags.StringSliceVar(&r.Topics, "topic", nil, "Only include repositories with any of these topics")
+	flags.StringVar(&r.NameRegex, "name-regex", "", "Only include repositories whose name matches this regular expression")
+	flags.StringVar(&r.UpdatedSince, "updated-since", "", "Only include repositories updated on or after this date (YYYY-MM-DD or RFC3339)")
+	flags.StringVar(&r.ReposFile, "repos-file", "", "File listing repository names to process, one per line")
+	flags.StringArrayVar(&r.Properties, "property", nil, "Only include repositories with this custom property value, as key=value (repeatable)")
+}
+
+// Filter validates the flag values and builds the matching RepoFilter. Invalid
+// values are validation failures.
+func (r *RepoFilterFlags) Filter() (RepoFilter, error) {
+	filter, err := r.filter()
+	return filter, exitcode.New(exitcode.ValidationFailure, err)
+}
+
+func (r *RepoFilterFlags) filter() (RepoFilter, error) {
+	var filter RepoFilter
+
+	if r.IncludeArchived && r.ExcludeArchived {
+		return filter, fmt.Errorf("--include-archived and --exclude-archived cannot be used together")
+	}
+	filter.ExcludeArchived = r.ExcludeArchived
+	filter.ExcludeForks = r.ExcludeForks
+
+	switch strings.ToLower(r.Visibility) {
+	case "", "public", "private", "internal":
+		filter.Visibility = strings.ToLower(r.Visibility)
+	default:
+		return filter, fmt.Errorf("invalid visibility %q, expected public, private or internal", r.Visibility)
+	}
+
+	for _, topic := range r.Topics {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			filter.Topics = append(filter.Topics, topic)
+		}
+	}
+
+	if r.NameRegex != "" {
+		re, err := regexp.Compile(r.NameRegex)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --name-regex: %w", err)
+		}
+		filter.NameRegex = re
+	}
+
+	if r.UpdatedSince != "" {
+		since, err := parseSince(r.UpdatedSince)
+		if err != nil {
+			return filter, err
+		}
+		filter.UpdatedSince = since
+	}
+
+	selectors, err := ParsePropertySelectors(r.Properties)
+	if err != nil {
+		return filter, err
+	}
+	filter.Properties = selectors
+	return filter, nil
+}
+
+func parseSince(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return t, fmt.Errorf("invalid --updated-since %q, expected YYYY-MM-DD or RFC3339", value)
+	}
+	return t, nil
+}
+
+// Repos combines repositories given as arguments with any listed in
+// --repos-file.
+func (r *RepoFilterFlags) Repos(args []string) ([]string, error) {
+	repos := append([]string{}, args...)
+	if r.ReposFile == "" {
+		return repos, nil
+	}
+	fileRepos, err := ReadReposFile(r.ReposFile)
+	if err != nil {
+		return nil, err
+	}
+	return append(repos, fileRepos...), nil
+}
+
+// ReadReposFile reads repository names one per line, ignoring blank lines and
+// lines starting with #. Names given as owner/repo are reduced to the repo.
+func ReadReposFile(fileName string) ([]string, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil {
+			zap.S().Warnf("Error closing file: %v", closeErr)
+		}
+	}()
+
+	var repos []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.LastIndex(line, "/"); i >= 0 {
+			line = line[i+1:]
+		}
+		repos = append(repos, line)
+	}
+	return repos, scanner.Err()
+}
+
+type repoGetter interface {
+	GetRepo(ctx context.Context, owner string, name string) (*data.RepoSingleQuery, error)
+	GetFilteredReposList(ctx context.Context, owner string, endCursor *string, filter RepoFilter) (*data.ReposQuery, error)
+}
+
+// GatherRepos returns the named repositories, or every repository in the
+// organization when none are named, keeping only those matching filter.
+func GatherRepos(ctx context.Context, g repoGetter, owner string, repos []string, filter RepoFilter) ([]data.RepoInfo, error) {
+	var allRepos []data.RepoInfo
+	tracker := progress.FromContext(ctx)
+
+	if len(repos) > 0 {
+		zap.S().Infof("Processing repos: %s", repos)
+		tracker.AddRepositories(len(repos))
+		for _, repo := range repos {
+			zap.S().Debugf("Processing %s/%s", owner, repo)
+			repoQuery, err := g.GetRepo(ctx, owner, repo)
+			if err != nil {
+				return allRepos, err
+			}
+			allRepos = append(allRepos, repoQuery.Repository)
+		}
+	} else {
+		var reposCursor *string
+		for {
+			zap.S().Debugf("Processing list of repositories for %s", owner)
+			reposQuery, err := g.GetFilteredReposList(ctx, owner, reposCursor, filter)
+			if err != nil {
+				return allRepos, err
+			}
+			if reposCursor == nil {
+				tracker.AddRepositories(reposQuery.Organization.Repositories.TotalCount)
+			}
+			allRepos = append(allRepos, reposQuery.Organization.Repositories.Nodes...)
+			reposCursor = &reposQuery.Organization.Repositories.PageInfo.EndCursor
+			if !reposQuery.Organization.Repositories.PageInfo.HasNextPage {
+				break
+			}
+		}
+	}
+
+	var matched []data.RepoInfo
+	for _, repo := range allRepos {
+		if filter.Match(repo) {
+			matched = append(matched, repo)
+		} else {
+			zap.S().Debugf("Skipping %s as it does not match the repository filters", repo.Name)
+		}
+	}
+	// Repositories counted from TotalCount that are filtered out are not
+	// processed
+	tracker.AddRepositories(len(matched) - len(allRepos))
+	return matched, nil
+}
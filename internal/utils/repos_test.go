@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+func repoWithTopics(name string, topics ...string) data.RepoInfo {
+	repo := data.RepoInfo{Name: name, Visibility: "PRIVATE"}
+	for _, topic := range topics {
+		node := struct {
+			Topic struct {
+				Name string `json:"name"`
+			} `json:"topic"`
+		}{}
+		node.Topic.Name = topic
+		repo.RepositoryTopics.Nodes = append(repo.RepositoryTopics.Nodes, node)
+	}
+	return repo
+}
+
+func TestRepoFilterMatch(t *testing.T) {
+	since := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		filter RepoFilter
+		repo   data.RepoInfo
+		want   bool
+	}{
+		{"zero filter", RepoFilter{}, data.RepoInfo{Name: "app", IsArchived: true, IsFork: true}, true},
+		{"visibility match", RepoFilter{Visibility: "internal"}, data.RepoInfo{Visibility: "INTERNAL"}, true},
+		{"visibility mismatch", RepoFilter{Visibility: "internal"}, data.RepoInfo{Visibility: "PRIVATE"}, false},
+		{"exclude archived", RepoFilter{ExcludeArchived: true}, data.RepoInfo{IsArchived: true}, false},
+		{"exclude forks", RepoFilter{ExcludeForks: true}, data.RepoInfo{IsFork: true}, false},
+		{"topic match", RepoFilter{Topics: []string{"ops", "Deploy"}}, repoWithTopics("app", "deploy"), true},
+		{"topic mismatch", RepoFilter{Topics: []string{"ops"}}, repoWithTopics("app", "deploy"), false},
+		{"name regex match", RepoFilter{NameRegex: regexp.MustCompile("^svc-")}, data.RepoInfo{Name: "svc-api"}, true},
+		{"name regex mismatch", RepoFilter{NameRegex: regexp.MustCompile("^svc-")}, data.RepoInfo{Name: "web"}, false},
+		{"updated after", RepoFilter{UpdatedSince: since}, data.RepoInfo{UpdatedAt: since.Add(time.Hour)}, true},
+		{"updated before", RepoFilter{UpdatedSince: since}, data.RepoInfo{UpdatedAt: since.Add(-time.Hour)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(tt.repo); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepoFilterFlags(t *testing.T) {
+	flags := RepoFilterFlags{
+		Visibility:      "Public",
+		ExcludeArchived: true,
+		Topics:          []string{" ops ", ""},
+		NameRegex:       "^svc-",
+		UpdatedSince:    "2024-06-01",
+	}
+	filter, err := flags.Filter()
+	if err != nil {
+		t.Fatalf("Filter() error = %v", err)
+	}
+	if filter.Visibility != "public" || !filter.ExcludeArchived || filter.ExcludeForks {
+		t.Errorf("Unexpected filter %+v", filter)
+	}
+	if !reflect.DeepEqual(filter.Topics, []string{"ops"}) {
+		t.Errorf("Expected topics [ops], got %v", filter.Topics)
+	}
+	if filter.NameRegex == nil || !filter.NameRegex.MatchString("svc-api") {
+		t.Error("Expected name regex to be compiled")
+	}
+	if !filter.UpdatedSince.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected updated since %v", filter.UpdatedSince)
+	}
+
+	invalid := []RepoFilterFlags{
+		{IncludeArchived: true, ExcludeArchived: true},
+		{Visibility: "secret"},
+		{NameRegex: "("},
+		{UpdatedSince: "yesterday"},
+	}
+	for _, flags := range invalid {
+		if _, err := flags.Filter(); err == nil {
+			t.Errorf("Expected error for %+v", flags)
+		}
+	}
+}
+
+func TestReadReposFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "repos.txt")
+	content := "# services\napp\n\n  testorg/api  \n#web\n"
+	if err := os.WriteFile(fileName, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	flags := RepoFilterFlags{ReposFile: fileName}
+	repos, err := flags.Repos([]string{"cli"})
+	if err != nil {
+		t.Fatalf("Repos() error = %v", err)
+	}
+	if !reflect.DeepEqual(repos, []string{"cli", "app", "api"}) {
+		t.Errorf("Expected [cli app api], got %v", repos)
+	}
+
+	flags.ReposFile = filepath.Join(t.TempDir(), "missing.txt")
+	if _, err := flags.Repos(nil); err == nil {
+		t.Error("Expected error for missing repos file")
+	}
+}
+
+func TestGatherRepos(t *testing.T) {
+	ctx := context.Background()
+	server := NewMockGitHubServer()
+	server.AddRepo(1, "svc-api").Topics = []string{"deploy"}
+	server.AddRepo(2, "svc-archived").IsArchived = true
+	server.AddRepo(3, "svc-fork").IsFork = true
+	server.AddRepo(4, "web").Visibility = "PUBLIC"
+	server.AddRepo(5, "svc-internal").Visibility = "INTERNAL"
+
+	g := newMockServerGetter(t, server)
+
+	names := func(repos []data.RepoInfo) []string {
+		var result []string
+		for _, repo := range repos {
+			result = append(result, repo.Name)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name   string
+		repos  []string
+		filter RepoFilter
+		want   []string
+	}{
+		{"all", nil, RepoFilter{}, []string{"svc-api", "svc-archived", "svc-fork", "svc-internal", "web"}},
+		{"server side", nil, RepoFilter{ExcludeArchived: true, ExcludeForks: true, Visibility: "private"}, []string{"svc-api"}},
+		{"internal", nil, RepoFilter{Visibility: "internal"}, []string{"svc-internal"}},
+		{"public", nil, RepoFilter{Visibility: "public"}, []string{"web"}},
+		{"topic", nil, RepoFilter{Topics: []string{"deploy"}}, []string{"svc-api"}},
+		{"named repos filtered", []string{"svc-fork", "web"}, RepoFilter{ExcludeForks: true}, []string{"web"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos, err := GatherRepos(ctx, g, "testorg", tt.repos, tt.filter)
+			if err != nil {
+				t.Fatalf("GatherRepos() error = %v", err)
+			}
+			if got := names(repos); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GatherRepos() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := GatherRepos(ctx, g, "testorg", []string{"missing"}, RepoFilter{}); err == nil {
+		t.Error("Expected error for missing repository")
+	}
+}
@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"go.uber.org/zap"
+)
+
+const (
+	UserActive    = "active"
+	UserSuspended = "suspended"
+	// UserNotFound is a reviewer whose account has been deleted
+	UserNotFound = "not found"
+)
+
+// ReviewerGrant is a user who can approve deployments to an environment,
+// either directly or as a member of a reviewer team.
+type ReviewerGrant struct {
+	User        string
+	Repository  string
+	Environment string
+	// GrantedBy is "User" for a direct reviewer or "Team:<slug>"
+	GrantedBy string
+	Status    string
+}
+
+var ReviewerReportColumns = []string{
+	"UserLogin",
+	"RepositoryName",
+	"EnvironmentName",
+	"GrantedBy",
+	"UserStatus",
+}
+
+// Row formats the grant in the order of ReviewerReportColumns.
+func (r ReviewerGrant) Row() []string {
+	return []string{r.User, r.Repository, r.Environment, r.GrantedBy, r.Status}
+}
+
+// ReviewerFinding is an environment whose reviewers cannot approve
+// deployments as configured.
+type ReviewerFinding struct {
+	Repository  string
+	Environment string
+	Message     string
+}
+
+type ReviewerAudit struct {
+	Grants   []ReviewerGrant
+	Findings []ReviewerFinding
+}
+
+type reviewerAuditGetter interface {
+	teamMembersGetter
+	GetUser(ctx context.Context, login string) ([]byte, error)
+}
+
+// reviewerAuditor caches team members and user statuses, since the same
+// reviewers are usually configured on many environments.
+type reviewerAuditor struct {
+	g       reviewerAuditGetter
+	owner   string
+	members map[string][]string
+	status  map[string]string
+}
+
+// AuditReviewers expands the required reviewers of each environment to the
+// users who can approve deployments to it. Environments with an empty
+// reviewer team, or without any active reviewer, are reported as findings.
+func AuditReviewers(ctx context.Context, g reviewerAuditGetter, owner string, environments []data.EnvironmentDetails) (ReviewerAudit, error) {
+	a := reviewerAuditor{g: g, owner: owner, members: make(map[string][]string), status: make(map[string]string)}
+	var audit ReviewerAudit
+
+	for _, env := range environments {
+		if len(env.Reviewers) == 0 {
+			continue
+		}
+		active := 0
+		for _, reviewer := range env.Reviewers {
+			grantedBy := "User"
+			logins := []string{reviewer.Reviewer.Login}
+			if reviewer.Type == "Team" {
+				slug := reviewer.Reviewer.Slug
+				if slug == "" {
+					slug = reviewer.Reviewer.Login
+				}
+				grantedBy = "Team:" + slug
+				members, err := a.teamMembers(ctx, slug)
+				if err != nil {
+					return audit, err
+				}
+				if len(members) == 0 {
+					audit.Findings = append(audit.Findings, ReviewerFinding{env.Repository, env.Name, fmt.Sprintf("reviewer team %s has no members", slug)})
+				}
+				logins = members
+			}
+			for _, login := range logins {
+				status, err := a.userStatus(ctx, login)
+				if err != nil {
+					return audit, err
+				}
+				if status == UserActive {
+					active++
+				}
+				audit.Grants = append(audit.Grants, ReviewerGrant{
+					User:        login,
+					Repository:  env.Repository,
+					Environment: env.Name,
+					GrantedBy:   grantedBy,
+					Status:      status,
+				})
+			}
+		}
+		if active == 0 {
+			audit.Findings = append(audit.Findings, ReviewerFinding{env.Repository, env.Name, "no active user can approve deployments"})
+		}
+	}
+
+	sort.SliceStable(audit.Grants, func(i, j int) bool {
+		x, y := audit.Grants[i], audit.Grants[j]
+		if !strings.EqualFold(x.User, y.User) {
+			return strings.ToLower(x.User) < strings.ToLower(y.User)
+		}
+		if x.Repository != y.Repository {
+			return x.Repository < y.Repository
+		}
+		return x.Environment < y.Environment
+	})
+	return audit, nil
+}
+
+func (a *reviewerAuditor) teamMembers(ctx context.Context, slug string) ([]string, error) {
+	if members, ok := a.members[slug]; ok {
+		return members, nil
+	}
+	zap.S().Debugf("Gathering members of team %s", slug)
+	members, err := GatherTeamMembers(ctx, a.g, a.owner, slug)
+	if err != nil {
+		return nil, fmt.Errorf("looking up members of team %s: %w", slug, err)
+	}
+	a.members[slug] = members
+	return members, nil
+}
+
+func (a *reviewerAuditor) userStatus(ctx context.Context, login string) (string, error) {
+	if status, ok := a.status[login]; ok {
+		return status, nil
+	}
+	status := UserActive
+	resp, err := a.g.GetUser(ctx, login)
+	if err != nil {
+		if !strings.Contains(err.Error(), "404: Not Found") {
+			return "", fmt.Errorf("looking up user %s: %w", login, err)
+		}
+		status = UserNotFound
+	} else {
+		var user data.User
+		if err := json.Unmarshal(resp, &user); err != nil {
+			return "", err
+		}
+		if user.SuspendedAt != nil {
+			status = UserSuspended
+		}
+	}
+	a.status[login] = status
+	return status, nil
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+func TestAuditReviewers(t *testing.T) {
+	ctx := context.Background()
+	server := NewMockGitHubServer()
+	server.Accounts = []data.Reviewers{{Type: "User", Reviewer: data.Reviewer{ID: 1, Login: "octocat"}}}
+	server.TeamMembers = map[string][]string{
+		"release-managers": {"hubot", "monalisa"},
+		"empty":            {},
+		"former":           {"ghost"},
+	}
+	server.SuspendedUsers = map[string]time.Time{"ghost": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	g := newMockServerGetter(t, server)
+
+	team := func(slug string) data.Reviewers {
+		return data.Reviewers{Type: "Team", Reviewer: data.Reviewer{Login: slug, Slug: slug}}
+	}
+	environments := []data.EnvironmentDetails{
+		{Repository: "app", Name: "production", Reviewers: []data.Reviewers{team("release-managers"), {Type: "User", Reviewer: data.Reviewer{ID: 1, Login: "octocat"}}}},
+		{Repository: "app", Name: "staging", Reviewers: []data.Reviewers{team("empty"), team("former")}},
+		{Repository: "web", Name: "production", Reviewers: []data.Reviewers{{Type: "User", Reviewer: data.Reviewer{Login: "deleted-user"}}}},
+		{Repository: "web", Name: "preview"},
+	}
+
+	audit, err := AuditReviewers(ctx, g, "testorg", environments)
+	if err != nil {
+		t.Fatalf("AuditReviewers() error = %v", err)
+	}
+
+	wantGrants := []ReviewerGrant{
+		{"deleted-user", "web", "production", "User", UserNotFound},
+		{"ghost", "app", "staging", "Team:former", UserSuspended},
+		{"hubot", "app", "production", "Team:release-managers", UserActive},
+		{"monalisa", "app", "production", "Team:release-managers", UserActive},
+		{"octocat", "app", "production", "User", UserActive},
+	}
+	if !reflect.DeepEqual(audit.Grants, wantGrants) {
+		t.Errorf("Grants = %+v, want %+v", audit.Grants, wantGrants)
+	}
+
+	wantFindings := []ReviewerFinding{
+		{"app", "staging", "reviewer team empty has no members"},
+		{"app", "staging", "no active user can approve deployments"},
+		{"web", "production", "no active user can approve deployments"},
+	}
+	if !reflect.DeepEqual(audit.Findings, wantFindings) {
+		t.Errorf("Findings = %+v, want %+v", audit.Findings, wantFindings)
+	}
+
+	if row := audit.Grants[1].Row(); !reflect.DeepEqual(row, []string{"ghost", "app", "staging", "Team:former", "suspended"}) {
+		t.Errorf("Unexpected row %v", row)
+	}
+}
+
+func TestGatherTeamMembers(t *testing.T) {
+	ctx := context.Background()
+	server := NewMockGitHubServer()
+	server.TeamMembers = map[string][]string{"release-managers": {"hubot", "octocat"}}
+	g := newMockServerGetter(t, server)
+
+	members, err := GatherTeamMembers(ctx, g, "testorg", "release-managers")
+	if err != nil {
+		t.Fatalf("GatherTeamMembers() error = %v", err)
+	}
+	if !reflect.DeepEqual(members, []string{"hubot", "octocat"}) {
+		t.Errorf("Expected [hubot octocat], got %v", members)
+	}
+}
@@ -1,23 +1,26 @@
 package utils
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"strconv"
 
 	"github.com/katiem0/gh-environments/internal/data"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/nacl/box"
 )
 
-func (g *APIGetter) CreateEnvironmentSecret(owner string, repo string, env string, secret string, data io.Reader) error {
+func (g *APIGetter) CreateEnvironmentSecret(ctx context.Context, owner string, repo string, env string, secret string, data io.Reader) error {
 	url := fmt.Sprintf("repos/%s/%s/environments/%s/secrets/%s", owner, repo, env, secret)
 
-	resp, err := g.restClient.Request("PUT", url, data)
+	resp, err := g.restClient.RequestWithContext(ctx, "PUT", url, data)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("HTTP error for URL %s: %v", url, err)
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
@@ -35,26 +38,30 @@ func CreateSecretData(keyID string, encryptedValue string) *data.CreateEnvSecret
 	return &s
 }
 
-func (g *APIGetter) CreateSecretList(filedata [][]string) []data.ImportedSecret {
-	// convert csv lines to array of structs
+func (g *APIGetter) CreateSecretList(filedata [][]string) ([]data.ImportedSecret, error) {
+	// convert csv lines to array of structs, mapping columns by header name
 	var secretList []data.ImportedSecret
-	var secret data.ImportedSecret
+
+	header, err := parseCSVHeader(filedata, SecretRequiredHeaders)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, each := range filedata[1:] {
-		// Skip if not enough columns
-		if len(each) < 5 {
+		var secret data.ImportedSecret
+		secret.RepositoryName = header.Value(each, "RepositoryName")
+		secret.RepositoryID, _ = strconv.Atoi(header.Value(each, "RepositoryID"))
+		secret.EnvironmentName = header.Value(each, "EnvironmentName")
+		secret.Name = header.Value(each, "SecretName")
+		secret.Value = header.Value(each, "SecretValue")
+
+		// Skip rows that do not identify a secret
+		if secret.RepositoryName == "" || secret.EnvironmentName == "" || secret.Name == "" {
 			continue
 		}
-
-		secret.RepositoryName = each[0]
-		repositoryID, _ := strconv.Atoi(each[1])
-		secret.RepositoryID = repositoryID
-		secret.EnvironmentName = each[2]
-		secret.Name = each[3]
-		secret.Value = each[4]
-
 		secretList = append(secretList, secret)
 	}
-	return secretList
+	return secretList, nil
 }
 
 func (g *APIGetter) EncryptSecret(publicKey string, secret string) (string, error) {
@@ -76,11 +83,12 @@ func (g *APIGetter) EncryptSecret(publicKey string, secret string) (string, erro
 	return encryptedValue, nil
 }
 
-func (g *APIGetter) GetEnvironmentPublicKey(owner string, repo string, env string) ([]byte, error) {
+func (g *APIGetter) GetEnvironmentPublicKey(ctx context.Context, owner string, repo string, env string) ([]byte, error) {
 	url := fmt.Sprintf("repos/%s/%s/environments/%s/secrets/public-key", owner, repo, env)
-	resp, err := g.restClient.Request("GET", url, nil)
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		log.Printf("Body read error, %v", err)
+		return nil, err
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
@@ -94,9 +102,11 @@ func (g *APIGetter) GetEnvironmentPublicKey(owner string, repo string, env strin
 	return responseData, err
 }
 
-func (g *APIGetter) GetEnvironmentSecrets(owner string, repo string, env string) ([]byte, error) {
-	url := fmt.Sprintf("repos/%s/%s/environments/%s/secrets", owner, repo, env)
-	resp, err := g.restClient.Request("GET", url, nil)
+const secretsPerPage = 100
+
+func (g *APIGetter) GetEnvironmentSecrets(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error) {
+	url := fmt.Sprintf("repos/%s/%s/environments/%s/secrets?per_page=%d&page=%d", owner, repo, env, secretsPerPage, page)
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		log.Printf("Body read error, %v", err)
 		return nil, err
@@ -112,3 +122,28 @@ func (g *APIGetter) GetEnvironmentSecrets(owner string, repo string, env string)
 	}
 	return responseData, err
 }
+
+type secretsGetter interface {
+	GetEnvironmentSecrets(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error)
+}
+
+// GatherEnvironmentSecrets returns every secret of env, reading as many pages
+// as it takes to reach the total count.
+func GatherEnvironmentSecrets(ctx context.Context, g secretsGetter, owner string, repo string, env string) ([]data.Secret, error) {
+	var secrets []data.Secret
+	for page := 1; ; page++ {
+		zap.S().Debugf("Gathering secrets for %s/%s/%s, page %d", owner, repo, env, page)
+		resp, err := g.GetEnvironmentSecrets(ctx, owner, repo, env, page)
+		if err != nil {
+			return nil, err
+		}
+		var pageSecrets data.EnvSecret
+		if err := json.Unmarshal(resp, &pageSecrets); err != nil {
+			return nil, fmt.Errorf("parsing secrets: %w", err)
+		}
+		secrets = append(secrets, pageSecrets.Secrets...)
+		if len(pageSecrets.Secrets) == 0 || len(secrets) >= pageSecrets.TotalCount {
+			return secrets, nil
+		}
+	}
+}
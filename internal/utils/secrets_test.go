@@ -2,7 +2,9 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
@@ -14,6 +16,7 @@ import (
 )
 
 func TestGetEnvironmentSecrets(t *testing.T) {
+	ctx := context.Background()
 	logger := zaptest.NewLogger(t)
 	zap.ReplaceGlobals(logger)
 
@@ -37,7 +40,7 @@ func TestGetEnvironmentSecrets(t *testing.T) {
 	getter := newAPIGetterWithMockREST(mockClient)
 
 	// Call the method
-	result, err := getter.GetEnvironmentSecrets("testorg", "testrepo", "production")
+	result, err := getter.GetEnvironmentSecrets(ctx, "testorg", "testrepo", "production", 1)
 
 	// Verify
 	if err != nil {
@@ -64,6 +67,7 @@ func TestGetEnvironmentSecrets(t *testing.T) {
 }
 
 func TestGetEnvironmentPublicKey(t *testing.T) {
+	ctx := context.Background()
 	logger := zaptest.NewLogger(t)
 	zap.ReplaceGlobals(logger)
 
@@ -87,7 +91,7 @@ func TestGetEnvironmentPublicKey(t *testing.T) {
 	getter := newAPIGetterWithMockREST(mockClient)
 
 	// Call the method
-	result, err := getter.GetEnvironmentPublicKey("testorg", "testrepo", "production")
+	result, err := getter.GetEnvironmentPublicKey(ctx, "testorg", "testrepo", "production")
 
 	// Verify
 	if err != nil {
@@ -110,6 +114,7 @@ func TestGetEnvironmentPublicKey(t *testing.T) {
 }
 
 func TestCreateEnvironmentSecret(t *testing.T) {
+	ctx := context.Background()
 	logger := zaptest.NewLogger(t)
 	zap.ReplaceGlobals(logger)
 
@@ -170,7 +175,7 @@ func TestCreateEnvironmentSecret(t *testing.T) {
 	jsonData, _ := json.Marshal(secretData)
 
 	// Call the method
-	err := getter.CreateEnvironmentSecret("testorg", "testrepo", "production", "TEST_SECRET", bytes.NewReader(jsonData))
+	err := getter.CreateEnvironmentSecret(ctx, "testorg", "testrepo", "production", "TEST_SECRET", bytes.NewReader(jsonData))
 
 	// Verify
 	if err != nil {
@@ -188,9 +193,13 @@ func TestCreateSecretList(t *testing.T) {
 	}
 
 	// Execute
-	result := g.CreateSecretList(filedata)
+	result, err := g.CreateSecretList(filedata)
 
 	// Verify
+	if err != nil {
+		t.Fatalf("CreateSecretList() error = %v", err)
+	}
+
 	if len(result) != 2 {
 		t.Errorf("Expected 2 secrets, got %d", len(result))
 		return
@@ -252,3 +261,57 @@ func TestEncryptSecret(t *testing.T) {
 		t.Error("Expected encrypted value to be base64 encoded")
 	}
 }
+
+func TestCreateSecretListByHeader(t *testing.T) {
+	g := &APIGetter{}
+	filedata := [][]string{
+		{"RepositoryID", "RepositoryName", "EnvironmentName", "SecretName", "SecretValue", "SecretCreatedAt", "SecretUpdatedAt"},
+		{"12345", "testrepo", "production", "SECRET_1", "value1", "", ""},
+		{"12345", "testrepo", "production", "", "", "", ""},
+	}
+
+	result, err := g.CreateSecretList(filedata)
+	if err != nil {
+		t.Fatalf("CreateSecretList() error = %v", err)
+	}
+
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 secret, got %d", len(result))
+	}
+
+	if result[0].RepositoryID != 12345 || result[0].RepositoryName != "testrepo" {
+		t.Errorf("Expected testrepo with ID 12345, got %s with ID %d", result[0].RepositoryName, result[0].RepositoryID)
+	}
+
+	_, err = g.CreateSecretList([][]string{{"RepositoryName", "EnvironmentName", "SecretName"}})
+	if err == nil {
+		t.Error("Expected error for missing SecretValue header, got nil")
+	}
+}
+
+func TestGatherEnvironmentSecrets(t *testing.T) {
+	ctx := context.Background()
+	server := NewMockGitHubServer()
+	env := &MockEnvironment{Name: "production"}
+	for i := 0; i < 150; i++ {
+		env.Secrets = append(env.Secrets, data.Secret{Name: fmt.Sprintf("NAME_%d", i)})
+	}
+	server.AddRepo(1, "app").Environments["production"] = env
+	requests := 0
+	server.BeforeRequest = func(req *http.Request) { requests++ }
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secrets, err := GatherEnvironmentSecrets(ctx, g, "testorg", "app", "production")
+	if err != nil {
+		t.Fatalf("GatherEnvironmentSecrets() error = %v", err)
+	}
+	if len(secrets) != 150 || secrets[149].Name != "NAME_149" {
+		t.Errorf("Expected every secret to be gathered, got %d", len(secrets))
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 pages to be read, got %d requests", requests)
+	}
+}
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"go.uber.org/zap"
+)
+
+type branchPolicyGetter interface {
+	GetDeploymentBranchPolicies(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error)
+	CreateDeploymentBranches(ctx context.Context, owner string, repo string, env string, data io.Reader) error
+	DeleteDeploymentBranchPolicy(ctx context.Context, owner string, repo string, env string, policyID int) error
+}
+
+type protectionRuleGetter interface {
+	GetAvailableDeploymentApps(ctx context.Context, owner string, repo string, env string) ([]byte, error)
+	GetDeploymentProtectionRules(ctx context.Context, owner string, repo string, env string) ([]byte, error)
+	CreateDeploymentProtectionRule(ctx context.Context, owner string, repo string, env string, data io.Reader) error
+	DeleteDeploymentProtectionRule(ctx context.Context, owner string, repo string, env string, ruleID int) error
+}
+
+// SyncDeploymentBranches adds the branch policies listed for an environment that
+// do not already exist, leaving matching policies untouched. Existing policies
+// that are not listed are only deleted when prune is set. An error is returned
+// along with the summary when any policy could not be added or deleted.
+func SyncDeploymentBranches(ctx context.Context, owner string, environment data.ImportedEnvironment, prune bool, g branchPolicyGetter) (string, error) {
+	var added, removed, failed int
+
+	target := fmt.Sprintf("%s/%s/%s", owner, environment.RepositoryName, environment.EnvironmentName)
+
+	existing, err := GatherDeploymentBranchPolicies(ctx, g, owner, environment.RepositoryName, environment.EnvironmentName)
+	if err != nil {
+		return fmt.Sprintf("Branch policies for %s: not updated", target), err
+	}
+
+	changes := DiffDeploymentBranches(existing, environment.Branches)
+
+	for _, branch := range changes.Add {
+		createEnvironmentBranch, err := json.Marshal(branch)
+		if err != nil {
+			return "", err
+		}
+		zap.S().Debugf("Adding %s policy %s to %s", branch.Type, branch.Name, target)
+		err = g.CreateDeploymentBranches(ctx, owner, environment.RepositoryName, environment.EnvironmentName, bytes.NewReader(createEnvironmentBranch))
+		if err != nil {
+			zap.S().Errorf("Error arose creating deployment policy %s for %s: %v", branch.Name, target, err)
+			failed++
+			continue
+		}
+		added++
+	}
+
+	if prune {
+		for _, policy := range changes.Remove {
+			zap.S().Debugf("Deleting %s policy %s from %s", policy.Type, policy.Name, target)
+			err = g.DeleteDeploymentBranchPolicy(ctx, owner, environment.RepositoryName, environment.EnvironmentName, policy.ID)
+			if err != nil {
+				zap.S().Errorf("Error arose deleting deployment policy %s for %s: %v", policy.Name, target, err)
+				failed++
+				continue
+			}
+			removed++
+		}
+	}
+
+	summary := fmt.Sprintf("Branch policies for %s: %d added, %d removed, %d unchanged", target, added, removed, len(changes.Unchanged))
+	if !prune && len(changes.Remove) > 0 {
+		summary += fmt.Sprintf(", %d not in file (use --prune-branch-policies to delete)", len(changes.Remove))
+	}
+	if failed > 0 {
+		summary += fmt.Sprintf(", %d failed", failed)
+		return summary, fmt.Errorf("failed to update %d branch policy(s) for %s", failed, target)
+	}
+	return summary, nil
+}
+
+// SyncProtectionRules enables the custom deployment protection rules listed for
+// an environment, resolving each app against the apps available to the
+// environment first. Enabled rules that are not listed are only disabled when
+// prune is set. An error is returned along with the summary when any rule
+// could not be enabled or disabled.
+func SyncProtectionRules(ctx context.Context, owner string, environment data.ImportedEnvironment, prune bool, g protectionRuleGetter) (string, error) {
+	var existing data.DeploymentProtectionPolicy
+	var available data.AvailableDeploymentApps
+	var added, removed, failed int
+
+	target := fmt.Sprintf("%s/%s/%s", owner, environment.RepositoryName, environment.EnvironmentName)
+	notUpdated := fmt.Sprintf("Protection rules for %s: not updated", target)
+
+	rulesResp, err := g.GetDeploymentProtectionRules(ctx, owner, environment.RepositoryName, environment.EnvironmentName)
+	if err != nil {
+		return notUpdated, err
+	}
+	if err = json.Unmarshal(rulesResp, &existing); err != nil {
+		return notUpdated, err
+	}
+
+	appsResp, err := g.GetAvailableDeploymentApps(ctx, owner, environment.RepositoryName, environment.EnvironmentName)
+	if err != nil {
+		return notUpdated, err
+	}
+	if err = json.Unmarshal(appsResp, &available); err != nil {
+		return notUpdated, err
+	}
+
+	changes := DiffProtectionRules(existing.CustomDeploymentRules, environment.ProtectionRules, available.Apps)
+
+	for _, rule := range changes.Unavailable {
+		zap.S().Errorf("App %s (%d) is not available to %s", rule.App.Slug, rule.App.IntegrationID, target)
+	}
+
+	for _, app := range changes.Add {
+		createRule, err := json.Marshal(data.CreateDeploymentProtectionRule{IntegrationID: app.ID})
+		if err != nil {
+			return "", err
+		}
+		zap.S().Debugf("Enabling protection rule for app %s on %s", app.Slug, target)
+		err = g.CreateDeploymentProtectionRule(ctx, owner, environment.RepositoryName, environment.EnvironmentName, bytes.NewReader(createRule))
+		if err != nil {
+			zap.S().Errorf("Error arose enabling protection rule for app %s on %s: %v", app.Slug, target, err)
+			failed++
+			continue
+		}
+		added++
+	}
+
+	if prune {
+		for _, rule := range changes.Remove {
+			zap.S().Debugf("Disabling protection rule for app %s on %s", rule.App.Slug, target)
+			err = g.DeleteDeploymentProtectionRule(ctx, owner, environment.RepositoryName, environment.EnvironmentName, rule.PolicyID)
+			if err != nil {
+				zap.S().Errorf("Error arose disabling protection rule for app %s on %s: %v", rule.App.Slug, target, err)
+				failed++
+				continue
+			}
+			removed++
+		}
+	}
+
+	summary := fmt.Sprintf("Protection rules for %s: %d added, %d removed, %d unchanged", target, added, removed, len(changes.Unchanged))
+	if !prune && len(changes.Remove) > 0 {
+		summary += fmt.Sprintf(", %d not in file (use --prune-protection-rules to disable)", len(changes.Remove))
+	}
+	if len(changes.Unavailable) > 0 {
+		var slugs []string
+		for _, rule := range changes.Unavailable {
+			slugs = append(slugs, rule.App.Slug)
+		}
+		summary += fmt.Sprintf(", %d unavailable (%s)", len(changes.Unavailable), strings.Join(slugs, ", "))
+	}
+	if failed > 0 {
+		summary += fmt.Sprintf(", %d failed", failed)
+		return summary, fmt.Errorf("failed to update %d protection rule(s) for %s", failed, target)
+	}
+	return summary, nil
+}
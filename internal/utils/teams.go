@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/katiem0/gh-environments/internal/data"
+)
+
+func (g *APIGetter) GetTeam(ctx context.Context, owner string, slug string) ([]byte, error) {
+	url := fmt.Sprintf("orgs/%s/teams/%s", owner, slug)
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP error for URL %s: %v", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Error closing response body: %v", closeErr)
+		}
+	}()
+	responseData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body from URL %s: %w", url, err)
+	}
+	return responseData, nil
+}
+
+func (g *APIGetter) GetUser(ctx context.Context, login string) ([]byte, error) {
+	url := fmt.Sprintf("users/%s", login)
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP error for URL %s: %v", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Error closing response body: %v", closeErr)
+		}
+	}()
+	responseData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body from URL %s: %w", url, err)
+	}
+	return responseData, nil
+}
+
+type reviewerGetter interface {
+	GetTeam(ctx context.Context, owner string, slug string) ([]byte, error)
+	GetUser(ctx context.Context, login string) ([]byte, error)
+}
+
+// ResolveReviewers looks up the IDs of the given team slugs and user logins
+// so they can be sent as environment reviewers.
+func ResolveReviewers(ctx context.Context, g reviewerGetter, owner string, teams []string, users []string) ([]data.CreateReviewer, error) {
+	var reviewers []data.CreateReviewer
+	for _, slug := range teams {
+		resp, err := g.GetTeam(ctx, owner, slug)
+		if err != nil {
+			return nil, fmt.Errorf("looking up team %s: %w", slug, err)
+		}
+		var team data.Team
+		if err := json.Unmarshal(resp, &team); err != nil {
+			return nil, err
+		}
+		reviewers = append(reviewers, data.CreateReviewer{Type: "Team", ID: team.ID})
+	}
+	for _, login := range users {
+		resp, err := g.GetUser(ctx, login)
+		if err != nil {
+			return nil, fmt.Errorf("looking up user %s: %w", login, err)
+		}
+		var user data.User
+		if err := json.Unmarshal(resp, &user); err != nil {
+			return nil, err
+		}
+		reviewers = append(reviewers, data.CreateReviewer{Type: "User", ID: user.ID})
+	}
+	return reviewers, nil
+}
+
+const teamMembersPerPage = 100
+
+// GetTeamMembers returns a page of the members of a team, including the
+// members of its child teams.
+func (g *APIGetter) GetTeamMembers(ctx context.Context, owner string, slug string, page int) ([]byte, error) {
+	url := fmt.Sprintf("orgs/%s/teams/%s/members?per_page=%d&page=%d", owner, slug, teamMembersPerPage, page)
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP error for URL %s: %v", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Error closing response body: %v", closeErr)
+		}
+	}()
+	responseData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body from URL %s: %w", url, err)
+	}
+	return responseData, nil
+}
+
+type teamMembersGetter interface {
+	GetTeamMembers(ctx context.Context, owner string, slug string, page int) ([]byte, error)
+}
+
+// GatherTeamMembers returns the logins of every member of a team.
+func GatherTeamMembers(ctx context.Context, g teamMembersGetter, owner string, slug string) ([]string, error) {
+	var logins []string
+	for page := 1; ; page++ {
+		resp, err := g.GetTeamMembers(ctx, owner, slug, page)
+		if err != nil {
+			return nil, err
+		}
+		var members []data.User
+		if err := json.Unmarshal(resp, &members); err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			logins = append(logins, member.Login)
+		}
+		if len(members) < teamMembersPerPage {
+			return logins, nil
+		}
+	}
+}
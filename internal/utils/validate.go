@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	MaxWaitTimer = 43200
+	MaxReviewers = 6
+)
+
+// ValidationError describes a single problem found in an import file. Line is
+// 1-based and counts the header row; Column is 1-based.
+type ValidationError struct {
+	Line       int
+	Column     int
+	ColumnName string
+	Message    string
+}
+
+func (e ValidationError) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("line %d, column %d (%s): %s", e.Line, e.Column, e.ColumnName, e.Message)
+}
+
+// ValidateEnvironmentList checks every row of an environments CSV file and
+// returns all errors found, rather than stopping at the first one. Columns are
+// located by header name, and optional columns are only checked when present.
+func ValidateEnvironmentList(fileData [][]string) []ValidationError {
+	var errs []ValidationError
+
+	if len(fileData) == 0 {
+		return append(errs, ValidationError{Line: 1, Message: "file is empty"})
+	}
+
+	header := NewCSVHeader(fileData[0])
+	for _, name := range header.Missing(EnvironmentRequiredHeaders) {
+		errs = append(errs, ValidationError{Line: 1, Message: fmt.Sprintf("missing required header %q", name)})
+	}
+	seen := make(map[string]bool)
+	for i, name := range fileData[0] {
+		name = strings.TrimSpace(name)
+		if seen[name] {
+			errs = append(errs, ValidationError{Line: 1, Column: i + 1, ColumnName: name, Message: "duplicate header"})
+		}
+		seen[name] = true
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	for i, row := range fileData[1:] {
+		line := i + 2
+		if len(row) != len(fileData[0]) {
+			errs = append(errs, ValidationError{Line: line, Message: fmt.Sprintf("expected %d columns, got %d", len(fileData[0]), len(row))})
+			continue
+		}
+		errs = append(errs, validateEnvironmentRow(line, header, row)...)
+	}
+	return errs
+}
+
+func validateEnvironmentRow(line int, header CSVHeader, row []string) []ValidationError {
+	var errs []ValidationError
+	fail := func(name string, format string, args ...interface{}) {
+		errs = append(errs, ValidationError{
+			Line:       line,
+			Column:     header[name] + 1,
+			ColumnName: name,
+			Message:    fmt.Sprintf(format, args...),
+		})
+	}
+	value := func(name string) string {
+		return header.Value(row, name)
+	}
+
+	if strings.TrimSpace(value("RepositoryName")) == "" {
+		fail("RepositoryName", "repository name is required")
+	}
+	if v := value("RepositoryID"); v != "" {
+		if _, err := strconv.Atoi(v); err != nil {
+			fail("RepositoryID", "%q is not an integer", v)
+		}
+	}
+	if strings.TrimSpace(value("EnvironmentName")) == "" {
+		fail("EnvironmentName", "environment name is required")
+	}
+	if v := value("AdminBypass"); v != "" {
+		if _, err := strconv.ParseBool(v); err != nil {
+			fail("AdminBypass", "%q is not a boolean", v)
+		}
+	}
+	if v := value("WaitTimer"); v != "" {
+		waitTimer, err := strconv.Atoi(v)
+		if err != nil {
+			fail("WaitTimer", "%q is not an integer", v)
+		} else if waitTimer < 0 || waitTimer > MaxWaitTimer {
+			fail("WaitTimer", "%d is outside the allowed range 0-%d", waitTimer, MaxWaitTimer)
+		}
+	}
+	if v := value("Reviewers"); v != "" {
+		reviewers := strings.Split(v, "|")
+		if len(reviewers) > MaxReviewers {
+			fail("Reviewers", "%d reviewers listed, at most %d are allowed", len(reviewers), MaxReviewers)
+		}
+		for _, reviewer := range reviewers {
+			fields := strings.Split(reviewer, ";")
+			if len(fields) != 3 {
+				fail("Reviewers", "reviewer %q is not in the format <User|Team>;Name;ID", reviewer)
+				continue
+			}
+			if fields[0] != "User" && fields[0] != "Team" {
+				fail("Reviewers", "reviewer %q has type %q, expected User or Team", reviewer, fields[0])
+			}
+			if _, err := strconv.Atoi(fields[2]); err != nil {
+				fail("Reviewers", "reviewer %q has a non-integer ID %q", reviewer, fields[2])
+			}
+		}
+	}
+	if v := value("PreventSelfReview"); v != "" {
+		if _, err := strconv.ParseBool(v); err != nil {
+			fail("PreventSelfReview", "%q is not a boolean", v)
+		}
+	}
+	policyType := value("BranchPolicyType")
+	switch policyType {
+	case "", "null", "protected", "custom":
+	default:
+		fail("BranchPolicyType", "%q is not a valid branch policy type, expected protected, custom or null", policyType)
+	}
+	if v := value("Branches"); v != "" {
+		if policyType != "custom" {
+			fail("Branches", "branches are only allowed when BranchPolicyType is custom")
+		}
+		for _, branch := range strings.Split(v, "|") {
+			fields := strings.Split(branch, ";")
+			if len(fields) != 2 || fields[0] == "" {
+				fail("Branches", "branch %q is not in the format Name;<branch|tag>", branch)
+				continue
+			}
+			if fields[1] != "branch" && fields[1] != "tag" {
+				fail("Branches", "branch %q has type %q, expected branch or tag", branch, fields[1])
+			}
+		}
+	}
+	if v := value("CustomDeploymentProtectionPolicy"); v != "" {
+		for _, rule := range strings.Split(v, "|") {
+			fields := strings.Split(rule, ";")
+			if len(fields) != 4 {
+				fail("CustomDeploymentProtectionPolicy", "rule %q is not in the format PolicyID;Enabled;AppID;AppSlug", rule)
+				continue
+			}
+			if fields[0] != "" {
+				if _, err := strconv.Atoi(fields[0]); err != nil {
+					fail("CustomDeploymentProtectionPolicy", "rule %q has a non-integer policy ID %q", rule, fields[0])
+				}
+			}
+			if fields[1] != "" {
+				if _, err := strconv.ParseBool(fields[1]); err != nil {
+					fail("CustomDeploymentProtectionPolicy", "rule %q has a non-boolean enabled value %q", rule, fields[1])
+				}
+			}
+			if fields[2] != "" {
+				if _, err := strconv.Atoi(fields[2]); err != nil {
+					fail("CustomDeploymentProtectionPolicy", "rule %q has a non-integer app ID %q", rule, fields[2])
+				}
+			}
+			if fields[2] == "" && fields[3] == "" {
+				fail("CustomDeploymentProtectionPolicy", "rule %q must include an app ID or app slug", rule)
+			}
+		}
+	}
+	return errs
+}
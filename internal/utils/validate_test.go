@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+var validateHeader = []string{"RepositoryName", "RepositoryID", "EnvironmentName", "AdminBypass", "WaitTimer", "Reviewers", "PreventSelfReview", "BranchPolicyType", "Branches", "CustomDeploymentProtectionPolicy", "SecretsTotalCount", "VariablesTotalCount"}
+
+func TestValidateEnvironmentListValid(t *testing.T) {
+	filedata := [][]string{
+		validateHeader,
+		{"testrepo", "12345", "production", "false", "5", "User;user1;1|Team;team1;2", "true", "protected", "", "", "", ""},
+		{"testrepo", "12345", "staging", "True", "", "", "false", "custom", "main;branch|v*;tag", "", "", ""},
+		{"testrepo", "", "dev", "", "0", "", "", "", "", "", "", ""},
+	}
+
+	errs := ValidateEnvironmentList(filedata)
+	if len(errs) != 0 {
+		t.Errorf("Expected no validation errors, got %v", errs)
+	}
+}
+
+func TestValidateEnvironmentListHeaders(t *testing.T) {
+	filedata := [][]string{
+		{"RepositoryID", "EnvName", "AdminBypass", "AdminBypass"},
+	}
+
+	errs := ValidateEnvironmentList(filedata)
+	if len(errs) != 3 {
+		t.Fatalf("Expected 3 header errors, got %d: %v", len(errs), errs)
+	}
+	if errs[0].Error() != `line 1: missing required header "RepositoryName"` {
+		t.Errorf("Unexpected first error: %v", errs[0])
+	}
+	if errs[2].Column != 4 || errs[2].Message != "duplicate header" {
+		t.Errorf("Unexpected duplicate header error: %v", errs[2])
+	}
+
+	errs = ValidateEnvironmentList([][]string{})
+	if len(errs) != 1 {
+		t.Errorf("Expected 1 error for empty file, got %d", len(errs))
+	}
+}
+
+func TestValidateEnvironmentListReordered(t *testing.T) {
+	filedata := [][]string{
+		{"EnvironmentName", "WaitTimer", "RepositoryName", "ExtraColumn"},
+		{"production", "abc", "testrepo", "ignored"},
+	}
+
+	errs := ValidateEnvironmentList(filedata)
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error, got %d: %v", len(errs), errs)
+	}
+	if errs[0].Line != 2 || errs[0].Column != 2 || errs[0].ColumnName != "WaitTimer" {
+		t.Errorf("Unexpected error location: %v", errs[0])
+	}
+}
+
+func TestValidateEnvironmentListRows(t *testing.T) {
+	filedata := [][]string{
+		validateHeader,
+		{"testrepo", "abc", "", "maybe", "5m", "User;user1", "yes", "all", "main", "", "", ""},
+		{"testrepo", "1", "production", "false", "50000", "User;a;1|User;b;2|User;c;3|User;d;4|User;e;5|User;f;6|Bot;g;x", "true", "protected", "main;branch", "", "", ""},
+		{"testrepo", "1", "short"},
+	}
+
+	errs := ValidateEnvironmentList(filedata)
+
+	expected := []string{
+		"line 2, column 2 (RepositoryID)",
+		"line 2, column 3 (EnvironmentName)",
+		"line 2, column 4 (AdminBypass)",
+		"line 2, column 5 (WaitTimer)",
+		"line 2, column 6 (Reviewers)",
+		"line 2, column 7 (PreventSelfReview)",
+		"line 2, column 8 (BranchPolicyType)",
+		"line 2, column 9 (Branches)",
+		"line 3, column 5 (WaitTimer): 50000 is outside the allowed range",
+		"line 3, column 6 (Reviewers): 7 reviewers listed",
+		"line 3, column 6 (Reviewers): reviewer \"Bot;g;x\" has type",
+		"line 3, column 6 (Reviewers): reviewer \"Bot;g;x\" has a non-integer ID",
+		"line 3, column 9 (Branches): branches are only allowed",
+		"line 4: expected 12 columns, got 3",
+	}
+
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	all := strings.Join(messages, "\n")
+
+	for _, want := range expected {
+		if !strings.Contains(all, want) {
+			t.Errorf("Expected an error containing %q, got:\n%s", want, all)
+		}
+	}
+}
+
+func TestValidationErrorString(t *testing.T) {
+	err := ValidationError{Line: 4, Message: "expected 12 columns, got 3"}
+	if err.Error() != "line 4: expected 12 columns, got 3" {
+		t.Errorf("Unexpected error string: %s", err.Error())
+	}
+
+	err = ValidationError{Line: 2, Column: 5, ColumnName: "WaitTimer", Message: "bad"}
+	if err.Error() != "line 2, column 5 (WaitTimer): bad" {
+		t.Errorf("Unexpected error string: %s", err.Error())
+	}
+}
+
+func TestValidateEnvironmentListProtectionRules(t *testing.T) {
+	filedata := [][]string{
+		{"RepositoryName", "EnvironmentName", "CustomDeploymentProtectionPolicy"},
+		{"testrepo", "production", "10;true;1;deploy-gate|;;;deploy-gate"},
+		{"testrepo", "staging", "x;maybe;y;|10;true|;true;;"},
+	}
+
+	errs := ValidateEnvironmentList(filedata)
+
+	var messages []string
+	for _, err := range errs {
+		if err.Line == 2 {
+			t.Errorf("Expected no errors on line 2, got %v", err)
+		}
+		messages = append(messages, err.Error())
+	}
+	all := strings.Join(messages, "\n")
+
+	for _, want := range []string{"non-integer policy ID", "non-boolean enabled value", "non-integer app ID", "is not in the format PolicyID;Enabled;AppID;AppSlug", "must include an app ID or app slug"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("Expected an error containing %q, got:\n%s", want, all)
+		}
+	}
+}
@@ -1,20 +1,23 @@
 package utils
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"strconv"
 
 	"github.com/katiem0/gh-environments/internal/data"
+	"go.uber.org/zap"
 )
 
-func (g *APIGetter) CreateEnvironmentVariables(owner string, repo string, env string, data io.Reader) error {
+func (g *APIGetter) CreateEnvironmentVariables(ctx context.Context, owner string, repo string, env string, data io.Reader) error {
 	url := fmt.Sprintf("repos/%s/%s/environments/%s/variables", owner, repo, env)
 
-	resp, err := g.restClient.Request("POST", url, data)
+	resp, err := g.restClient.RequestWithContext(ctx, "POST", url, data)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("HTTP error for URL %s: %v", url, err)
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
@@ -24,6 +27,21 @@ func (g *APIGetter) CreateEnvironmentVariables(owner string, repo string, env st
 	return err
 }
 
+func (g *APIGetter) UpdateEnvironmentVariable(ctx context.Context, owner string, repo string, env string, name string, data io.Reader) error {
+	url := fmt.Sprintf("repos/%s/%s/environments/%s/variables/%s", owner, repo, env, name)
+
+	resp, err := g.restClient.RequestWithContext(ctx, "PATCH", url, data)
+	if err != nil {
+		return fmt.Errorf("HTTP error for URL %s: %v", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Error closing response body: %v", closeErr)
+		}
+	}()
+	return nil
+}
+
 func CreateVariableData(variable data.ImportedVariable) *data.CreateVariable {
 	s := data.CreateVariable{
 		Name:  variable.Name,
@@ -32,31 +50,39 @@ func CreateVariableData(variable data.ImportedVariable) *data.CreateVariable {
 	return &s
 }
 
-func (g *APIGetter) CreateVariableList(filedata [][]string) []data.ImportedVariable {
-	// convert csv lines to array of structs
+func (g *APIGetter) CreateVariableList(filedata [][]string) ([]data.ImportedVariable, error) {
+	// convert csv lines to array of structs, mapping columns by header name
 	var variableList []data.ImportedVariable
-	var vars data.ImportedVariable
+
+	header, err := parseCSVHeader(filedata, VariableRequiredHeaders)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, each := range filedata[1:] {
-		// Check if we have enough columns
-		if len(each) < 5 {
-			continue // Skip rows with insufficient data
-		}
-		vars.RepositoryName = each[0]
-		repositoryID, _ := strconv.Atoi(each[1])
-		vars.RepositoryID = repositoryID
-		vars.EnvironmentName = each[2]
-		vars.Name = each[3]
-		vars.Value = each[4]
+		var vars data.ImportedVariable
+		vars.RepositoryName = header.Value(each, "RepositoryName")
+		vars.RepositoryID, _ = strconv.Atoi(header.Value(each, "RepositoryID"))
+		vars.EnvironmentName = header.Value(each, "EnvironmentName")
+		vars.Name = header.Value(each, "VariableName")
+		vars.Value = header.Value(each, "VariableValue")
 
+		// Skip rows that do not identify a variable
+		if vars.RepositoryName == "" || vars.EnvironmentName == "" || vars.Name == "" {
+			continue
+		}
 		variableList = append(variableList, vars)
 	}
-	return variableList
+	return variableList, nil
 }
 
-func (g *APIGetter) GetEnvironmentVariables(owner string, repo string, env string) ([]byte, error) {
-	url := fmt.Sprintf("repos/%s/%s/environments/%s/variables", owner, repo, env)
+// variablesPerPage is the most variables the API returns in a page
+const variablesPerPage = 30
 
-	resp, err := g.restClient.Request("GET", url, nil)
+func (g *APIGetter) GetEnvironmentVariables(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error) {
+	url := fmt.Sprintf("repos/%s/%s/environments/%s/variables?per_page=%d&page=%d", owner, repo, env, variablesPerPage, page)
+
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP error: %v", err)
 	}
@@ -73,3 +99,28 @@ func (g *APIGetter) GetEnvironmentVariables(owner string, repo string, env strin
 
 	return responseData, nil
 }
+
+type variablesGetter interface {
+	GetEnvironmentVariables(ctx context.Context, owner string, repo string, env string, page int) ([]byte, error)
+}
+
+// GatherEnvironmentVariables returns every variable of env, reading as many
+// pages as it takes to reach the total count.
+func GatherEnvironmentVariables(ctx context.Context, g variablesGetter, owner string, repo string, env string) ([]data.Variable, error) {
+	var variables []data.Variable
+	for page := 1; ; page++ {
+		zap.S().Debugf("Gathering variables for %s/%s/%s, page %d", owner, repo, env, page)
+		resp, err := g.GetEnvironmentVariables(ctx, owner, repo, env, page)
+		if err != nil {
+			return nil, err
+		}
+		var pageVariables data.EnvVariables
+		if err := json.Unmarshal(resp, &pageVariables); err != nil {
+			return nil, fmt.Errorf("parsing variables: %w", err)
+		}
+		variables = append(variables, pageVariables.Variables...)
+		if len(pageVariables.Variables) == 0 || len(variables) >= pageVariables.TotalCount {
+			return variables, nil
+		}
+	}
+}
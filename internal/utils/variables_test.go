@@ -2,7 +2,9 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
@@ -14,6 +16,7 @@ import (
 )
 
 func TestGetEnvironmentVariables(t *testing.T) {
+	ctx := context.Background()
 	logger := zaptest.NewLogger(t)
 	zap.ReplaceGlobals(logger)
 
@@ -37,7 +40,7 @@ func TestGetEnvironmentVariables(t *testing.T) {
 	getter := newAPIGetterWithMockREST(mockClient)
 
 	// Call the method
-	result, err := getter.GetEnvironmentVariables("testorg", "testrepo", "production")
+	result, err := getter.GetEnvironmentVariables(ctx, "testorg", "testrepo", "production", 1)
 
 	// Verify
 	if err != nil {
@@ -68,6 +71,7 @@ func TestGetEnvironmentVariables(t *testing.T) {
 }
 
 func TestCreateEnvironmentVariables(t *testing.T) {
+	ctx := context.Background()
 	logger := zaptest.NewLogger(t)
 	zap.ReplaceGlobals(logger)
 
@@ -122,7 +126,7 @@ func TestCreateEnvironmentVariables(t *testing.T) {
 	jsonData, _ := json.Marshal(varData)
 
 	// Call the method
-	err := getter.CreateEnvironmentVariables("testorg", "testrepo", "production", bytes.NewReader(jsonData))
+	err := getter.CreateEnvironmentVariables(ctx, "testorg", "testrepo", "production", bytes.NewReader(jsonData))
 
 	// Verify
 	if err != nil {
@@ -163,9 +167,13 @@ func TestCreateVariableList(t *testing.T) {
 	}
 
 	// Execute
-	result := g.CreateVariableList(filedata)
+	result, err := g.CreateVariableList(filedata)
 
 	// Verify
+	if err != nil {
+		t.Fatalf("CreateVariableList() error = %v", err)
+	}
+
 	if len(result) != 2 {
 		t.Errorf("Expected 2 variables, got %d", len(result))
 		return
@@ -201,3 +209,56 @@ func TestCreateVariableList(t *testing.T) {
 		t.Errorf("Expected VariableName VAR_2, got %s", result[1].Name)
 	}
 }
+
+func TestCreateVariableListByHeader(t *testing.T) {
+	g := &APIGetter{}
+	filedata := [][]string{
+		{"RepositoryID", "RepositoryName", "EnvironmentName", "VariableName", "VariableValue"},
+		{"12345", "testrepo", "production", "VAR_1", "value1"},
+	}
+
+	result, err := g.CreateVariableList(filedata)
+	if err != nil {
+		t.Fatalf("CreateVariableList() error = %v", err)
+	}
+
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 variable, got %d", len(result))
+	}
+
+	if result[0].RepositoryID != 12345 || result[0].RepositoryName != "testrepo" {
+		t.Errorf("Expected testrepo with ID 12345, got %s with ID %d", result[0].RepositoryName, result[0].RepositoryID)
+	}
+
+	_, err = g.CreateVariableList([][]string{{"RepositoryName", "EnvironmentName"}})
+	if err == nil {
+		t.Error("Expected error for missing variable headers, got nil")
+	}
+}
+
+func TestGatherEnvironmentVariables(t *testing.T) {
+	ctx := context.Background()
+	server := NewMockGitHubServer()
+	env := &MockEnvironment{Name: "production"}
+	for i := 0; i < 45; i++ {
+		env.Variables = append(env.Variables, data.Variable{Name: fmt.Sprintf("NAME_%d", i)})
+	}
+	server.AddRepo(1, "app").Environments["production"] = env
+	requests := 0
+	server.BeforeRequest = func(req *http.Request) { requests++ }
+	g, err := server.APIGetter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	variables, err := GatherEnvironmentVariables(ctx, g, "testorg", "app", "production")
+	if err != nil {
+		t.Fatalf("GatherEnvironmentVariables() error = %v", err)
+	}
+	if len(variables) != 45 || variables[44].Name != "NAME_44" {
+		t.Errorf("Expected every variable to be gathered, got %d", len(variables))
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 pages to be read, got %d requests", requests)
+	}
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/katiem0/gh-environments/internal/data"
+	"github.com/katiem0/gh-environments/internal/workflows"
+	"go.uber.org/zap"
+)
+
+const workflowsPath = ".github/workflows"
+
+// GetRepoContents returns a file, or the entries of a directory, on the
+// default branch of repo.
+func (g *APIGetter) GetRepoContents(ctx context.Context, owner string, repo string, path string) ([]byte, error) {
+	url := fmt.Sprintf("repos/%s/%s/contents/%s", owner, repo, path)
+	resp, err := g.restClient.RequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP error for URL %s: %v", url, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Error closing response body: %v", closeErr)
+		}
+	}()
+	responseData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body from URL %s: %w", url, err)
+	}
+	return responseData, nil
+}
+
+type contentsGetter interface {
+	GetRepoContents(ctx context.Context, owner string, repo string, path string) ([]byte, error)
+}
+
+// WorkflowFile is a workflow on the default branch of a repository.
+type WorkflowFile struct {
+	Path    string
+	Content []byte
+}
+
+// GatherWorkflowFiles returns the .yml and .yaml files in .github/workflows.
+// A repository without the directory has no workflows.
+func GatherWorkflowFiles(ctx context.Context, g contentsGetter, owner string, repo string) ([]WorkflowFile, error) {
+	resp, err := g.GetRepoContents(ctx, owner, repo, workflowsPath)
+	if err != nil {
+		if strings.Contains(err.Error(), "404: Not Found") {
+			zap.S().Debugf("No workflows found for %s/%s", owner, repo)
+			return nil, nil
+		}
+		return nil, err
+	}
+	var entries []data.RepoContent
+	if err := json.Unmarshal(resp, &entries); err != nil {
+		return nil, err
+	}
+
+	var files []WorkflowFile
+	for _, entry := range entries {
+		if entry.Type != "file" || !workflows.IsWorkflowFile(entry.Name) {
+			continue
+		}
+		resp, err := g.GetRepoContents(ctx, owner, repo, entry.Path)
+		if err != nil {
+			return nil, err
+		}
+		var file data.RepoContent
+		if err := json.Unmarshal(resp, &file); err != nil {
+			return nil, err
+		}
+		content, err := decodeContent(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Path, err)
+		}
+		files = append(files, WorkflowFile{Path: entry.Path, Content: content})
+	}
+	return files, nil
+}
+
+func decodeContent(file data.RepoContent) ([]byte, error) {
+	if file.Encoding != "base64" {
+		return []byte(file.Content), nil
+	}
+	// The API wraps base64 content at 60 characters
+	return base64.StdEncoding.DecodeString(strings.ReplaceAll(file.Content, "\n", ""))
+}
+
+// GatherWorkflowReferences parses the environment references of every
+// workflow in repo. Files that cannot be parsed are returned separately so
+// callers can decide how to treat a repository they could not fully read.
+func GatherWorkflowReferences(ctx context.Context, g contentsGetter, owner string, repo string) ([]workflows.Reference, []error, error) {
+	files, err := GatherWorkflowFiles(ctx, g, owner, repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	var refs []workflows.Reference
+	var invalid []error
+	for _, file := range files {
+		fileRefs, err := workflows.Parse(file.Path, file.Content)
+		if err != nil {
+			zap.S().Warnf("Unable to parse workflow %s/%s: %v", repo, file.Path, err)
+			invalid = append(invalid, err)
+			continue
+		}
+		refs = append(refs, fileRefs...)
+	}
+	return refs, invalid, nil
+}
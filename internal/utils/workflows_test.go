@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGatherWorkflowReferences(t *testing.T) {
+	ctx := context.Background()
+	server := NewMockGitHubServer()
+	server.AddRepo(1, "app").Workflows = map[string]string{
+		"deploy.yml": "jobs:\n  deploy:\n    environment: production\n",
+		"broken.yml": "jobs: [",
+		"README.md":  "not a workflow",
+	}
+	server.AddRepo(2, "web")
+	g := newMockServerGetter(t, server)
+
+	refs, invalid, err := GatherWorkflowReferences(ctx, g, "testorg", "app")
+	if err != nil {
+		t.Fatalf("GatherWorkflowReferences() error = %v", err)
+	}
+	if len(refs) != 1 || refs[0].Workflow != ".github/workflows/deploy.yml" || refs[0].Environment != "production" {
+		t.Errorf("Unexpected references %+v", refs)
+	}
+	if len(invalid) != 1 {
+		t.Errorf("Expected 1 invalid workflow, got %v", invalid)
+	}
+
+	refs, invalid, err = GatherWorkflowReferences(ctx, g, "testorg", "web")
+	if err != nil || refs != nil || invalid != nil {
+		t.Errorf("Expected no workflows for web, got %v, %v, %v", refs, invalid, err)
+	}
+}
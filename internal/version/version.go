@@ -0,0 +1,19 @@
+package version
+
+import "runtime/debug"
+
+// version can be set at build time with
+// -ldflags "-X github.com/katiem0/gh-environments/internal/version.version=v1.2.3"
+var version = ""
+
+// Version returns the version of this build. Without ldflags it falls back
+// to the module version that go build records from the git tag, or "dev".
+func Version() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
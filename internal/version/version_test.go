@@ -0,0 +1,15 @@
+package version
+
+import "testing"
+
+func TestVersion(t *testing.T) {
+	if got := Version(); got == "" {
+		t.Error("Expected a version")
+	}
+
+	version = "v1.2.3"
+	defer func() { version = "" }()
+	if got := Version(); got != "v1.2.3" {
+		t.Errorf("Expected the version set at build time, got %q", got)
+	}
+}
@@ -0,0 +1,187 @@
+package workflows
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Reference is a job that targets an environment.
+type Reference struct {
+	Workflow    string `json:"workflow"`
+	Job         string `json:"job"`
+	Environment string `json:"environment"`
+	// Dynamic is set when the name contains an expression, such as
+	// ${{ inputs.environment }}, that can only be resolved at run time.
+	Dynamic bool `json:"dynamic"`
+}
+
+var expression = regexp.MustCompile(`\$\{\{.*?\}\}`)
+
+// Parse returns the environment of every job in a workflow file, in the order
+// the jobs appear. The environment may be a name or a map with a name and url.
+func Parse(workflow string, content []byte) ([]Reference, error) {
+	var doc struct {
+		Jobs yaml.Node `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", workflow, err)
+	}
+	if doc.Jobs.Kind == 0 {
+		return nil, nil
+	}
+	if doc.Jobs.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: jobs must be a map", workflow)
+	}
+
+	var refs []Reference
+	for i := 0; i+1 < len(doc.Jobs.Content); i += 2 {
+		job := doc.Jobs.Content[i].Value
+		var spec struct {
+			Environment yaml.Node `yaml:"environment"`
+		}
+		if err := doc.Jobs.Content[i+1].Decode(&spec); err != nil {
+			return nil, fmt.Errorf("%s: job %s: %w", workflow, job, err)
+		}
+		name, err := environmentName(spec.Environment)
+		if err != nil {
+			return nil, fmt.Errorf("%s: job %s: %w", workflow, job, err)
+		}
+		if name == "" {
+			continue
+		}
+		refs = append(refs, Reference{
+			Workflow:    workflow,
+			Job:         job,
+			Environment: name,
+			Dynamic:     expression.MatchString(name),
+		})
+	}
+	return refs, nil
+}
+
+func environmentName(node yaml.Node) (string, error) {
+	switch node.Kind {
+	case 0:
+		return "", nil
+	case yaml.ScalarNode:
+		return strings.TrimSpace(node.Value), nil
+	case yaml.MappingNode:
+		var env struct {
+			Name string `yaml:"name"`
+		}
+		if err := node.Decode(&env); err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(env.Name), nil
+	default:
+		return "", fmt.Errorf("environment must be a name or a map with a name")
+	}
+}
+
+// Matches reports whether the reference targets env. Names are compared
+// case-insensitively, as GitHub does. Each expression in a dynamic name
+// matches any text, so ${{ inputs.environment }} matches every environment.
+func (r Reference) Matches(env string) bool {
+	if !r.Dynamic {
+		return strings.EqualFold(r.Environment, env)
+	}
+	parts := expression.Split(r.Environment, -1)
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	pattern, err := regexp.Compile("(?i)^" + strings.Join(parts, ".*") + "$")
+	return err == nil && pattern.MatchString(env)
+}
+
+// IsWorkflowFile reports whether name has a workflow file extension.
+func IsWorkflowFile(name string) bool {
+	ext := path.Ext(name)
+	return ext == ".yml" || ext == ".yaml"
+}
+
+// Target links an environment of a repository to a job that targets it. An
+// environment that no job targets has an empty Workflow and Job, and a job
+// that targets an environment that does not exist has Exists unset.
+type Target struct {
+	Repository  string `json:"repository"`
+	Environment string `json:"environment"`
+	Exists      bool   `json:"exists"`
+	Workflow    string `json:"workflow,omitempty"`
+	Job         string `json:"job,omitempty"`
+	Dynamic     bool   `json:"dynamic"`
+}
+
+// CrossReference maps the environments of repo to the jobs in refs. Each
+// environment is listed with every job whose name matches it, followed by
+// references that match no environment. Dynamic references that match no
+// environment are listed with their expression as the name.
+func CrossReference(repo string, environments []string, refs []Reference) []Target {
+	var targets []Target
+	matched := make([]bool, len(refs))
+	for _, env := range environments {
+		found := false
+		for i, ref := range refs {
+			if !ref.Matches(env) {
+				continue
+			}
+			found = true
+			matched[i] = true
+			targets = append(targets, Target{
+				Repository:  repo,
+				Environment: env,
+				Exists:      true,
+				Workflow:    ref.Workflow,
+				Job:         ref.Job,
+				Dynamic:     ref.Dynamic,
+			})
+		}
+		if !found {
+			targets = append(targets, Target{Repository: repo, Environment: env, Exists: true})
+		}
+	}
+	for i, ref := range refs {
+		if matched[i] {
+			continue
+		}
+		targets = append(targets, Target{
+			Repository:  repo,
+			Environment: ref.Environment,
+			Workflow:    ref.Workflow,
+			Job:         ref.Job,
+			Dynamic:     ref.Dynamic,
+		})
+	}
+	return targets
+}
+
+// Missing reports whether t is a job targeting an environment that does not
+// exist, which GitHub creates without protection rules when the job runs.
+func (t Target) Missing() bool {
+	return !t.Exists && !t.Dynamic && t.Job != ""
+}
+
+var TargetReportColumns = []string{
+	"RepositoryName",
+	"EnvironmentName",
+	"EnvironmentExists",
+	"Workflow",
+	"Job",
+	"Dynamic",
+}
+
+// Row formats the target in the order of TargetReportColumns.
+func (t Target) Row() []string {
+	return []string{
+		t.Repository,
+		t.Environment,
+		strconv.FormatBool(t.Exists),
+		t.Workflow,
+		t.Job,
+		strconv.FormatBool(t.Dynamic),
+	}
+}
@@ -0,0 +1,111 @@
+package workflows
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	content := `
+name: Deploy
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+  staging:
+    environment: staging
+  production:
+    environment:
+      name: production
+      url: https://example.com
+  preview:
+    environment: ${{ inputs.environment }}
+  regional:
+    environment:
+      name: deploy-${{ matrix.region }}
+`
+	refs, err := Parse(".github/workflows/deploy.yml", []byte(content))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := []Reference{
+		{Workflow: ".github/workflows/deploy.yml", Job: "staging", Environment: "staging"},
+		{Workflow: ".github/workflows/deploy.yml", Job: "production", Environment: "production"},
+		{Workflow: ".github/workflows/deploy.yml", Job: "preview", Environment: "${{ inputs.environment }}", Dynamic: true},
+		{Workflow: ".github/workflows/deploy.yml", Job: "regional", Environment: "deploy-${{ matrix.region }}", Dynamic: true},
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("Parse() = %+v, want %+v", refs, want)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	invalid := map[string]string{
+		"yaml":        "jobs: [",
+		"jobs list":   "jobs:\n  - build\n",
+		"environment": "jobs:\n  deploy:\n    environment: [production]\n",
+	}
+	for name, content := range invalid {
+		if _, err := Parse("ci.yml", []byte(content)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	refs, err := Parse("empty.yml", []byte("name: Empty\non: push\n"))
+	if err != nil || refs != nil {
+		t.Errorf("Expected no references for a workflow without jobs, got %v, %v", refs, err)
+	}
+}
+
+func TestReferenceMatches(t *testing.T) {
+	tests := []struct {
+		ref  Reference
+		env  string
+		want bool
+	}{
+		{Reference{Environment: "Production"}, "production", true},
+		{Reference{Environment: "production"}, "production-eu", false},
+		{Reference{Environment: "${{ inputs.environment }}", Dynamic: true}, "anything", true},
+		{Reference{Environment: "deploy-${{ matrix.region }}", Dynamic: true}, "Deploy-EU", true},
+		{Reference{Environment: "deploy-${{ matrix.region }}", Dynamic: true}, "staging", false},
+		{Reference{Environment: "a.b-${{ x }}", Dynamic: true}, "axb-1", false},
+	}
+	for _, tt := range tests {
+		if got := tt.ref.Matches(tt.env); got != tt.want {
+			t.Errorf("%q.Matches(%q) = %v, want %v", tt.ref.Environment, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestCrossReference(t *testing.T) {
+	refs := []Reference{
+		{Workflow: "deploy.yml", Job: "prod", Environment: "Production"},
+		{Workflow: "deploy.yml", Job: "qa", Environment: "qa"},
+		{Workflow: "preview.yml", Job: "preview", Environment: "pr-${{ github.event.number }}", Dynamic: true},
+		{Workflow: "manual.yml", Job: "run", Environment: "${{ inputs.target }}", Dynamic: true},
+	}
+	got := CrossReference("app", []string{"production", "staging"}, refs)
+	want := []Target{
+		{Repository: "app", Environment: "production", Exists: true, Workflow: "deploy.yml", Job: "prod"},
+		{Repository: "app", Environment: "production", Exists: true, Workflow: "manual.yml", Job: "run", Dynamic: true},
+		{Repository: "app", Environment: "staging", Exists: true, Workflow: "manual.yml", Job: "run", Dynamic: true},
+		{Repository: "app", Environment: "qa", Workflow: "deploy.yml", Job: "qa"},
+		{Repository: "app", Environment: "pr-${{ github.event.number }}", Workflow: "preview.yml", Job: "preview", Dynamic: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("CrossReference() = %+v, want %+v", got, want)
+	}
+
+	var missing []string
+	for _, target := range got {
+		if target.Missing() {
+			missing = append(missing, target.Environment)
+		}
+	}
+	if !reflect.DeepEqual(missing, []string{"qa"}) {
+		t.Errorf("Expected only qa to be missing, got %v", missing)
+	}
+	if row := got[3].Row(); !reflect.DeepEqual(row, []string{"app", "qa", "false", "deploy.yml", "qa", "false"}) {
+		t.Errorf("Unexpected row %v", row)
+	}
+}
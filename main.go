@@ -1,15 +1,25 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/katiem0/gh-environments/cmd"
+	"github.com/katiem0/gh-environments/internal/exitcode"
 )
 
 func main() {
+	// Interrupting a run cancels its requests, so reports are cut short
+	// rather than lost
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Instantiate and execute root command
 	cmd := cmd.NewCmdRoot()
-	if err := cmd.Execute(); err != nil {
-		os.Exit(1)
+	if err := cmd.ExecuteContext(ctx); err != nil {
+		stop()
+		os.Exit(exitcode.Code(err))
 	}
 }